@@ -5,3 +5,7 @@ go 1.26.0 // GOVERSION
 require gopkg.in/yaml.v3 v3.0.1
 
 require github.com/google/jsonschema-go v0.4.3
+
+require github.com/titanous/json5 v1.0.0
+
+require golang.org/x/text v0.40.0
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+// cacheFileName is the repository-root file holding the validation cache.
+const cacheFileName = ".datacur8.cache"
+
+// fileCache maps repo-relative file paths to the parsed items of files that
+// last parsed and passed schema validation cleanly. It is invalidated as a
+// whole when the config contents or the CLI version change.
+type fileCache struct {
+	Version    string                    `json:"version"`
+	ConfigHash string                    `json:"config_hash"`
+	Files      map[string]fileCacheEntry `json:"files"`
+	next       map[string]fileCacheEntry // entries to persist after this run
+	checked    map[string]bool           // files looked up during this run
+}
+
+// fileCacheEntry identifies a file by size and modification time and records
+// its parsed items.
+type fileCacheEntry struct {
+	Type    string           `json:"type"`
+	Size    int64            `json:"size"`
+	ModTime int64            `json:"mod_time"`
+	Items   []map[string]any `json:"items"`
+}
+
+// loadFileCache reads the cache at path. A missing, unreadable, or stale cache
+// yields an empty cache rather than an error.
+func loadFileCache(path string, configData []byte, version string) *fileCache {
+	hash := sha256.Sum256(configData)
+	fresh := &fileCache{
+		Version:    version,
+		ConfigHash: hex.EncodeToString(hash[:]),
+		Files:      map[string]fileCacheEntry{},
+		next:       map[string]fileCacheEntry{},
+		checked:    map[string]bool{},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fresh
+	}
+
+	var stored fileCache
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep large integers in cached items exact
+	if err := dec.Decode(&stored); err != nil {
+		return fresh
+	}
+	if stored.Version != fresh.Version || stored.ConfigHash != fresh.ConfigHash || stored.Files == nil {
+		return fresh
+	}
+
+	for _, entry := range stored.Files {
+		for _, item := range entry.Items {
+			exactNumbers(item)
+		}
+	}
+	fresh.Files = stored.Files
+	return fresh
+}
+
+// lookup returns the cached items for f when its size and modification time
+// are unchanged since they were cached. It marks f as checked in this run,
+// so a miss that does not end in store drops f's entry on save.
+func (c *fileCache) lookup(f discovery.DiscoveredFile, info os.FileInfo) ([]map[string]any, bool) {
+	c.checked[f.Path] = true
+	entry, ok := c.Files[f.Path]
+	if !ok || entry.Type != f.TypeName || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return nil, false
+	}
+	c.next[f.Path] = entry
+	return entry.Items, true
+}
+
+// store records the parsed items of a file that passed parsing and schema validation.
+func (c *fileCache) store(f discovery.DiscoveredFile, info os.FileInfo, items []map[string]any) {
+	c.next[f.Path] = fileCacheEntry{
+		Type:    f.TypeName,
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Items:   items,
+	}
+}
+
+// save writes the cache to path, which sits at the repository root. Entries
+// of files this run did not check, such as those outside --since, are kept
+// while the file still exists; files that were checked and no longer
+// validate are dropped.
+func (c *fileCache) save(path string) error {
+	root := filepath.Dir(path)
+	for rel, entry := range c.Files {
+		if _, ok := c.next[rel]; ok || c.checked[rel] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel))); err == nil {
+			c.next[rel] = entry
+		}
+	}
+	c.Files = c.next
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+const cacheTestConfig = `version: "0.0.0"
+cache:
+  enabled: true
+types:
+  - name: item
+    input: json
+    match:
+      include: ["^data/.*\\.json$"]
+    schema:
+      type: object
+      required: ["id"]
+      properties:
+        id: { type: string }
+    constraints:
+      - type: unique
+        key: "$.id"
+`
+
+func writeCacheTestFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countReads replaces readDataFile for the duration of the test and returns a
+// pointer to the number of data files read.
+func countReads(t *testing.T) *int {
+	t.Helper()
+	reads := 0
+	var mu sync.Mutex
+	orig := readDataFile
+	readDataFile = func(name string, limit int64) ([]byte, error) {
+		mu.Lock()
+		reads++
+		mu.Unlock()
+		return orig(name, limit)
+	}
+	t.Cleanup(func() { readDataFile = orig })
+	return &reads
+}
+
+func TestLoadFileCache_ExactIntegers(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, cacheFileName)
+	config := []byte(cacheTestConfig)
+	writeCacheTestFile(t, root, "data/a.json", `{"id": 1234567890123456789}`)
+	info, err := os.Stat(filepath.Join(root, "data", "a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := discovery.DiscoveredFile{Path: "data/a.json", TypeName: "item"}
+
+	c := loadFileCache(path, config, "dev")
+	c.store(f, info, []map[string]any{{"id": 1234567890123456789}})
+	if err := c.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	items, ok := loadFileCache(path, config, "dev").lookup(f, info)
+	if !ok || items[0]["id"] != 1234567890123456789 {
+		t.Fatalf("cached items = %#v, %v", items, ok)
+	}
+}
+
+func TestFileCache_SaveKeepsUncheckedFiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, cacheFileName)
+	config := []byte(cacheTestConfig)
+	c := loadFileCache(path, config, "dev")
+	for _, rel := range []string{"data/a.json", "data/b.json", "data/c.json"} {
+		writeCacheTestFile(t, root, rel, `{"id": "1"}`)
+		info, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.store(discovery.DiscoveredFile{Path: rel, TypeName: "item"}, info, []map[string]any{{"id": "1"}})
+	}
+	if err := c.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run checks only a.json, which now fails, and c.json is deleted
+	// without being checked; b.json is outside the run and must survive.
+	if err := os.Remove(filepath.Join(root, "data", "c.json")); err != nil {
+		t.Fatal(err)
+	}
+	writeCacheTestFile(t, root, "data/a.json", `{"id": 1}`)
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "data", "a.json"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(root, "data", "a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = loadFileCache(path, config, "dev")
+	if _, ok := c.lookup(discovery.DiscoveredFile{Path: "data/a.json", TypeName: "item"}, info); ok {
+		t.Fatal("expected a cache miss for the modified data/a.json")
+	}
+	if err := c.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got := slices.Sorted(maps.Keys(loadFileCache(path, config, "dev").Files))
+	if want := []string{"data/b.json"}; !slices.Equal(got, want) {
+		t.Fatalf("cached files = %v, want %v", got, want)
+	}
+}
+
+func TestRunValidate_CacheSkipsUnchangedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeCacheTestFile(t, root, ".datacur8", cacheTestConfig)
+	writeCacheTestFile(t, root, "data/a.json", `{"id": "1"}`)
+	writeCacheTestFile(t, root, "data/b.json", `{"id": "1"}`)
+	t.Chdir(root)
+
+	reads := countReads(t)
+
+	if code := RunValidate(false, Options{Version: "dev"}); code != ExitDataInvalid {
+		t.Fatalf("first run exit code = %d, want %d", code, ExitDataInvalid)
+	}
+	if *reads != 2 {
+		t.Fatalf("first run read %d files, want 2", *reads)
+	}
+	if _, err := os.Stat(filepath.Join(root, cacheFileName)); err != nil {
+		t.Fatalf("expected cache file to be written: %v", err)
+	}
+
+	// Unchanged tree: nothing is re-parsed, but the cross-file duplicate is still reported.
+	*reads = 0
+	if code := RunValidate(false, Options{Version: "dev"}); code != ExitDataInvalid {
+		t.Fatalf("second run exit code = %d, want %d", code, ExitDataInvalid)
+	}
+	if *reads != 0 {
+		t.Fatalf("second run read %d files, want 0", *reads)
+	}
+
+	// A modified file is re-parsed and the fix is observed.
+	writeCacheTestFile(t, root, "data/b.json", `{"id": "2"}`)
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "data/b.json"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	*reads = 0
+	if code := RunValidate(false, Options{Version: "dev"}); code != ExitOK {
+		t.Fatalf("third run exit code = %d, want %d", code, ExitOK)
+	}
+	if *reads != 1 {
+		t.Fatalf("third run read %d files, want 1", *reads)
+	}
+}
+
+func TestRunValidate_NoCacheForcesFullValidation(t *testing.T) {
+	root := t.TempDir()
+	writeCacheTestFile(t, root, ".datacur8", cacheTestConfig)
+	writeCacheTestFile(t, root, "data/a.json", `{"id": "1"}`)
+	t.Chdir(root)
+
+	reads := countReads(t)
+
+	if code := RunValidate(false, Options{Version: "dev"}); code != ExitOK {
+		t.Fatalf("first run exit code = %d, want %d", code, ExitOK)
+	}
+	*reads = 0
+	if code := RunValidate(false, Options{Version: "dev", NoCache: true}); code != ExitOK {
+		t.Fatalf("no-cache run exit code = %d, want %d", code, ExitOK)
+	}
+	if *reads != 1 {
+		t.Fatalf("no-cache run read %d files, want 1", *reads)
+	}
+}
+
+func TestRunValidate_CacheInvalidatedByVersion(t *testing.T) {
+	root := t.TempDir()
+	writeCacheTestFile(t, root, ".datacur8", cacheTestConfig)
+	writeCacheTestFile(t, root, "data/a.json", `{"id": "1"}`)
+	t.Chdir(root)
+
+	reads := countReads(t)
+
+	if code := RunValidate(false, Options{Version: "dev"}); code != ExitOK {
+		t.Fatalf("first run exit code = %d, want %d", code, ExitOK)
+	}
+	*reads = 0
+	if code := RunValidate(false, Options{Version: "0.0.1"}); code != ExitOK {
+		t.Fatalf("second run exit code = %d, want %d", code, ExitOK)
+	}
+	if *reads != 1 {
+		t.Fatalf("run with new CLI version read %d files, want 1", *reads)
+	}
+}
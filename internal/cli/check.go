@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// checkPriority orders the exit codes RunCheck can combine, most important
+// first: a broken config or tree hides everything else, and invalid data
+// matters more than formatting.
+var checkPriority = []int{ExitConfigInvalid, ExitDiscoveryError, ExitDataInvalid, ExitTidyFailure, ExitTidyCheckDiff}
+
+// RunCheck runs the check command: tidy in check mode, then validate, as one
+// invocation for a pre-commit hook. Tidy names the files that need formatting
+// rather than printing diffs. Validate is skipped when tidy could not load the
+// config or discover files, since it would fail the same way. The entries of
+// both stages are reported once, in the requested format, without the
+// duplicates of a file that neither could parse. Unless opts.Quiet, a line
+// per stage is printed to stderr at the end.
+// Returns the highest-priority exit code of the stages that ran.
+func RunCheck(opts Options) int {
+	rep, ok := newReporter(opts)
+	if !ok {
+		return ExitConfigInvalid
+	}
+	rep.root, _ = opts.rootDir() // an invalid root is reported by tidy
+
+	var entries []reportEntry
+	opts.listUntidy = true
+	opts.collect = &entries
+	tidyCode := RunTidy(false, false, opts)
+
+	validateCode := -1 // not run
+	if tidyCode != ExitConfigInvalid && tidyCode != ExitDiscoveryError {
+		opts.quietWarnings = true
+		validateCode = RunValidate(false, opts)
+	}
+
+	entries = dedupeEntries(entries)
+	if opts.SortOutput {
+		sortEntries(entries)
+	}
+	if len(entries) > 0 {
+		rep.report(entries)
+	}
+
+	if !opts.Quiet {
+		fmt.Fprintf(os.Stderr, "check: tidy %s\n", checkStageResult(tidyCode))
+		fmt.Fprintf(os.Stderr, "check: validate %s\n", checkStageResult(validateCode))
+	}
+	return combineCheckCodes(tidyCode, validateCode)
+}
+
+// dedupeEntries drops each entry whose level, file, row, and message repeat
+// an earlier entry, such as a parse error reported by both tidy and validate.
+// The first entry is kept, since tidy also names the type.
+func dedupeEntries(entries []reportEntry) []reportEntry {
+	type entryKey struct {
+		level, file, message string
+		row                  int
+	}
+	seen := make(map[entryKey]bool, len(entries))
+	return slices.DeleteFunc(entries, func(e reportEntry) bool {
+		k := entryKey{level: e.Level, file: e.File, message: e.Message, row: -1}
+		if e.Row != nil {
+			k.row = *e.Row
+		}
+		if seen[k] {
+			return true
+		}
+		seen[k] = true
+		return false
+	})
+}
+
+// checkStageResult describes a stage's exit code for the check summary.
+func checkStageResult(code int) string {
+	switch code {
+	case -1:
+		return "skipped"
+	case ExitOK:
+		return "passed"
+	default:
+		return fmt.Sprintf("failed (exit %d)", code)
+	}
+}
+
+// combineCheckCodes returns the code of codes that comes first in
+// checkPriority, or ExitOK when none of them failed.
+func combineCheckCodes(codes ...int) int {
+	for _, c := range checkPriority {
+		if slices.Contains(codes, c) {
+			return c
+		}
+	}
+	for _, c := range codes {
+		if c > ExitOK {
+			return c
+		}
+	}
+	return ExitOK
+}
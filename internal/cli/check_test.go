@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCombineCheckCodes_PicksHighestPriority(t *testing.T) {
+	tests := []struct {
+		name           string
+		tidy, validate int
+		want           int
+	}{
+		{"both pass", ExitOK, ExitOK, ExitOK},
+		{"tidy diff only", ExitTidyCheckDiff, ExitOK, ExitTidyCheckDiff},
+		{"validate fails", ExitOK, ExitDataInvalid, ExitDataInvalid},
+		{"data beats tidy diff", ExitTidyCheckDiff, ExitDataInvalid, ExitDataInvalid},
+		{"tidy failure beats tidy diff", ExitTidyFailure, ExitOK, ExitTidyFailure},
+		{"config skips validate", ExitConfigInvalid, -1, ExitConfigInvalid},
+		{"discovery beats data", ExitTidyFailure, ExitDiscoveryError, ExitDiscoveryError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combineCheckCodes(tt.tidy, tt.validate); got != tt.want {
+				t.Errorf("combineCheckCodes(%d, %d) = %d, want %d", tt.tidy, tt.validate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCheck_ReportsEachEntryOnce(t *testing.T) {
+	root := t.TempDir()
+	cfgText := `version: "0.0.0"
+types:
+  - name: item
+    input: json
+    match:
+      include: ["^data/.*\\.json$"]
+    schema:
+      type: object
+    constraints:
+      - type: unique
+        key: "$.id"
+`
+	writeCacheTestFile(t, root, ".datacur8", cfgText)
+	writeCacheTestFile(t, root, "data/bad.json", `{"id": "a",}`+"\n")
+	writeCacheTestFile(t, root, "data/a.json", "{\n  \"id\": \"dup\"\n}\n")
+	writeCacheTestFile(t, root, "data/b.json", "{\n  \"id\": \"dup\"\n}\n")
+
+	report := filepath.Join(t.TempDir(), "report.json")
+	opts := Options{Root: root, Version: "dev", Format: "json", ReportFile: report, Quiet: true}
+	if code := RunCheck(opts); code != ExitDataInvalid {
+		t.Fatalf("exit code = %d, want %d", code, ExitDataInvalid)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []reportEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("report is not one JSON document: %v\n%s", err, data)
+	}
+
+	// bad.json fails to parse in both stages but is reported once
+	files := make(map[string]int)
+	for _, e := range entries {
+		files[e.File]++
+	}
+	if len(entries) != 3 || files["data/bad.json"] != 1 || files["data/a.json"] != 1 || files["data/b.json"] != 1 {
+		t.Errorf("expected one entry per failing file, got %+v", entries)
+	}
+	if entries[0].File != "data/bad.json" || entries[0].Type != "item" {
+		t.Errorf("expected tidy's parse error, with its type, first; got %+v", entries[0])
+	}
+}
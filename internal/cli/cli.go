@@ -3,48 +3,93 @@ package cli
 import (
 	"bytes"
 	"encoding/csv"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
 	"github.com/UnitVectorY-Labs/datacur8/internal/constraints"
 	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
 	"github.com/UnitVectorY-Labs/datacur8/internal/export"
+	"github.com/UnitVectorY-Labs/datacur8/internal/fix"
 	"github.com/UnitVectorY-Labs/datacur8/internal/schema"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
 	"github.com/UnitVectorY-Labs/datacur8/internal/tidy"
+	"golang.org/x/text/transform"
 	"gopkg.in/yaml.v3"
 )
 
 // Exit codes
 const (
-	ExitOK            = 0
-	ExitConfigInvalid = 1
-	ExitDataInvalid   = 2
-	ExitExportFailure = 3
-	ExitTidyFailure   = 4
-	ExitTidyCheckDiff = 5
+	ExitOK             = 0
+	ExitConfigInvalid  = 1
+	ExitDataInvalid    = 2
+	ExitExportFailure  = 3
+	ExitTidyFailure    = 4
+	ExitTidyCheckDiff  = 5
+	ExitDiscoveryError = 6
 )
 
-// reportEntry is a structured error/warning for JSON/YAML output.
-type reportEntry struct {
-	Level   string `json:"level" yaml:"level"`
-	Type    string `json:"type,omitempty" yaml:"type,omitempty"`
-	File    string `json:"file,omitempty" yaml:"file,omitempty"`
-	Row     *int   `json:"row,omitempty" yaml:"row,omitempty"`
-	Message string `json:"message" yaml:"message"`
+// Options holds the flags shared by the validate, export, and tidy commands.
+type Options struct {
+	Format       string // output format (text, json, yaml, csv) - from --format flag
+	FormatByType bool   // nest json/yaml report entries under their type name
+	Color        string // always, never, or auto (default) - colorize text reports and tidy diffs
+	PathStyle    string // relative (default) or absolute - how reported file paths are written
+	NoCache      bool   // validate only: ignore and do not update the file cache
+	Jobs         int    // validate/export: max concurrent file parsers; < 1 means GOMAXPROCS
+	Profile      bool   // validate/export: print per-stage timings to stderr
+	Since        string // validate only: git ref; report only files changed since it
+	Root         string // base directory for .datacur8, discovery, and outputs; "" means the working directory
+	Config       string // http(s) URL of the config to use instead of .datacur8 in Root; "" means .datacur8
+	StrictConfig bool   // validate only: report config.Lint findings as errors instead of warnings
+	Export       bool   // validate only: export the validated items when validation passes
+	Fix          bool   // validate only: rewrite values that have a single mechanical fix, then re-validate
+	SortOutput   bool   // validate/export: sort report entries by level, file, row, and message
+	Quiet        bool   // check only: print failures but not the per-stage summary
+	Verbose      bool   // plan only: also list each matched file and the files excluded by match.exclude
+	DiffContext  int    // tidy only: unchanged lines shown around each change in check-mode diffs; < 0 shows whole files
+	Version      string // CLI version string
+
+	SkipVersionCheck bool   // do not compare the config version with Version
+	LenientConfig    bool   // drop unknown top-level config keys with a warning instead of failing
+	FilesFrom        string // validate/tidy/plan: file listing the candidate paths; discovery does not walk the tree
+	ReportFile       string // also write the report (json unless --format is ndjson, yaml, or csv) to this file
+	ReportStdout     bool   // with ReportFile: still print the report as usual; false writes it only to the file
+
+	// set by RunCheck: tidy names files that need formatting instead of
+	// printing diffs, validate does not repeat the config warnings, and both
+	// add their report entries to collect for RunCheck to report once
+	listUntidy    bool
+	quietWarnings bool
+	collect       *[]reportEntry
+
+	onExcluded func(discovery.ExcludedFile) // set by RunPlan with Verbose; passed to discovery
 }
 
-// RunValidate runs the validate command.
+// RunValidate runs the validate command. With opts.Fix, values with a single
+// mechanical fix are rewritten in their files and the files validated again
+// before anything is reported. With opts.Export, a clean validation goes on to
+// export the items it already parsed, as the export command would.
 // configOnly: if true, only validate config, not data.
-// format: output format (text, json, yaml) - from --format flag, overrides config.
-// version: CLI version string.
+// opts: shared command options.
 // Returns exit code.
-func RunValidate(configOnly bool, format string, version string) int {
-	cfg, resolvedFormat, code := loadAndValidateConfig(format, version)
+func RunValidate(configOnly bool, opts Options) int {
+	prof := newProfile(opts.Profile)
+	start := time.Now()
+	cfg, rep, code := loadAndValidateConfig(opts)
+	prof.record("config", time.Since(start), 0, 0)
+	defer prof.write(os.Stderr, rep.format)
 	if code != ExitOK {
 		return code
 	}
@@ -58,35 +103,230 @@ func RunValidate(configOnly bool, format string, version string) int {
 		return ExitOK
 	}
 
-	rootDir, _ := os.Getwd()
-	files, discoverErrs := discovery.Discover(rootDir, cfg.Types)
+	rootDir, _ := opts.rootDir() // checked by loadAndValidateConfig
+	start = time.Now()
+	files, discoverErrs := discover(rootDir, cfg, opts)
+	prof.record("discovery", time.Since(start), len(files), 0)
+	unreadable, discoverErrs := splitUnreadable(discoverErrs)
 	if len(discoverErrs) > 0 {
-		reportErrors(resolvedFormat, toReportEntries("error", "discovery", discoverErrs))
-		return ExitConfigInvalid
+		rep.report(discoveryErrorsToEntries(discoverErrs))
+		return ExitDiscoveryError
+	}
+
+	var changed, partial map[string]bool
+	if opts.Since != "" {
+		var err error
+		changed, err = gitChangedFiles(rootDir, opts.Since)
+		if err != nil {
+			rep.report([]reportEntry{{Level: "error", Type: "discovery", Message: err.Error()}})
+			return ExitConfigInvalid
+		}
+		narrowed := sinceFiles(files, changed, cfg.Types)
+		partial = partialTypes(files, narrowed)
+		files = narrowed
+	}
+
+	warnings := deprecationWarnings(files)
+	if opts.Since != "" {
+		warnings = append(onlyChangedEntries(warnings, changed), sinceWarnings(files, changed, cfg.Types)...)
+	}
+
+	var cache *fileCache
+	cachePath := filepath.Join(rootDir, cacheFileName)
+	if cfg.Cache.IsEnabled() && !opts.NoCache {
+		// Key the cache on the resolved config so changes to an extended base
+		// config also invalidate it.
+		configData, _ := yaml.Marshal(cfg)
+		cache = loadFileCache(cachePath, configData, opts.Version)
 	}
 
-	items, parseEntries, schemaEntries := parseAndValidateFiles(files, cfg)
+	items, parseEntries, schemaEntries := parseAndValidateFiles(rootDir, files, cfg, cache, opts.Jobs, prof)
+
+	if cache != nil {
+		if err := cache.save(cachePath); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: writing %s: %v\n", cacheFileName, err)
+		}
+	}
 
-	constraintErrs := constraints.Evaluate(items, cfg.Types)
+	start = time.Now()
+	constraintErrs := constraints.EvaluatePartial(rootDir, items, cfg.Types, partial)
+	prof.record("constraints", time.Since(start), 0, countItems(items))
+
+	if opts.Fix {
+		start = time.Now()
+		fixed := applyFixes(rootDir, files, items, cfg.Types, changed)
+		prof.record("fix", time.Since(start), fixed, 0)
+		if fixed > 0 {
+			// The cache is bypassed: it was saved before the files were rewritten.
+			items, parseEntries, schemaEntries = parseAndValidateFiles(rootDir, files, cfg, nil, opts.Jobs, prof)
+			constraintErrs = constraints.EvaluatePartial(rootDir, items, cfg.Types, partial)
+		}
+	}
 	constraintEntries := constraintErrorsToEntries(constraintErrs)
 
-	allEntries := append(parseEntries, schemaEntries...)
+	allEntries := append(warnings, parseEntries...)
+	allEntries = append(allEntries, schemaEntries...)
 	allEntries = append(allEntries, constraintEntries...)
+	if opts.Since != "" {
+		// Files loaded only to resolve foreign keys are not reported.
+		allEntries = onlyChangedEntries(allEntries, changed)
+	}
+	allEntries = append(unreadable, allEntries...)
+	if opts.SortOutput {
+		sortEntries(allEntries)
+	}
+
+	if len(allEntries) > 0 {
+		rep.report(allEntries)
+	}
+	if hasErrorEntries(allEntries) {
+		return ExitDataInvalid
+	}
+
+	if opts.Export {
+		return exportItems(cfg, items, rootDir, rep, prof)
+	}
+	return ExitOK
+}
+
+// stdinPath is the file name reported for data read by validate --stdin.
+const stdinPath = "<stdin>"
+
+// RunValidateStdin runs validate --stdin: the data read from r is parsed as a
+// single file of the named type and validated against its schema and the
+// constraints that can be evaluated without other files. foreign_key,
+// count_equals, path_equals_attr, path_template_equals_attr, and sequence
+// constraints, and those reading a $path selector, are skipped with a warning.
+// Returns exit code.
+func RunValidateStdin(typeName string, r io.Reader, opts Options) int {
+	cfg, rep, code := loadAndValidateConfig(opts)
+	if code != ExitOK {
+		return code
+	}
+
+	var td *config.TypeDef
+	for i := range cfg.Types {
+		if cfg.Types[i].Name == typeName {
+			td = &cfg.Types[i]
+			break
+		}
+	}
+	if td == nil {
+		rep.report([]reportEntry{{Level: "error", Type: "config", Message: fmt.Sprintf("--type %q does not match any defined type", typeName)}})
+		return ExitConfigInvalid
+	}
+
+	rawData, err := io.ReadAll(r)
+	if err != nil {
+		rep.report([]reportEntry{{Level: "error", Type: typeName, File: stdinPath, Message: fmt.Sprintf("reading stdin: %v", err)}})
+		return ExitDataInvalid
+	}
+
+	// Only constraints that need nothing but this input are evaluated.
+	var warnings []reportEntry
+	stdinType := *td
+	stdinType.Constraints = nil
+	for ci, cd := range td.Constraints {
+		reason := nonLocalReason(cd, typeName)
+		if reason == "" {
+			stdinType.Constraints = append(stdinType.Constraints, cd)
+			continue
+		}
+		warnings = append(warnings, reportEntry{
+			Level:   "warning",
+			Type:    typeName,
+			File:    stdinPath,
+			Message: fmt.Sprintf("%s constraint %s skipped: %s", cd.Type, constraintLabel(cd, ci), reason),
+		})
+	}
+
+	f := discovery.DiscoveredFile{Path: stdinPath, TypeName: typeName, TypeDef: &stdinType, PathCaptures: map[string]string{}}
+	res := parseAndValidateData(rawData, f, cfg)
+
+	allEntries := append(warnings, res.parseEntries...)
+	allEntries = append(allEntries, res.schemaEntries...)
+	if len(res.parseEntries) == 0 {
+		items := map[string][]constraints.Item{typeName: toConstraintItems(f, res.parsed)}
+		rootDir, _ := opts.rootDir() // checked by loadAndValidateConfig
+		constraintErrs := constraints.EvaluatePartial(rootDir, items, []config.TypeDef{stdinType}, map[string]bool{typeName: true})
+		allEntries = append(allEntries, constraintErrorsToEntries(constraintErrs)...)
+	}
 
 	if len(allEntries) > 0 {
-		reportErrors(resolvedFormat, allEntries)
+		rep.report(allEntries)
+	}
+	if hasErrorEntries(allEntries) {
 		return ExitDataInvalid
 	}
 
 	return ExitOK
 }
 
+// tidyCSVOptions returns how the CSV files of td are tidied: the configured
+// column sorting and, with tidy.canonical_numbers, the schema's number and
+// integer columns.
+func tidyCSVOptions(cfg *config.Config, td *config.TypeDef) tidy.CSVOptions {
+	opts := tidy.CSVOptions{SortColumns: cfg.Tidy.ShouldSortColumns()}
+	if !cfg.Tidy.ShouldCanonicalizeNumbers() {
+		return opts
+	}
+	for name, col := range schemaPropertyTypes(td.Schema) {
+		if col.Type == "number" || col.Type == "integer" {
+			if opts.NumberColumns == nil {
+				opts.NumberColumns = make(map[string]string)
+			}
+			opts.NumberColumns[name] = col.Type
+		}
+	}
+	return opts
+}
+
+// nonLocalReason returns why cd, a constraint of the named type, cannot be
+// evaluated on items read without the rest of the repository, or "" when it
+// can.
+func nonLocalReason(cd config.ConstraintDef, typeName string) string {
+	switch cd.Type {
+	case "foreign_key", "count_equals":
+		return fmt.Sprintf("needs items of type %q", cd.References.Type)
+	case "path_equals_attr", "path_template_equals_attr":
+		return "path captures are not available for stdin"
+	case "sequence":
+		return fmt.Sprintf("needs the files of type %q", typeName)
+	}
+	if readsPathCaptures(cd) {
+		return "path captures are not available for stdin"
+	}
+	return ""
+}
+
+// readsPathCaptures reports whether a selector of cd reads a path capture
+// ($path.<name>), which data without a file path does not have.
+func readsPathCaptures(cd config.ConstraintDef) bool {
+	sels := append([]string{cd.Key, cd.Left, cd.Right}, cd.Keys...)
+	if cd.References != nil {
+		sels = append(sels, cd.References.Key)
+	}
+	for _, sel := range sels {
+		s, err := selector.Parse(sel)
+		if err != nil {
+			continue
+		}
+		if _, ok := s.PathCapture(); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // RunExport runs the export command.
-// format: output format (text, json, yaml) - from --format flag.
-// version: CLI version string.
+// opts: shared command options.
 // Returns exit code.
-func RunExport(format string, version string) int {
-	cfg, resolvedFormat, code := loadAndValidateConfig(format, version)
+func RunExport(opts Options) int {
+	prof := newProfile(opts.Profile)
+	start := time.Now()
+	cfg, rep, code := loadAndValidateConfig(opts)
+	prof.record("config", time.Since(start), 0, 0)
+	defer prof.write(os.Stderr, rep.format)
 	if code != ExitOK {
 		return code
 	}
@@ -96,28 +336,49 @@ func RunExport(format string, version string) int {
 		return ExitOK
 	}
 
-	rootDir, _ := os.Getwd()
-	files, discoverErrs := discovery.Discover(rootDir, cfg.Types)
+	rootDir, _ := opts.rootDir() // checked by loadAndValidateConfig
+	start = time.Now()
+	files, discoverErrs := discovery.Discover(rootDir, cfg.Types, discoveryOptions(cfg))
+	prof.record("discovery", time.Since(start), len(files), 0)
+	unreadable, discoverErrs := splitUnreadable(discoverErrs)
 	if len(discoverErrs) > 0 {
-		reportErrors(resolvedFormat, toReportEntries("error", "discovery", discoverErrs))
-		return ExitConfigInvalid
+		rep.report(discoveryErrorsToEntries(discoverErrs))
+		return ExitDiscoveryError
 	}
 
-	items, parseEntries, schemaEntries := parseAndValidateFiles(files, cfg)
+	warnings := deprecationWarnings(files)
 
-	constraintErrs := constraints.Evaluate(items, cfg.Types)
+	items, parseEntries, schemaEntries := parseAndValidateFiles(rootDir, files, cfg, nil, opts.Jobs, prof)
+
+	start = time.Now()
+	constraintErrs := constraints.EvaluateIn(rootDir, items, cfg.Types)
+	prof.record("constraints", time.Since(start), 0, countItems(items))
 	constraintEntries := constraintErrorsToEntries(constraintErrs)
 
-	allEntries := append(parseEntries, schemaEntries...)
+	allEntries := append(unreadable, warnings...)
+	allEntries = append(allEntries, parseEntries...)
+	allEntries = append(allEntries, schemaEntries...)
 	allEntries = append(allEntries, constraintEntries...)
+	if opts.SortOutput {
+		sortEntries(allEntries)
+	}
 
 	if len(allEntries) > 0 {
-		reportErrors(resolvedFormat, allEntries)
+		rep.report(allEntries)
+	}
+	if hasErrorEntries(allEntries) {
 		return ExitDataInvalid
 	}
 
+	return exportItems(cfg, items, rootDir, rep, prof)
+}
+
+// exportItems writes the validated items to the configured outputs and the
+// export manifest, reporting each changed file on stderr.
+// Returns exit code.
+func exportItems(cfg *config.Config, items map[string][]constraints.Item, rootDir string, rep reporter, prof *profile) int {
 	// Check if any types define output
-	hasOutput := false
+	hasOutput := cfg.Export.CombinedPath() != ""
 	for _, td := range cfg.Types {
 		if td.Output != nil {
 			hasOutput = true
@@ -137,14 +398,46 @@ func RunExport(format string, version string) int {
 		}
 	}
 
-	results, exportErrs := export.Export(exportData, cfg.Types, rootDir)
+	start := time.Now()
+	results, exportErrs := export.Export(exportData, cfg.Types, cfg.Export.CombinedPath(), rootDir)
+	prof.record("export", time.Since(start), len(results), countItems(items))
 	if len(exportErrs) > 0 {
-		reportErrors(resolvedFormat, toReportEntries("error", "export", exportErrs))
+		rep.report(toReportEntries("error", "export", exportErrs))
 		return ExitExportFailure
 	}
 
 	for _, r := range results {
-		fmt.Fprintf(os.Stderr, "exported %d items to %s (%s)\n", r.Count, r.Path, r.Format)
+		if !r.Changed {
+			continue
+		}
+		if r.Parts > 0 {
+			fmt.Fprintf(os.Stderr, "exported %d items to %d parts indexed by %s (%s)\n", r.Count, r.Parts, rep.displayPath(r.Path), r.Format)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "exported %d items to %s (%s)\n", r.Count, rep.displayPath(r.Path), r.Format)
+	}
+
+	if manifestPath := cfg.Export.ManifestPath(); manifestPath != "" {
+		m, err := export.WriteManifest(results, manifestPath, rootDir)
+		if err != nil {
+			rep.report(toReportEntries("error", "export", []error{err}))
+			return ExitExportFailure
+		}
+		if m.Changed {
+			fmt.Fprintf(os.Stderr, "wrote manifest of %d files to %s\n", m.Count, rep.displayPath(m.Path))
+		}
+		results = append(results, m)
+	}
+
+	if attrsPath := cfg.Export.GitAttributesPath(); attrsPath != "" {
+		a, err := export.WriteGitAttributes(results, attrsPath, rootDir)
+		if err != nil {
+			rep.report(toReportEntries("error", "export", []error{err}))
+			return ExitExportFailure
+		}
+		if a.Changed {
+			fmt.Fprintf(os.Stderr, "marked %d generated files in %s\n", a.Count, rep.displayPath(a.Path))
+		}
 	}
 
 	return ExitOK
@@ -152,11 +445,12 @@ func RunExport(format string, version string) int {
 
 // RunTidy runs the tidy command.
 // writeChanges: if true, rewrite files; otherwise run in check mode and print diffs.
-// format: output format (text, json, yaml) - from --format flag.
-// version: CLI version string.
+// verify: if true (with writeChanges), re-tidy each rewritten file in memory and
+// fail when the output is not stable.
+// opts: shared command options.
 // Returns exit code.
-func RunTidy(writeChanges bool, format string, version string) int {
-	cfg, resolvedFormat, code := loadAndValidateConfig(format, version)
+func RunTidy(writeChanges, verify bool, opts Options) int {
+	cfg, rep, code := loadAndValidateConfig(opts)
 	if code != ExitOK {
 		return code
 	}
@@ -171,19 +465,24 @@ func RunTidy(writeChanges bool, format string, version string) int {
 		return ExitOK
 	}
 
-	rootDir, _ := os.Getwd()
-	files, discoverErrs := discovery.Discover(rootDir, cfg.Types)
+	rootDir, _ := opts.rootDir() // checked by loadAndValidateConfig
+	files, discoverErrs := discover(rootDir, cfg, opts)
+	tidyErrors, discoverErrs := splitUnreadable(discoverErrs)
 	if len(discoverErrs) > 0 {
-		reportErrors(resolvedFormat, toReportEntries("error", "discovery", discoverErrs))
-		return ExitConfigInvalid
+		rep.report(discoveryErrorsToEntries(discoverErrs))
+		return ExitDiscoveryError
 	}
 
-	var tidyErrors []reportEntry
 	var changed []string
 
 	for _, f := range files {
+		// Tidy writes UTF-8, so files in another encoding are left as they are.
+		if f.TypeDef.Decoder() != nil {
+			continue
+		}
 		absPath := filepath.Join(rootDir, f.Path)
-		result, err := tidy.TidyFile(absPath, f.TypeDef.Input, !writeChanges)
+		csvOpts := tidyCSVOptions(cfg, f.TypeDef)
+		result, err := tidy.TidyFile(absPath, f.TypeDef.InputFor(f.Path), !writeChanges, csvOpts)
 		if err != nil {
 			tidyErrors = append(tidyErrors, reportEntry{
 				Level:   "error",
@@ -197,13 +496,30 @@ func RunTidy(writeChanges bool, format string, version string) int {
 		if result.Changed {
 			changed = append(changed, f.Path)
 			if !writeChanges {
-				fmt.Fprint(os.Stderr, tidy.RenderColorUnifiedDiff(f.Path, result.Original, result.Tidied))
+				if opts.listUntidy {
+					fmt.Fprintf(os.Stderr, "needs formatting: %s\n", f.Path)
+				} else if rep.color {
+					fmt.Fprint(os.Stderr, tidy.RenderColorUnifiedDiff(f.Path, result.Original, result.Tidied, opts.DiffContext))
+				} else {
+					fmt.Fprint(os.Stderr, tidy.RenderUnifiedDiff(f.Path, result.Original, result.Tidied, opts.DiffContext))
+				}
+			}
+		}
+
+		if verify && writeChanges && result.Changed {
+			if err := tidy.VerifyIdempotent(f.TypeDef.InputFor(f.Path), result.Tidied, csvOpts); err != nil {
+				tidyErrors = append(tidyErrors, reportEntry{
+					Level:   "error",
+					Type:    f.TypeName,
+					File:    f.Path,
+					Message: err.Error(),
+				})
 			}
 		}
 	}
 
 	if len(tidyErrors) > 0 {
-		reportErrors(resolvedFormat, tidyErrors)
+		rep.report(tidyErrors)
 		return ExitTidyFailure
 	}
 
@@ -223,127 +539,637 @@ func RunTidy(writeChanges bool, format string, version string) int {
 	return ExitTidyCheckDiff
 }
 
-// loadAndValidateConfig loads the .datacur8 config, applies defaults, validates it,
-// and resolves the output format. Returns the config, resolved format, and exit code.
-func loadAndValidateConfig(formatOverride string, version string) (*config.Config, string, int) {
-	resolvedFormat := "text"
-	if formatOverride != "" {
-		resolvedFormat = formatOverride
+// newReporter resolves the reporter for the --format, --color, --path-style,
+// and --report-file flags, printing an error for an invalid flag. The report
+// file, when set, is written up front. The root is left for the caller to set.
+func newReporter(opts Options) (reporter, bool) {
+	rep := reporter{format: "text", byType: opts.FormatByType}
+	if opts.Format != "" {
+		rep.format = opts.Format
 	}
 
-	switch resolvedFormat {
-	case "text", "json", "yaml":
+	switch rep.format {
+	case "text", "json", "ndjson", "yaml", "csv":
 		// valid
 	default:
-		fmt.Fprintf(os.Stderr, "error: --format %q is not valid; must be text, json, or yaml\n", resolvedFormat)
-		return nil, "text", ExitConfigInvalid
+		fmt.Fprintf(os.Stderr, "error: --format %q is not valid; must be text, json, ndjson, yaml, or csv\n", rep.format)
+		return reporter{format: "text"}, false
+	}
+
+	switch opts.Color {
+	case "", "auto", "always", "never":
+		rep.color = resolveColor(opts.Color, isTerminal(os.Stderr), os.Getenv("NO_COLOR"))
+	default:
+		fmt.Fprintf(os.Stderr, "error: --color %q is not valid; must be always, never, or auto\n", opts.Color)
+		return reporter{format: "text"}, false
+	}
+
+	switch opts.PathStyle {
+	case "", "relative":
+		// reported as discovered
+	case "absolute":
+		rep.absPaths = true
+	default:
+		fmt.Fprintf(os.Stderr, "error: --path-style %q is not valid; must be relative or absolute\n", opts.PathStyle)
+		return reporter{format: "text"}, false
 	}
 
-	rootDir, err := os.Getwd()
+	if opts.collect != nil {
+		// RunCheck reports the collected entries, and writes the report file
+		rep.collect = opts.collect
+	} else if opts.ReportFile != "" {
+		// written up front so the file exists, as an empty report, for clean runs
+		rep.file = &reportFile{path: opts.ReportFile, format: reportFileFormat(rep.format), entries: []reportEntry{}}
+		if err := rep.file.write(rep.byType); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --report-file: %v\n", err)
+			return reporter{format: "text"}, false
+		}
+		rep.fileOnly = !opts.ReportStdout
+	}
+
+	return rep, true
+}
+
+// loadAndValidateConfig loads the .datacur8 config, applies defaults, validates it,
+// and resolves the reporter. Returns the config, reporter, and exit code.
+func loadAndValidateConfig(opts Options) (*config.Config, reporter, int) {
+	rep, ok := newReporter(opts)
+	if !ok {
+		return nil, rep, ExitConfigInvalid
+	}
+
+	rootDir, err := opts.rootDir()
 	if err != nil {
-		reportErrors(resolvedFormat, []reportEntry{{Level: "error", Type: "config", Message: err.Error()}})
-		return nil, resolvedFormat, ExitConfigInvalid
+		rep.report([]reportEntry{{Level: "error", Type: "config", Message: err.Error()}})
+		return nil, rep, ExitConfigInvalid
 	}
+	rep.root = rootDir
 
 	configPath := filepath.Join(rootDir, ".datacur8")
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		reportErrors(resolvedFormat, []reportEntry{{Level: "error", Type: "config", Message: ".datacur8 not found in current directory. Run from repo root."}})
-		return nil, resolvedFormat, ExitConfigInvalid
+	if opts.Config != "" {
+		if !config.IsURL(opts.Config) {
+			rep.report([]reportEntry{{Level: "error", Type: "config", Message: fmt.Sprintf("--config %q must be an http or https URL", opts.Config)}})
+			return nil, rep, ExitConfigInvalid
+		}
+		configPath = opts.Config
+	} else if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		msg := ".datacur8 not found in current directory. Run from repo root."
+		if opts.Root != "" {
+			msg = fmt.Sprintf(".datacur8 not found in --root directory %q.", opts.Root)
+		}
+		rep.report([]reportEntry{{Level: "error", Type: "config", Message: msg}})
+		return nil, rep, ExitConfigInvalid
 	}
 
-	cfg, err := config.Load(configPath)
+	var cfg *config.Config
+	var loadWarnings []string
+	if opts.LenientConfig {
+		cfg, loadWarnings, err = config.LoadLenient(configPath)
+	} else {
+		cfg, err = config.Load(configPath)
+	}
 	if err != nil {
-		reportErrors(resolvedFormat, []reportEntry{{Level: "error", Type: "config", Message: err.Error()}})
-		return nil, resolvedFormat, ExitConfigInvalid
+		rep.report([]reportEntry{{Level: "error", Type: "config", Message: err.Error()}})
+		return nil, rep, ExitConfigInvalid
 	}
 
+	version := opts.Version
+	if opts.SkipVersionCheck {
+		version = config.SkipVersionCheck
+	}
 	warnings, errs := config.Validate(cfg, version)
-	for _, w := range warnings {
-		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	warnings = append(loadWarnings, warnings...)
+	if opts.StrictConfig {
+		lint := config.Lint(cfg)
+		warnings = slices.DeleteFunc(warnings, func(w string) bool { return slices.Contains(lint, w) })
+		for _, w := range lint {
+			errs = append(errs, errors.New(w))
+		}
+	}
+	if !opts.quietWarnings {
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
 	}
 
 	if len(errs) > 0 {
-		reportErrors(resolvedFormat, toReportEntries("error", "config", errs))
-		return nil, resolvedFormat, ExitConfigInvalid
+		rep.report(toReportEntries("error", "config", errs))
+		return nil, rep, ExitConfigInvalid
 	}
 
-	return cfg, resolvedFormat, ExitOK
+	return cfg, rep, ExitOK
+}
+
+// rootDir returns the absolute base directory for the config, discovery, and
+// outputs: Root when set, otherwise the working directory.
+func (o Options) rootDir() (string, error) {
+	if o.Root == "" {
+		return os.Getwd()
+	}
+	dir, err := filepath.Abs(o.Root)
+	if err != nil {
+		return "", fmt.Errorf("--root %q: %w", o.Root, err)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("--root %q does not exist", o.Root)
+		}
+		return "", fmt.Errorf("--root %q: %w", o.Root, err)
+	}
+	if !fi.IsDir() {
+		return "", fmt.Errorf("--root %q is not a directory", o.Root)
+	}
+	return dir, nil
+}
+
+// errFileTooLarge reports a data file larger than its max_file_size.
+var errFileTooLarge = errors.New("file too large")
+
+// readDataFile reads a discovered data file of at most limit bytes, returning
+// errFileTooLarge for a larger one. The read itself is capped, so a file that
+// grows after its size was checked is not read whole either. Tests replace it
+// to observe reads.
+var readDataFile = func(name string, limit int64) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if fi, err := file.Stat(); err == nil && fi.Size() > limit {
+		return nil, errFileTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errFileTooLarge
+	}
+	return data, nil
+}
+
+// fileResult holds the outcome of reading, parsing, and schema-validating one file.
+type fileResult struct {
+	parsed        []map[string]any
+	parseEntries  []reportEntry
+	schemaEntries []reportEntry
+	info          os.FileInfo // file info captured for the cache, if enabled
+	cached        bool        // parsed came from the cache
+	parseTime     time.Duration
+	schemaTime    time.Duration
 }
 
 // parseAndValidateFiles parses each discovered file and validates against schema.
+// When cache is non-nil, files unchanged since they last validated cleanly
+// reuse their cached items instead of being read and parsed again. Files are
+// parsed by up to jobs concurrent workers (GOMAXPROCS when jobs < 1) and the
+// results merged in discovery order, so output does not depend on jobs.
+// Parse and schema times are summed across files and recorded in prof.
 // Returns the constraint items map, parse errors, and schema errors.
-func parseAndValidateFiles(files []discovery.DiscoveredFile, cfg *config.Config) (
+func parseAndValidateFiles(rootDir string, files []discovery.DiscoveredFile, cfg *config.Config, cache *fileCache, jobs int, prof *profile) (
 	map[string][]constraints.Item, []reportEntry, []reportEntry,
 ) {
+	results := make([]fileResult, len(files))
+
+	start := time.Now()
+	var pending []int
+	for i, f := range files {
+		if cache != nil {
+			if fi, err := os.Stat(filepath.Join(rootDir, f.Path)); err == nil {
+				results[i].info = fi
+				if cached, ok := cache.lookup(f, fi); ok {
+					results[i].parsed = cached
+					results[i].cached = true
+					continue
+				}
+			}
+		}
+		pending = append(pending, i)
+	}
+	if cache != nil {
+		prof.record("cache", time.Since(start), len(files)-len(pending), 0)
+	}
+
+	runParallel(len(pending), jobs, func(n int) {
+		i := pending[n]
+		info := results[i].info
+		results[i] = parseAndValidateFile(rootDir, files[i], cfg)
+		results[i].info = info
+	})
+
 	items := make(map[string][]constraints.Item)
 	var parseEntries []reportEntry
 	var schemaEntries []reportEntry
+	var parseTime, schemaTime time.Duration
+	parsedItems := 0
+
+	for i, f := range files {
+		r := results[i]
+		parseTime += r.parseTime
+		schemaTime += r.schemaTime
+		if !r.cached {
+			parsedItems += len(r.parsed)
+		}
+		parseEntries = append(parseEntries, r.parseEntries...)
+		schemaEntries = append(schemaEntries, r.schemaEntries...)
+		if len(r.parseEntries) > 0 {
+			continue
+		}
 
-	for _, f := range files {
-		rootDir, _ := os.Getwd()
-		absPath := filepath.Join(rootDir, f.Path)
+		items[f.TypeName] = append(items[f.TypeName], toConstraintItems(f, r.parsed)...)
+		if cache != nil && !r.cached && r.info != nil && len(r.schemaEntries) == 0 {
+			cache.store(f, r.info, r.parsed)
+		}
+	}
+
+	prof.record("parse", parseTime, len(pending), parsedItems)
+	prof.record("schema", schemaTime, 0, parsedItems)
+
+	return items, parseEntries, schemaEntries
+}
+
+// parseAndValidateFile reads and parses a single file and validates each of
+// its items against the type schema.
+// CSV files are streamed row by row: each row is schema-validated as it is
+// read, and only the fields that csvKeptFields names are kept, so neither the
+// raw file nor its full rows are held in memory.
+func parseAndValidateFile(rootDir string, f discovery.DiscoveredFile, cfg *config.Config) fileResult {
+	start := time.Now()
+	readErr := func(err error) fileResult {
+		return fileResult{parseEntries: []reportEntry{{
+			Level:   "error",
+			Type:    f.TypeName,
+			File:    f.Path,
+			Message: fmt.Sprintf("reading file: %v", err),
+		}}, parseTime: time.Since(start)}
+	}
+	limit := int64(cfg.MaxFileSizeFor(f.TypeDef))
+	tooLarge := func() fileResult {
+		return fileResult{parseEntries: []reportEntry{{
+			Level:   "error",
+			Type:    f.TypeName,
+			File:    f.Path,
+			Message: fmt.Sprintf("file exceeds max_file_size of %d bytes; not read", limit),
+		}}, parseTime: time.Since(start)}
+	}
 
-		rawData, err := os.ReadFile(absPath)
+	if f.TypeDef.Input == "csv" {
+		file, err := os.Open(filepath.Join(rootDir, f.Path))
 		if err != nil {
-			parseEntries = append(parseEntries, reportEntry{
+			return readErr(err)
+		}
+		defer file.Close()
+		if fi, err := file.Stat(); err == nil && fi.Size() > limit {
+			return tooLarge()
+		}
+		// like readDataFile, cap the read in case the file grew since Stat
+		capped := &io.LimitedReader{R: file, N: limit + 1}
+		var r io.Reader = capped
+		if dec := f.TypeDef.Decoder(); dec != nil {
+			r = transform.NewReader(capped, dec)
+		}
+
+		keep := csvKeptFields(cfg, f.TypeDef)
+		var parsed []map[string]any
+		var schemaEntries []reportEntry
+		var schemaTime time.Duration
+		perrs := readCSVRows(r, f.TypeDef, f.Path, func(i int, item map[string]any) {
+			schemaStart := time.Now()
+			schemaEntries = append(schemaEntries, schemaEntriesFor(item, i, f, cfg)...)
+			schemaTime += time.Since(schemaStart)
+			parsed = append(parsed, keepFields(item, keep))
+		})
+		if capped.N == 0 {
+			return tooLarge()
+		}
+		elapsed := time.Since(start)
+		if len(perrs) > 0 {
+			return fileResult{parseEntries: perrs, parseTime: elapsed}
+		}
+		return fileResult{parsed: parsed, schemaEntries: schemaEntries, parseTime: elapsed - schemaTime, schemaTime: schemaTime}
+	}
+
+	rawData, err := readDataFile(filepath.Join(rootDir, f.Path), limit)
+	if errors.Is(err, errFileTooLarge) {
+		return tooLarge()
+	}
+	if err != nil {
+		return readErr(err)
+	}
+	readTime := time.Since(start)
+
+	r := parseAndValidateData(rawData, f, cfg)
+	r.parseTime += readTime
+	return r
+}
+
+// parseAndValidateData parses the raw content of f and validates each of its
+// items against the type schema. Content in a non-UTF-8 encoding is decoded
+// first.
+func parseAndValidateData(rawData []byte, f discovery.DiscoveredFile, cfg *config.Config) fileResult {
+	start := time.Now()
+	if dec := f.TypeDef.Decoder(); dec != nil {
+		decoded, err := dec.Bytes(rawData)
+		if err != nil {
+			return fileResult{parseEntries: []reportEntry{{
 				Level:   "error",
 				Type:    f.TypeName,
 				File:    f.Path,
-				Message: fmt.Sprintf("reading file: %v", err),
-			})
-			continue
+				Message: fmt.Sprintf("decoding %s: %v", f.TypeDef.Encoding, err),
+			}}, parseTime: time.Since(start)}
 		}
+		rawData = decoded
+	}
+	parsed, perrs := parseDataFile(rawData, f.TypeDef.Input, f.TypeDef, f.Path)
+	return validateParsed(parsed, perrs, f, cfg, time.Since(start))
+}
 
-		parsed, perrs := parseDataFile(rawData, f.TypeDef.Input, f.TypeDef, f.Path)
-		parseEntries = append(parseEntries, perrs...)
+// validateParsed validates each parsed item of f against the type schema,
+// unless parsing reported errors.
+func validateParsed(parsed []map[string]any, perrs []reportEntry, f discovery.DiscoveredFile, cfg *config.Config, parseTime time.Duration) fileResult {
+	if len(perrs) > 0 {
+		return fileResult{parseEntries: perrs, parseTime: parseTime}
+	}
 
-		if len(perrs) > 0 {
-			continue
+	start := time.Now()
+	var schemaEntries []reportEntry
+	for i, data := range parsed {
+		rowIndex := -1
+		if f.TypeDef.Input == "csv" {
+			rowIndex = i
+		}
+		schemaEntries = append(schemaEntries, schemaEntriesFor(data, rowIndex, f, cfg)...)
+	}
+
+	return fileResult{parsed: parsed, schemaEntries: schemaEntries, parseTime: parseTime, schemaTime: time.Since(start)}
+}
+
+// schemaEntriesFor validates one item of f against the type schema. A
+// rowIndex of -1 means the item is not a CSV row.
+func schemaEntriesFor(data map[string]any, rowIndex int, f discovery.DiscoveredFile, cfg *config.Config) []reportEntry {
+	var entries []reportEntry
+	for _, se := range schema.ValidateItem(f.TypeDef.Schema, data, cfg.StrictMode, cfg.SchemaDialectFor(f.TypeDef)) {
+		entry := reportEntry{
+			Level:   "error",
+			Type:    f.TypeName,
+			File:    f.Path,
+			Message: se.Error(),
+		}
+		if rowIndex >= 0 {
+			entry.Row = new(rowIndex)
+		}
+		var verr *schema.ValidationError
+		if errors.As(se, &verr) {
+			entry.Instance = verr.Instance
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// runParallel calls fn for every index in [0, n) using up to jobs goroutines
+// (GOMAXPROCS when jobs < 1). With jobs == 1 indexes are processed in order on
+// the calling goroutine.
+func runParallel(n, jobs int, fn func(i int)) {
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	if jobs == 1 || n <= 1 {
+		for i := range n {
+			fn(i)
 		}
+		return
+	}
 
-		for i, data := range parsed {
-			rowIndex := -1
-			if f.TypeDef.Input == "csv" {
-				rowIndex = i
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, n) {
+		wg.Go(func() {
+			for i := range next {
+				fn(i)
 			}
+		})
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
 
-			schemaErrs := schema.ValidateItem(f.TypeDef.Schema, data, cfg.StrictMode)
-			for _, se := range schemaErrs {
-				entry := reportEntry{
-					Level:   "error",
-					Type:    f.TypeName,
-					File:    f.Path,
-					Message: se.Error(),
-				}
-				if rowIndex >= 0 {
-					entry.Row = new(rowIndex)
-				}
-				schemaEntries = append(schemaEntries, entry)
+// constraintLabel returns the constraint's id, or "#<index>" when it has none,
+// matching the identifier used in constraint error ordering.
+func constraintLabel(cd config.ConstraintDef, index int) string {
+	if cd.ID != "" {
+		return cd.ID
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// applyFixes rewrites the source files of the values fix.Find can repair,
+// printing each change, and each fix it had to leave out, to stderr. With
+// only non-nil, files not in it are left alone. Returns the number of files
+// rewritten.
+func applyFixes(rootDir string, files []discovery.DiscoveredFile, items map[string][]constraints.Item, types []config.TypeDef, only map[string]bool) int {
+	byPath := make(map[string]discovery.DiscoveredFile, len(files))
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+
+	var fixes []fix.Fix
+	for _, fx := range fix.Find(items, types) {
+		if only == nil || only[fx.FilePath] {
+			fixes = append(fixes, fx)
+		}
+	}
+
+	skip := func(fx fix.Fix, reason string) {
+		fmt.Fprintf(os.Stderr, "not fixed: %s: %s %q: %s\n", fixLocation(fx), fx.Path, fx.Old, reason)
+	}
+
+	rewritten := 0
+	for len(fixes) > 0 {
+		f := byPath[fixes[0].FilePath]
+		n := 1
+		for n < len(fixes) && fixes[n].FilePath == f.Path {
+			n++
+		}
+		fileFixes := fixes[:n]
+		fixes = fixes[n:]
+
+		if f.TypeDef.Decoder() != nil {
+			for _, fx := range fileFixes {
+				skip(fx, fmt.Sprintf("files in %s are not rewritten", f.TypeDef.Encoding))
 			}
+			continue
+		}
 
-			items[f.TypeName] = append(items[f.TypeName], constraints.Item{
-				TypeName:     f.TypeName,
-				FilePath:     f.Path,
-				Data:         data,
-				PathCaptures: f.PathCaptures,
-				RowIndex:     rowIndex,
-			})
+		absPath := filepath.Join(rootDir, f.Path)
+		raw, err := os.ReadFile(absPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "not fixed: %s: %v\n", f.Path, err)
+			continue
+		}
+		var applied []fix.Fix
+		for _, fx := range fileFixes {
+			out, err := fix.Apply(raw, f.TypeDef.InputFor(f.Path), fx.Old, fx.New)
+			if err != nil {
+				skip(fx, err.Error())
+				continue
+			}
+			raw = out
+			applied = append(applied, fx)
+		}
+		if len(applied) == 0 {
+			continue
+		}
+		if err := os.WriteFile(absPath, raw, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "not fixed: %s: %v\n", f.Path, err)
+			continue
+		}
+		for _, fx := range applied {
+			fmt.Fprintf(os.Stderr, "fixed: %s: %s %q -> %q (%s)\n", fixLocation(fx), fx.Path, fx.Old, fx.New, fx.Reason)
 		}
+		rewritten++
 	}
+	return rewritten
+}
 
-	return items, parseEntries, schemaEntries
+// fixLocation returns the file of fx, with its CSV row when it has one.
+func fixLocation(fx fix.Fix) string {
+	if fx.RowIndex >= 0 {
+		return fmt.Sprintf("%s (row %d)", fx.FilePath, fx.RowIndex)
+	}
+	return fx.FilePath
+}
+
+// countItems returns the total number of items across all types.
+func countItems(items map[string][]constraints.Item) int {
+	n := 0
+	for _, typeItems := range items {
+		n += len(typeItems)
+	}
+	return n
+}
+
+// discover runs discovery for cfg under rootDir. With --files-from, only the
+// listed files are candidates; a list that cannot be read is reported as a
+// discovery error.
+func discover(rootDir string, cfg *config.Config, opts Options) ([]discovery.DiscoveredFile, []error) {
+	dopts := discoveryOptions(cfg)
+	dopts.OnExcluded = opts.onExcluded
+	if opts.FilesFrom != "" {
+		listed, err := readFileList(opts.FilesFrom)
+		if err != nil {
+			return nil, []error{err}
+		}
+		dopts.Files = listed
+	}
+	return discovery.Discover(rootDir, cfg.Types, dopts)
+}
+
+// readFileList reads newline-delimited paths from name, ignoring blank lines
+// and surrounding whitespace.
+func readFileList(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading --files-from: %w", err)
+	}
+	files := []string{}
+	for line := range strings.Lines(string(data)) {
+		if p := strings.TrimSpace(line); p != "" {
+			files = append(files, p)
+		}
+	}
+	return files, nil
+}
+
+// discoveryOptions derives the discovery options from the config.
+func discoveryOptions(cfg *config.Config) discovery.Options {
+	opts := discovery.Options{FollowSymlinks: cfg.FollowSymlinks}
+	if combined := cfg.Export.CombinedPath(); combined != "" {
+		opts.SkipPaths = append(opts.SkipPaths, combined)
+	}
+	if manifest := cfg.Export.ManifestPath(); manifest != "" {
+		opts.SkipPaths = append(opts.SkipPaths, manifest)
+	}
+	if attrs := cfg.Export.GitAttributesPath(); attrs != "" {
+		opts.SkipPaths = append(opts.SkipPaths, attrs)
+	}
+	return opts
+}
+
+// deprecationWarnings returns one warning entry for each discovered file that
+// belongs to a type marked deprecated.
+func deprecationWarnings(files []discovery.DiscoveredFile) []reportEntry {
+	var entries []reportEntry
+	for _, f := range files {
+		if f.TypeDef == nil || f.TypeDef.Deprecated == "" {
+			continue
+		}
+		entries = append(entries, reportEntry{
+			Level:   "warning",
+			Type:    f.TypeName,
+			File:    f.Path,
+			Message: fmt.Sprintf("type %q is deprecated: %s", f.TypeName, f.TypeDef.Deprecated),
+		})
+	}
+	return entries
+}
+
+// toConstraintItems wraps the parsed items of a file for constraint evaluation.
+func toConstraintItems(f discovery.DiscoveredFile, parsed []map[string]any) []constraints.Item {
+	out := make([]constraints.Item, len(parsed))
+	for i, data := range parsed {
+		rowIndex := -1
+		if f.TypeDef.Input == "csv" {
+			rowIndex = i
+		}
+		out[i] = constraints.Item{
+			TypeName:     f.TypeName,
+			FilePath:     f.Path,
+			Data:         data,
+			PathCaptures: f.PathCaptures,
+			RowIndex:     rowIndex,
+		}
+	}
+	return out
 }
 
 // parseDataFile parses raw file bytes into a slice of data items.
 // JSON and YAML produce a single-element slice; CSV produces one per row.
+// Text files are not parsed and produce no items. Auto input is parsed as JSON,
+// JSON5, or YAML according to the file extension.
 func parseDataFile(raw []byte, inputFormat string, td *config.TypeDef, filePath string) ([]map[string]any, []reportEntry) {
 	switch inputFormat {
-	case "json":
-		return parseJSON(raw, filePath)
-	case "yaml":
-		return parseYAML(raw, filePath)
+	case "text":
+		return nil, nil
+	case "auto":
+		resolved := td.InputFor(filePath)
+		if resolved == "auto" {
+			return nil, []reportEntry{{
+				Level:   "error",
+				File:    filePath,
+				Message: fmt.Sprintf("input auto cannot parse %q files (use .json, .json5, .yaml, or .yml)", filepath.Ext(filePath)),
+			}}
+		}
+		return parseDataFile(raw, resolved, td, filePath)
+	case "json", "json5", "yaml":
+		parse := parseJSON
+		switch inputFormat {
+		case "json5":
+			parse = parseJSON5
+		case "yaml":
+			parse = parseYAML
+		}
+		items, errs := parse(raw, filePath)
+		if len(errs) == 0 && td.Coerce {
+			coerceItems(items, td.Schema)
+		}
+		return items, errs
 	case "csv":
-		return parseCSV(raw, td, filePath)
+		return parseCSVReader(bytes.NewReader(raw), td, filePath)
 	default:
 		return nil, []reportEntry{{
 			Level:   "error",
@@ -355,7 +1181,7 @@ func parseDataFile(raw []byte, inputFormat string, td *config.TypeDef, filePath
 
 func parseJSON(raw []byte, filePath string) ([]map[string]any, []reportEntry) {
 	var data map[string]any
-	if err := json.Unmarshal(raw, &data); err != nil {
+	if err := unmarshalJSON(raw, &data); err != nil {
 		return nil, []reportEntry{{
 			Level:   "error",
 			File:    filePath,
@@ -365,6 +1191,20 @@ func parseJSON(raw []byte, filePath string) ([]map[string]any, []reportEntry) {
 	return []map[string]any{data}, nil
 }
 
+// parseJSON5 parses JSON5 (JSON with comments, trailing commas, unquoted keys,
+// and the other JSON5 extensions). The file itself is left as written.
+func parseJSON5(raw []byte, filePath string) ([]map[string]any, []reportEntry) {
+	var data map[string]any
+	if err := unmarshalJSON5(raw, &data); err != nil {
+		return nil, []reportEntry{{
+			Level:   "error",
+			File:    filePath,
+			Message: fmt.Sprintf("parsing JSON5: %v", err),
+		}}
+	}
+	return []map[string]any{data}, nil
+}
+
 func parseYAML(raw []byte, filePath string) ([]map[string]any, []reportEntry) {
 	var data map[string]any
 	if err := yaml.Unmarshal(raw, &data); err != nil {
@@ -377,26 +1217,46 @@ func parseYAML(raw []byte, filePath string) ([]map[string]any, []reportEntry) {
 	return []map[string]any{data}, nil
 }
 
-func parseCSV(raw []byte, td *config.TypeDef, filePath string) ([]map[string]any, []reportEntry) {
-	reader := csv.NewReader(bytes.NewReader(raw))
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, []reportEntry{{
+// parseCSVReader reads CSV records one at a time from r, converting each row
+// as it is read, so the file is never buffered whole.
+func parseCSVReader(r io.Reader, td *config.TypeDef, filePath string) ([]map[string]any, []reportEntry) {
+	var items []map[string]any
+	if errs := readCSVRows(r, td, filePath, func(_ int, item map[string]any) {
+		items = append(items, item)
+	}); len(errs) > 0 {
+		return nil, errs
+	}
+	return items, nil
+}
+
+// readCSVRows reads CSV records one at a time from r and calls row with the
+// data-row index and converted item of each, as it is read. Once a row has
+// failed, the file is rejected, so row is no longer called. Returns the header
+// and row errors.
+func readCSVRows(r io.Reader, td *config.TypeDef, filePath string, row func(i int, item map[string]any)) []reportEntry {
+	csvErr := func(err error) []reportEntry {
+		return []reportEntry{{
 			Level:   "error",
 			File:    filePath,
 			Message: fmt.Sprintf("parsing CSV: %v", err),
 		}}
 	}
 
-	if len(records) == 0 {
-		return nil, []reportEntry{{
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	reader.FieldsPerRecord = -1 // row length is checked below, per td.StrictRows
+	headers, err := reader.Read()
+	if err == io.EOF {
+		return []reportEntry{{
 			Level:   "error",
 			File:    filePath,
 			Message: "CSV file is empty (no header row)",
 		}}
 	}
-
-	headers := records[0]
+	if err != nil {
+		return csvErr(err)
+	}
+	headers = slices.Clone(headers)
 
 	// Extract schema property types for type conversion
 	propTypes := schemaPropertyTypes(td.Schema)
@@ -404,9 +1264,14 @@ func parseCSV(raw []byte, td *config.TypeDef, filePath string) ([]map[string]any
 	// Extract required properties
 	requiredProps := schemaRequiredProperties(td.Schema)
 
+	// Numbered columns of fold properties become arrays of objects
+	folds, foldedCols, headerErrors := csvFolds(headers, td, filePath)
+
 	// Validate headers: unknown headers are invalid
-	var headerErrors []reportEntry
-	for _, h := range headers {
+	for j, h := range headers {
+		if foldedCols[j] {
+			continue
+		}
 		if _, ok := propTypes[h]; !ok {
 			headerErrors = append(headerErrors, reportEntry{
 				Level:   "error",
@@ -421,6 +1286,9 @@ func parseCSV(raw []byte, td *config.TypeDef, filePath string) ([]map[string]any
 	for _, h := range headers {
 		headerSet[h] = true
 	}
+	for _, f := range folds {
+		headerSet[f.property] = true
+	}
 	for _, req := range requiredProps {
 		if !headerSet[req] {
 			headerErrors = append(headerErrors, reportEntry{
@@ -432,24 +1300,48 @@ func parseCSV(raw []byte, td *config.TypeDef, filePath string) ([]map[string]any
 	}
 
 	if len(headerErrors) > 0 {
-		return nil, headerErrors
+		return headerErrors
 	}
 
-	var items []map[string]any
 	var parseErrors []reportEntry
 
-	for i, row := range records[1:] {
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return csvErr(err)
+		}
+		// Short rows are padded with empty cells and extra fields are
+		// ignored, unless strict_rows asks for a matching field count.
+		if td.StrictRows && len(record) != len(headers) {
+			parseErrors = append(parseErrors, reportEntry{
+				Level:   "error",
+				File:    filePath,
+				Row:     new(i),
+				Message: fmt.Sprintf("row %d: has %d fields, header has %d", i, len(record), len(headers)),
+			})
+			continue
+		}
 		item := make(map[string]any, len(headers))
 		rowHasError := false
 
 		for j, h := range headers {
+			if foldedCols[j] {
+				continue
+			}
 			val := ""
-			if j < len(row) {
-				val = row[j]
+			if j < len(record) {
+				val = record[j]
 			}
 
-			propType := propTypes[h]
-			converted, err := convertCSVValue(val, propType)
+			var converted any
+			if sep, ok := td.Split[h]; ok {
+				converted, err = splitCSVValue(val, sep, propTypes[h])
+			} else {
+				converted, err = convertCSVValue(val, propTypes[h])
+			}
 			if err != nil {
 				parseErrors = append(parseErrors, reportEntry{
 					Level:   "error",
@@ -463,21 +1355,57 @@ func parseCSV(raw []byte, td *config.TypeDef, filePath string) ([]map[string]any
 			item[h] = converted
 		}
 
-		if !rowHasError {
-			items = append(items, item)
+		for _, f := range folds {
+			arr, cellErrs := f.fold(record, headers, i, filePath)
+			if len(cellErrs) > 0 {
+				parseErrors = append(parseErrors, cellErrs...)
+				rowHasError = true
+				continue
+			}
+			item[f.property] = arr
+		}
+
+		if !rowHasError && len(parseErrors) == 0 {
+			row(i, item)
 		}
 	}
 
-	if len(parseErrors) > 0 {
-		return nil, parseErrors
+	return parseErrors
+}
+
+// coerceItems converts non-empty string values of top-level properties to the
+// number, integer, or boolean type declared in schemaMap, in place. Values that
+// do not convert are left as strings so schema validation reports them.
+func coerceItems(items []map[string]any, schemaMap map[string]any) {
+	propTypes := schemaPropertyTypes(schemaMap)
+	for _, item := range items {
+		for name, v := range item {
+			s, ok := v.(string)
+			if !ok || s == "" {
+				continue
+			}
+			switch propTypes[name].Type {
+			case "number", "integer", "boolean":
+				if converted, err := convertCSVValue(s, propTypes[name]); err == nil {
+					item[name] = converted
+				}
+			}
+		}
 	}
+}
 
-	return items, nil
+// csvColumnType describes how a CSV cell is converted for a schema property.
+type csvColumnType struct {
+	Type     string // JSON Schema type used for conversion
+	Nullable bool   // the property type is a union including "null"
+	Items    string // for arrays: the "items" type each split element converts to
 }
 
-// schemaPropertyTypes extracts property name -> type from a JSON Schema map.
-func schemaPropertyTypes(schemaMap map[string]any) map[string]string {
-	types := make(map[string]string)
+// schemaPropertyTypes extracts property name -> column type from a JSON Schema map.
+// A type union such as ["integer", "null"] converts as its first non-null type
+// and is marked nullable.
+func schemaPropertyTypes(schemaMap map[string]any) map[string]csvColumnType {
+	types := make(map[string]csvColumnType)
 	props, ok := schemaMap["properties"].(map[string]any)
 	if !ok {
 		return types
@@ -487,8 +1415,28 @@ func schemaPropertyTypes(schemaMap map[string]any) map[string]string {
 		if !ok {
 			continue
 		}
-		if t, ok := propSchema["type"].(string); ok {
-			types[name] = t
+		switch t := propSchema["type"].(type) {
+		case string:
+			types[name] = csvColumnType{Type: t, Nullable: t == "null"}
+		case []any:
+			var col csvColumnType
+			for _, u := range t {
+				s, ok := u.(string)
+				if !ok {
+					continue
+				}
+				if s == "null" {
+					col.Nullable = true
+				} else if col.Type == "" {
+					col.Type = s
+				}
+			}
+			types[name] = col
+		}
+		if items, ok := propSchema["items"].(map[string]any); ok {
+			col := types[name]
+			col.Items, _ = items["type"].(string)
+			types[name] = col
 		}
 	}
 	return types
@@ -510,8 +1458,13 @@ func schemaRequiredProperties(schemaMap map[string]any) []string {
 }
 
 // convertCSVValue converts a CSV string value to the appropriate Go type based on schema type.
-func convertCSVValue(val string, schemaType string) (any, error) {
-	switch schemaType {
+// An empty value in a nullable column converts to nil.
+func convertCSVValue(val string, col csvColumnType) (any, error) {
+	if val == "" && col.Nullable {
+		return nil, nil
+	}
+
+	switch col.Type {
 	case "boolean":
 		if val == "" {
 			return nil, fmt.Errorf("empty value for boolean type")
@@ -552,61 +1505,120 @@ func convertCSVValue(val string, schemaType string) (any, error) {
 	}
 }
 
-// reportErrors outputs errors in the given format.
-func reportErrors(format string, entries []reportEntry) {
-	switch format {
-	case "json":
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		_ = enc.Encode(entries)
-	case "yaml":
-		_ = yaml.NewEncoder(os.Stdout).Encode(entries)
-	default:
-		for _, e := range entries {
-			parts := []string{"error:"}
-			if e.Type != "" {
-				parts = append(parts, fmt.Sprintf("[%s]", e.Type))
-			}
-			if e.File != "" {
-				parts = append(parts, e.File)
-			}
-			if e.Row != nil {
-				parts = append(parts, fmt.Sprintf("(row %d)", *e.Row))
-			}
-			parts = append(parts, e.Message)
-			fmt.Fprintln(os.Stderr, strings.Join(parts, " "))
+// splitCSVValue splits a CSV cell on sep into an array, converting each
+// element to the column's items type. An empty cell is an empty array, or nil
+// in a nullable column.
+func splitCSVValue(val, sep string, col csvColumnType) (any, error) {
+	if val == "" {
+		if col.Nullable {
+			return nil, nil
+		}
+		return []any{}, nil
+	}
+	var elems []any
+	for i, part := range strings.Split(val, sep) {
+		v, err := convertCSVValue(part, csvColumnType{Type: col.Items})
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i, err)
 		}
+		elems = append(elems, v)
 	}
+	return elems, nil
+}
+
+// csvFold is a fold property of a CSV type: its array elements are read from
+// the columns <prefix>_<N>_<field>, element N holding one value per field.
+type csvFold struct {
+	property string
+	elements [][]csvFoldCell // in N order; only indexes that have a column
 }
 
-// toReportEntries converts a slice of errors into reportEntry values.
-func toReportEntries(level, category string, errs []error) []reportEntry {
-	entries := make([]reportEntry, len(errs))
-	for i, e := range errs {
-		entries[i] = reportEntry{
-			Level:   level,
-			Type:    category,
-			Message: e.Error(),
+// csvFoldCell is one column of a folded array element.
+type csvFoldCell struct {
+	column int
+	field  string
+	col    csvColumnType
+}
+
+// csvFolds matches headers against the type's fold prefixes. It returns the
+// folds that have at least one column, sorted by property, and the set of
+// header indexes they consume. A folded column whose field is not among the
+// properties of the array's items schema is a header error.
+func csvFolds(headers []string, td *config.TypeDef, filePath string) ([]csvFold, map[int]bool, []reportEntry) {
+	var folds []csvFold
+	consumed := make(map[int]bool)
+	var errs []reportEntry
+	props, _ := td.Schema["properties"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(td.Fold)) {
+		re := regexp.MustCompile("^" + regexp.QuoteMeta(td.Fold[name]) + `_(\d+)_(.+)$`)
+		prop, _ := props[name].(map[string]any)
+		itemsSchema, _ := prop["items"].(map[string]any)
+		_, declaresFields := itemsSchema["properties"]
+		fieldTypes := schemaPropertyTypes(itemsSchema)
+
+		f := csvFold{property: name}
+		byIndex := make(map[int][]csvFoldCell)
+		for j, h := range headers {
+			m := re.FindStringSubmatch(h)
+			if m == nil || consumed[j] {
+				continue
+			}
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				continue
+			}
+			consumed[j] = true
+			if _, ok := fieldTypes[m[2]]; declaresFields && !ok {
+				errs = append(errs, reportEntry{
+					Level:   "error",
+					File:    filePath,
+					Message: fmt.Sprintf("CSV header %q: field %q not found in the items schema of %q", h, m[2], name),
+				})
+				continue
+			}
+			byIndex[n] = append(byIndex[n], csvFoldCell{column: j, field: m[2], col: fieldTypes[m[2]]})
+		}
+		for _, n := range slices.Sorted(maps.Keys(byIndex)) {
+			f.elements = append(f.elements, byIndex[n])
+		}
+		if len(f.elements) > 0 {
+			folds = append(folds, f)
 		}
 	}
-	return entries
+	return folds, consumed, errs
 }
 
-// constraintErrorsToEntries converts constraint errors to report entries.
-func constraintErrorsToEntries(errs []constraints.Error) []reportEntry {
-	entries := make([]reportEntry, len(errs))
-	for i, e := range errs {
-		entries[i] = reportEntry{
-			Level:   "error",
-			Type:    e.TypeName,
-			File:    e.FilePath,
-			Message: fmt.Sprintf("[%s] %s", e.ConstraintType, e.Message),
+// fold builds the array for row i. Elements are kept in column index order;
+// an element whose cells are all empty is left out, so a fixed set of columns
+// can hold a shorter array.
+func (f csvFold) fold(row, headers []string, i int, filePath string) ([]any, []reportEntry) {
+	arr := []any{}
+	var errs []reportEntry
+	for _, cells := range f.elements {
+		if !slices.ContainsFunc(cells, func(c csvFoldCell) bool { return c.column < len(row) && row[c.column] != "" }) {
+			continue
 		}
-		if e.RowIndex >= 0 {
-			entries[i].Row = new(e.RowIndex)
+		elem := make(map[string]any, len(cells))
+		for _, c := range cells {
+			val := ""
+			if c.column < len(row) {
+				val = row[c.column]
+			}
+			converted, err := convertCSVValue(val, c.col)
+			if err != nil {
+				errs = append(errs, reportEntry{
+					Level:   "error",
+					File:    filePath,
+					Row:     new(i),
+					Message: fmt.Sprintf("row %d, column %q: %v", i, headers[c.column], err),
+				})
+				continue
+			}
+			elem[c.field] = converted
 		}
+		arr = append(arr, elem)
 	}
-	return entries
+	return arr, errs
 }
 
 //go:fix inline
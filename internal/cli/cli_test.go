@@ -0,0 +1,792 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	legacy := &config.TypeDef{Name: "legacy", Deprecated: "use current instead"}
+	current := &config.TypeDef{Name: "current"}
+	files := []discovery.DiscoveredFile{
+		{Path: "legacy/a.json", TypeName: "legacy", TypeDef: legacy},
+		{Path: "current/b.json", TypeName: "current", TypeDef: current},
+		{Path: "legacy/c.json", TypeName: "legacy", TypeDef: legacy},
+	}
+
+	got := deprecationWarnings(files)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 warnings, got %d: %+v", len(got), got)
+	}
+	for i, want := range []string{"legacy/a.json", "legacy/c.json"} {
+		if got[i].Level != "warning" || got[i].Type != "legacy" || got[i].File != want {
+			t.Errorf("warning %d = %+v, want warning for %s", i, got[i], want)
+		}
+	}
+	if got[0].Message != `type "legacy" is deprecated: use current instead` {
+		t.Errorf("unexpected message: %q", got[0].Message)
+	}
+}
+
+func TestDeprecationWarnings_NoFiles(t *testing.T) {
+	current := &config.TypeDef{Name: "current"}
+	files := []discovery.DiscoveredFile{
+		{Path: "current/b.json", TypeName: "current", TypeDef: current},
+	}
+
+	if got := deprecationWarnings(files); len(got) != 0 {
+		t.Fatalf("expected no warnings, got %+v", got)
+	}
+}
+
+func TestSchemaPropertyTypes_NullableUnion(t *testing.T) {
+	types := schemaPropertyTypes(map[string]any{
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "string"},
+			"count": map[string]any{"type": []any{"integer", "null"}},
+		},
+	})
+	if got := types["id"]; got != (csvColumnType{Type: "string"}) {
+		t.Errorf("id column = %+v", got)
+	}
+	if got := types["count"]; got != (csvColumnType{Type: "integer", Nullable: true}) {
+		t.Errorf("count column = %+v", got)
+	}
+}
+
+func TestConvertCSVValue_EmptyNullable(t *testing.T) {
+	got, err := convertCSVValue("", csvColumnType{Type: "integer", Nullable: true})
+	if err != nil || got != nil {
+		t.Fatalf("expected nil without error, got %v, %v", got, err)
+	}
+
+	if _, err := convertCSVValue("", csvColumnType{Type: "integer"}); err == nil {
+		t.Fatal("expected error for empty non-nullable integer")
+	}
+
+	got, err = convertCSVValue("7", csvColumnType{Type: "integer", Nullable: true})
+	if err != nil || got != float64(7) {
+		t.Fatalf("expected 7, got %v, %v", got, err)
+	}
+}
+
+func TestParseAndValidateFiles_JobsDeterministic(t *testing.T) {
+	root := t.TempDir()
+
+	td := &config.TypeDef{
+		Name:  "item",
+		Input: "json",
+		Schema: map[string]any{
+			"type":       "object",
+			"required":   []any{"id"},
+			"properties": map[string]any{"id": map[string]any{"type": "string"}},
+		},
+	}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+
+	var files []discovery.DiscoveredFile
+	for i := range 40 {
+		rel := fmt.Sprintf("data/%02d.json", i)
+		content := fmt.Sprintf(`{"id": "%d"}`, i)
+		switch i % 5 {
+		case 1:
+			content = `{"id": 1}` // schema error
+		case 3:
+			content = `{not json` // parse error
+		}
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, discovery.DiscoveredFile{Path: rel, TypeName: "item", TypeDef: td})
+	}
+
+	seqItems, seqParse, seqSchema := parseAndValidateFiles(root, files, cfg, nil, 1, nil)
+	if len(seqItems["item"]) != 32 || len(seqParse) != 8 || len(seqSchema) != 8 {
+		t.Fatalf("unexpected sequential results: %d items, %d parse errors, %d schema errors",
+			len(seqItems["item"]), len(seqParse), len(seqSchema))
+	}
+
+	for _, jobs := range []int{0, 4, 64} {
+		items, parseEntries, schemaEntries := parseAndValidateFiles(root, files, cfg, nil, jobs, nil)
+		if !reflect.DeepEqual(items, seqItems) {
+			t.Errorf("jobs=%d: items differ from sequential run", jobs)
+		}
+		if !reflect.DeepEqual(parseEntries, seqParse) {
+			t.Errorf("jobs=%d: parse errors differ from sequential run", jobs)
+		}
+		if !reflect.DeepEqual(schemaEntries, seqSchema) {
+			t.Errorf("jobs=%d: schema errors differ from sequential run", jobs)
+		}
+	}
+}
+
+func TestParseJSON_ExactIntegers(t *testing.T) {
+	items, errs := parseJSON([]byte(`{"id": 1234567890123456789, "tags": [{"n": 2}], "ratio": 0.5}`), "a.json")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	want := map[string]any{"id": 1234567890123456789, "tags": []any{map[string]any{"n": 2}}, "ratio": 0.5}
+	if !reflect.DeepEqual(items[0], want) {
+		t.Errorf("parsed = %#v, want %#v", items[0], want)
+	}
+
+	_, errs = parseJSON([]byte(`{"id": 1} {}`), "a.json")
+	if len(errs) != 1 || errs[0].Message != "parsing JSON: invalid character '{' after top-level value" {
+		t.Errorf("expected trailing data error, got %v", errs)
+	}
+}
+
+func TestParseAndValidateData_JSON5(t *testing.T) {
+	td := &config.TypeDef{
+		Name:  "service",
+		Input: "json5",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []any{"id", "ports"},
+			"properties": map[string]any{
+				"id":    map[string]any{"type": "string"},
+				"owner": map[string]any{"type": "integer"},
+				"mask":  map[string]any{"type": "integer"},
+				"ports": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			},
+		},
+	}
+	cfg := &config.Config{StrictMode: "ENABLED", Types: []config.TypeDef{*td}}
+	f := discovery.DiscoveredFile{Path: "svc.json5", TypeName: "service", TypeDef: td}
+
+	raw := []byte(`// service definition
+{
+  id: "api", // unquoted key
+  owner: 1234567890123456789,
+  mask: 0xFF,
+  /* trailing commas below */
+  ports: [80, 443,],
+}
+`)
+	res := parseAndValidateData(raw, f, cfg)
+	if len(res.parseEntries) != 0 || len(res.schemaEntries) != 0 {
+		t.Fatalf("expected JSON5 item to validate, got parse %v schema %v", res.parseEntries, res.schemaEntries)
+	}
+	want := map[string]any{"id": "api", "owner": 1234567890123456789, "mask": 255, "ports": []any{80, 443}}
+	if !reflect.DeepEqual(res.parsed[0], want) {
+		t.Errorf("parsed = %#v, want %#v", res.parsed[0], want)
+	}
+
+	res = parseAndValidateData([]byte("{id: 'api', ports: [], extra: true,}"), f, cfg)
+	if len(res.parseEntries) != 0 || len(res.schemaEntries) != 1 {
+		t.Errorf("expected 1 schema error for the extra property, got parse %v schema %v", res.parseEntries, res.schemaEntries)
+	}
+
+	res = parseAndValidateData([]byte("{id: 'api'} {}"), f, cfg)
+	if len(res.parseEntries) != 1 || !strings.HasPrefix(res.parseEntries[0].Message, "parsing JSON5: ") {
+		t.Errorf("expected trailing data error, got %v", res.parseEntries)
+	}
+}
+
+func TestParseDataFile_DuplicateKeys(t *testing.T) {
+	td := &config.TypeDef{Name: "doc", Input: "auto"}
+
+	_, errs := parseDataFile([]byte("{\"id\": \"a\",\n\"id\": \"b\"}"), td.Input, td, "a.json")
+	if len(errs) != 1 || errs[0].Message != `parsing JSON: line 2: key "id" already defined at line 1` {
+		t.Errorf("json: expected duplicate key error, got %v", errs)
+	}
+	_, errs = parseDataFile([]byte("id: a\nid: b\n"), td.Input, td, "a.yaml")
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, `mapping key "id" already defined at line 1`) {
+		t.Errorf("yaml: expected duplicate key error, got %v", errs)
+	}
+}
+
+func TestParseDataFile_Auto(t *testing.T) {
+	td := &config.TypeDef{Name: "doc", Input: "auto"}
+
+	items, errs := parseDataFile([]byte(`{"id": "a"}`), td.Input, td, "docs/a.json")
+	if len(errs) != 0 || len(items) != 1 || items[0]["id"] != "a" {
+		t.Fatalf("json: got items %v errs %v", items, errs)
+	}
+	items, errs = parseDataFile([]byte("id: b\n"), td.Input, td, "docs/b.yml")
+	if len(errs) != 0 || len(items) != 1 || items[0]["id"] != "b" {
+		t.Fatalf("yaml: got items %v errs %v", items, errs)
+	}
+
+	items, errs = parseDataFile([]byte("{id: 'c', /* json5 */}"), td.Input, td, "docs/c.json5")
+	if len(errs) != 0 || len(items) != 1 || items[0]["id"] != "c" {
+		t.Fatalf("json5: got items %v errs %v", items, errs)
+	}
+
+	_, errs = parseDataFile([]byte("id,name\n"), td.Input, td, "docs/c.csv")
+	if len(errs) != 1 || errs[0].Message != `input auto cannot parse ".csv" files (use .json, .json5, .yaml, or .yml)` {
+		t.Fatalf("csv: expected unsupported extension error, got %v", errs)
+	}
+}
+
+func TestParseAndValidateData_Coerce(t *testing.T) {
+	td := &config.TypeDef{
+		Name:   "item",
+		Input:  "yaml",
+		Coerce: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"count":  map[string]any{"type": "integer"},
+				"active": map[string]any{"type": "boolean"},
+				"label":  map[string]any{"type": "string"},
+			},
+		},
+	}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+	f := discovery.DiscoveredFile{Path: "item.yaml", TypeName: "item", TypeDef: td}
+
+	res := parseAndValidateData([]byte("count: \"5\"\nactive: \"true\"\nlabel: \"7\"\n"), f, cfg)
+	if len(res.parseEntries) != 0 || len(res.schemaEntries) != 0 {
+		t.Fatalf("expected coerced item to validate, got parse %v schema %v", res.parseEntries, res.schemaEntries)
+	}
+	want := map[string]any{"count": float64(5), "active": true, "label": "7"}
+	if !reflect.DeepEqual(res.parsed[0], want) {
+		t.Errorf("coerced item = %v, want %v", res.parsed[0], want)
+	}
+
+	res = parseAndValidateData([]byte("count: \"abc\"\n"), f, cfg)
+	if len(res.schemaEntries) != 1 {
+		t.Fatalf("expected 1 schema error for non-numeric string, got %v", res.schemaEntries)
+	}
+
+	td.Coerce = false
+	res = parseAndValidateData([]byte("count: \"5\"\n"), f, cfg)
+	if len(res.schemaEntries) != 1 {
+		t.Fatalf("expected schema error without coerce, got %v", res.schemaEntries)
+	}
+}
+
+func TestLoadAndValidateConfig_SkipVersionCheck(t *testing.T) {
+	dir := t.TempDir()
+	cfgText := "version: \"1.0.0\"\ntypes: []\n"
+	if err := os.WriteFile(filepath.Join(dir, ".datacur8"), []byte(cfgText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Root: dir, Version: "2.0.0", Format: "json"}
+	if _, _, code := loadAndValidateConfig(opts); code != ExitConfigInvalid {
+		t.Fatalf("expected major version mismatch to fail with %d, got %d", ExitConfigInvalid, code)
+	}
+
+	opts.SkipVersionCheck = true
+	if _, _, code := loadAndValidateConfig(opts); code != ExitOK {
+		t.Fatalf("expected --skip-version-check to bypass the mismatch, got exit %d", code)
+	}
+}
+
+func TestLoadAndValidateConfig_RemoteConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("version: \"1.0.0\"\ntypes:\n  - name: team\n    input: yaml\n    match:\n      include: [\"^teams/\"]\n    schema: {type: object}\n"))
+	}))
+	defer srv.Close()
+
+	// no .datacur8 in the root: the config comes from the URL
+	opts := Options{Root: t.TempDir(), Config: srv.URL + "/.datacur8", Version: "1.0.0", Format: "json"}
+	cfg, _, code := loadAndValidateConfig(opts)
+	if code != ExitOK || len(cfg.Types) != 1 || cfg.Types[0].Name != "team" {
+		t.Fatalf("expected remote config to load, got exit %d, %+v", code, cfg)
+	}
+
+	opts.Config = "configs/.datacur8"
+	if _, _, code := loadAndValidateConfig(opts); code != ExitConfigInvalid {
+		t.Fatalf("expected a --config that is not a URL to fail with %d, got %d", ExitConfigInvalid, code)
+	}
+}
+
+// writeLargeCSV writes a products CSV with rows data rows to root/data/products.csv
+// and returns the matching discovered file. Every 1000th row has a negative
+// price, which the schema rejects.
+func writeLargeCSV(t testing.TB, root string, rows int) discovery.DiscoveredFile {
+	var b strings.Builder
+	b.WriteString("id,price,active\n")
+	for i := range rows {
+		price := i % 500
+		if i%1000 == 999 {
+			price = -1
+		}
+		fmt.Fprintf(&b, "p%d,%d,%t\n", i, price, i%2 == 0)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data", "products.csv"), []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	td := &config.TypeDef{
+		Name:  "product",
+		Input: "csv",
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []any{"id", "price"},
+			"properties": map[string]any{
+				"id":     map[string]any{"type": "string"},
+				"price":  map[string]any{"type": "integer", "minimum": 0},
+				"active": map[string]any{"type": "boolean"},
+			},
+		},
+	}
+	return discovery.DiscoveredFile{Path: "data/products.csv", TypeName: "product", TypeDef: td}
+}
+
+func TestParseAndValidateFile_LargeCSV(t *testing.T) {
+	const rows = 5000
+	root := t.TempDir()
+	f := writeLargeCSV(t, root, rows)
+	f.TypeDef.Constraints = []config.ConstraintDef{{Type: "unique", Key: "$.id"}}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*f.TypeDef}}
+
+	// every field is schema-validated, but only the constraint key is kept
+	r := parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 0 {
+		t.Fatalf("unexpected parse errors: %v", r.parseEntries)
+	}
+	if len(r.parsed) != rows {
+		t.Fatalf("expected %d rows, got %d", rows, len(r.parsed))
+	}
+	if got := r.parsed[1234]; !reflect.DeepEqual(got, map[string]any{"id": "p1234"}) {
+		t.Errorf("unexpected row 1234: %v", got)
+	}
+	if len(r.schemaEntries) != rows/1000 {
+		t.Fatalf("expected %d schema errors, got %d", rows/1000, len(r.schemaEntries))
+	}
+	if row := r.schemaEntries[0].Row; row == nil || *row != 999 {
+		t.Errorf("expected first schema error on row 999, got %v", r.schemaEntries[0])
+	}
+
+	// an exported type keeps whole rows
+	f.TypeDef.Output = &config.OutputDef{Path: "out/products.json", Format: "json"}
+	r = parseAndValidateFile(root, f, cfg)
+	if got := r.parsed[1234]; got["id"] != "p1234" || got["price"] != 234.0 || got["active"] != true {
+		t.Errorf("unexpected row 1234: %v", got)
+	}
+	f.TypeDef.Output = nil
+
+	// a file over max_file_size is not parsed
+	cfg.MaxFileSize = 1024
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 1 || !strings.Contains(r.parseEntries[0].Message, "exceeds max_file_size") {
+		t.Errorf("expected max_file_size error, got %v", r.parseEntries)
+	}
+	cfg.MaxFileSize = 0
+
+	// a malformed record still fails the whole file
+	if err := os.WriteFile(filepath.Join(root, "data", "products.csv"), []byte("id,price,active\np1,1\"0,true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 1 || !strings.HasPrefix(r.parseEntries[0].Message, "parsing CSV: ") {
+		t.Errorf("expected CSV parse error, got %v", r.parseEntries)
+	}
+}
+
+func TestParseAndValidateFile_Latin1CSV(t *testing.T) {
+	root := t.TempDir()
+	td := &config.TypeDef{
+		Name:     "city",
+		Input:    "csv",
+		Encoding: "latin1",
+		Output:   &config.OutputDef{Path: "out/cities.json", Format: "json"}, // keeps whole rows
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name":    map[string]any{"type": "string"},
+				"country": map[string]any{"type": "string"},
+			},
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// "Zürich" and "Malmö" with ü and ö as single Latin-1 bytes
+	raw := []byte("name,country\nZ\xfcrich,CH\nMalm\xf6,SE\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "cities.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := discovery.DiscoveredFile{Path: "data/cities.csv", TypeName: "city", TypeDef: td}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+
+	r := parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 0 || len(r.schemaEntries) != 0 {
+		t.Fatalf("expected Latin-1 CSV to validate, got parse %v schema %v", r.parseEntries, r.schemaEntries)
+	}
+	if len(r.parsed) != 2 || r.parsed[0]["name"] != "Zürich" || r.parsed[1]["name"] != "Malmö" {
+		t.Errorf("unexpected rows: %v", r.parsed)
+	}
+
+	// the same bytes read as UTF-8 are not valid text
+	td.Encoding = ""
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parsed) == 2 && r.parsed[0]["name"] == "Zürich" {
+		t.Errorf("expected undecoded Latin-1 bytes not to read as Zürich")
+	}
+}
+
+func TestParseAndValidateFile_SplitCSV(t *testing.T) {
+	root := t.TempDir()
+	td := &config.TypeDef{
+		Name:   "post",
+		Input:  "csv",
+		Split:  map[string]string{"tags": ";", "scores": "|"},
+		Output: &config.OutputDef{Path: "out/posts.json", Format: "json"}, // keeps whole rows
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"id":     map[string]any{"type": "string"},
+				"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "minItems": 2},
+				"scores": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			},
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	raw := []byte("id,tags,scores\np1,a;b;c,1|2\np2,solo,\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "posts.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := discovery.DiscoveredFile{Path: "data/posts.csv", TypeName: "post", TypeDef: td}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+
+	r := parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 0 {
+		t.Fatalf("unexpected parse errors: %v", r.parseEntries)
+	}
+	if got := r.parsed[0]; !reflect.DeepEqual(got["tags"], []any{"a", "b", "c"}) || !reflect.DeepEqual(got["scores"], []any{1.0, 2.0}) {
+		t.Errorf("unexpected row 0: %v", got)
+	}
+	if got := r.parsed[1]["scores"]; !reflect.DeepEqual(got, []any{}) {
+		t.Errorf("expected empty cell to split into an empty array, got %#v", got)
+	}
+	if len(r.schemaEntries) != 1 || r.schemaEntries[0].Row == nil || *r.schemaEntries[0].Row != 1 {
+		t.Fatalf("expected minItems error on row 1 only, got %v", r.schemaEntries)
+	}
+
+	// elements convert to the items type
+	raw = []byte("id,tags,scores\np1,a;b,1|x\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "posts.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 1 || !strings.Contains(r.parseEntries[0].Message, `column "scores": element 1: invalid integer value: "x"`) {
+		t.Errorf("expected element conversion error, got %v", r.parseEntries)
+	}
+}
+
+func TestCSVKeptFields(t *testing.T) {
+	td := config.TypeDef{
+		Name:  "seat",
+		Input: "csv",
+		Schema: map[string]any{"properties": map[string]any{
+			"id":     map[string]any{"type": "string"},
+			"plan":   map[string]any{"type": "string", "enum": []any{"free", "pro"}},
+			"note":   map[string]any{"type": "string"},
+			"owner":  map[string]any{"type": "string"},
+			"region": map[string]any{"type": "string"},
+		}},
+		Constraints: []config.ConstraintDef{
+			{Type: "unique", Key: "$.id"},
+			{Type: "path_equals_attr", PathSelector: "path.region", References: &config.ReferenceDef{Key: "$.region"}},
+			{Type: "path_template_equals_attr", Template: "{path.file}", References: &config.ReferenceDef{Key: "$.note"}},
+			{Type: "unique", Key: "$path.file"},
+		},
+	}
+	team := config.TypeDef{Name: "team", Constraints: []config.ConstraintDef{
+		{Type: "foreign_key", Key: "$.lead", References: &config.ReferenceDef{Type: "seat", Key: "$.owner"}},
+	}}
+	cfg := &config.Config{Types: []config.TypeDef{td, team}}
+
+	got := csvKeptFields(cfg, &cfg.Types[0])
+	want := map[string]bool{"id": true, "region": true, "note": true, "owner": true, "plan": true}
+	if !maps.Equal(got, want) {
+		t.Errorf("csvKeptFields = %v, want %v", got, want)
+	}
+
+	// the whole item, or an exported type, needs whole rows
+	cfg.Types[0].Constraints = append(cfg.Types[0].Constraints, config.ConstraintDef{Type: "unique", Key: "$"})
+	if got := csvKeptFields(cfg, &cfg.Types[0]); got != nil {
+		t.Errorf("expected whole rows for a \"$\" key, got %v", got)
+	}
+	cfg.Types[0].Constraints = nil
+	cfg.Export = &config.ExportConfig{Combined: &config.CombinedOutputDef{Path: "out/all.jsonl"}}
+	if got := csvKeptFields(cfg, &cfg.Types[0]); got != nil {
+		t.Errorf("expected whole rows with a combined export, got %v", got)
+	}
+}
+
+func TestParseCSVReader_StrictRows(t *testing.T) {
+	td := &config.TypeDef{
+		Name:  "row",
+		Input: "csv",
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"a": map[string]any{"type": "string"},
+				"b": map[string]any{"type": "string"},
+			},
+		},
+	}
+	tests := []struct {
+		name string
+		raw  string
+		want []map[string]any
+		err  string
+	}{
+		{"short row", "a,b\n1\n", []map[string]any{{"a": "1", "b": ""}}, "row 0: has 1 fields, header has 2"},
+		{"long row", "a,b\n1,2\n3,4,5\n", []map[string]any{{"a": "1", "b": "2"}, {"a": "3", "b": "4"}}, "row 1: has 3 fields, header has 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			td.StrictRows = false
+			items, errs := parseCSVReader(strings.NewReader(tt.raw), td, "data/rows.csv")
+			if len(errs) != 0 {
+				t.Fatalf("lenient: unexpected errors: %v", errs)
+			}
+			if !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("lenient: got %#v, want %#v", items, tt.want)
+			}
+
+			td.StrictRows = true
+			items, errs = parseCSVReader(strings.NewReader(tt.raw), td, "data/rows.csv")
+			if items != nil {
+				t.Errorf("strict: expected no items, got %#v", items)
+			}
+			if len(errs) != 1 || errs[0].Message != tt.err {
+				t.Fatalf("strict: expected %q, got %v", tt.err, errs)
+			}
+			if errs[0].Row == nil {
+				t.Error("strict: expected the error to carry the row index")
+			}
+		})
+	}
+}
+
+func TestParseAndValidateFile_FoldCSV(t *testing.T) {
+	root := t.TempDir()
+	td := &config.TypeDef{
+		Name:   "order",
+		Input:  "csv",
+		Fold:   map[string]string{"items": "item"},
+		Output: &config.OutputDef{Path: "out/orders.json", Format: "json"}, // keeps whole rows
+		Schema: map[string]any{
+			"type":     "object",
+			"required": []any{"id", "items"},
+			"properties": map[string]any{
+				"id": map[string]any{"type": "string"},
+				"items": map[string]any{"type": "array", "items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name": map[string]any{"type": "string"},
+						"qty":  map[string]any{"type": "integer"},
+					},
+				}},
+			},
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	raw := []byte("id,item_1_name,item_1_qty,item_0_name,item_0_qty\no1,pear,1,apple,3\no2,,,plum,2\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "orders.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := discovery.DiscoveredFile{Path: "data/orders.csv", TypeName: "order", TypeDef: td}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+
+	r := parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 0 || len(r.schemaEntries) != 0 {
+		t.Fatalf("unexpected errors: %v %v", r.parseEntries, r.schemaEntries)
+	}
+	want := []any{map[string]any{"name": "apple", "qty": 3.0}, map[string]any{"name": "pear", "qty": 1.0}}
+	if got := r.parsed[0]["items"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected elements in index order, got %#v", got)
+	}
+	if got := r.parsed[1]["items"]; !reflect.DeepEqual(got, []any{map[string]any{"name": "plum", "qty": 2.0}}) {
+		t.Errorf("expected the empty element to be left out, got %#v", got)
+	}
+
+	// a huge index costs one element, not a slice sized by the index
+	raw = []byte("id,item_99999999999_name,item_5_name\no1,fig,kiwi\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "orders.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 0 || len(r.schemaEntries) != 0 {
+		t.Fatalf("unexpected errors: %v %v", r.parseEntries, r.schemaEntries)
+	}
+	want = []any{map[string]any{"name": "kiwi"}, map[string]any{"name": "fig"}}
+	if got := r.parsed[0]["items"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected elements for a large index in index order, got %#v", got)
+	}
+
+	// fields convert to their items property type and must be declared
+	raw = []byte("id,item_0_name,item_0_qty,item_0_color\no1,apple,x,red\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "orders.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 1 || !strings.Contains(r.parseEntries[0].Message, `CSV header "item_0_color": field "color" not found in the items schema of "items"`) {
+		t.Errorf("expected undeclared field error, got %v", r.parseEntries)
+	}
+	raw = []byte("id,item_0_name,item_0_qty\no1,apple,x\n")
+	if err := os.WriteFile(filepath.Join(root, "data", "orders.csv"), raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = parseAndValidateFile(root, f, cfg)
+	if len(r.parseEntries) != 1 || !strings.Contains(r.parseEntries[0].Message, `row 0, column "item_0_qty": invalid integer value: "x"`) {
+		t.Errorf("expected conversion error, got %v", r.parseEntries)
+	}
+}
+
+func TestParseAndValidateData_UTF16(t *testing.T) {
+	td := &config.TypeDef{Name: "city", Input: "json", Encoding: "utf-16"}
+	cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+	f := discovery.DiscoveredFile{Path: "city.json", TypeName: "city", TypeDef: td}
+
+	// UTF-16BE with a byte order mark
+	var raw []byte
+	raw = append(raw, 0xfe, 0xff)
+	for _, r := range `{"name":"Zürich"}` {
+		raw = append(raw, byte(r>>8), byte(r))
+	}
+	res := parseAndValidateData(raw, f, cfg)
+	if len(res.parseEntries) != 0 {
+		t.Fatalf("unexpected parse errors: %v", res.parseEntries)
+	}
+	if res.parsed[0]["name"] != "Zürich" {
+		t.Errorf("name = %q, want Zürich", res.parsed[0]["name"])
+	}
+}
+
+// BenchmarkParseAndValidateFile_WideCSV compares an exported type, which keeps
+// whole rows, with one whose rows keep only their constraint key. The
+// retained-B/op metric is the heap still held by the parsed rows.
+func BenchmarkParseAndValidateFile_WideCSV(b *testing.B) {
+	const rows, columns = 5000, 20
+	root := b.TempDir()
+	var sb strings.Builder
+	props := map[string]any{"id": map[string]any{"type": "string"}}
+	sb.WriteString("id")
+	for c := 1; c < columns; c++ {
+		fmt.Fprintf(&sb, ",c%02d", c)
+		props[fmt.Sprintf("c%02d", c)] = map[string]any{"type": "string"}
+	}
+	sb.WriteString("\n")
+	for i := range rows {
+		fmt.Fprintf(&sb, "r%d", i)
+		for c := 1; c < columns; c++ {
+			fmt.Fprintf(&sb, ",value-%d-%d", i, c)
+		}
+		sb.WriteString("\n")
+	}
+	if err := os.MkdirAll(filepath.Join(root, "data"), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data", "wide.csv"), []byte(sb.String()), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	td := &config.TypeDef{
+		Name:        "wide",
+		Input:       "csv",
+		Schema:      map[string]any{"type": "object", "properties": props},
+		Constraints: []config.ConstraintDef{{Type: "unique", Key: "$.id"}},
+	}
+	f := discovery.DiscoveredFile{Path: "data/wide.csv", TypeName: "wide", TypeDef: td}
+
+	for _, bc := range []struct {
+		name   string
+		output *config.OutputDef
+	}{
+		{"whole rows", &config.OutputDef{Path: "out/wide.json", Format: "json"}},
+		{"kept fields", nil},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			td.Output = bc.output
+			cfg := &config.Config{StrictMode: "DISABLED", Types: []config.TypeDef{*td}}
+			var retained uint64
+			b.ReportAllocs()
+			for b.Loop() {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				r := parseAndValidateFile(root, f, cfg)
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				if len(r.parseEntries) != 0 || len(r.parsed) != rows {
+					b.Fatalf("unexpected result: %d rows, %v", len(r.parsed), r.parseEntries)
+				}
+				retained += after.HeapAlloc - min(before.HeapAlloc, after.HeapAlloc)
+				runtime.KeepAlive(r)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
+
+func TestRunValidate_UnreadableFilesAreReported(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	cfgText := `version: "0.0.0"
+types:
+  - name: item
+    input: json
+    match:
+      include: ["^data/.*\\.json$"]
+    schema:
+      type: object
+    constraints:
+      - type: unique
+        key: "$.id"
+`
+	if err := os.WriteFile(filepath.Join(root, ".datacur8"), []byte(cfgText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writeCacheTestFile(t, root, "data/a.json", `{"id": "1"}`)
+	writeCacheTestFile(t, root, "data/b.json", `{"id": "1"}`)
+	writeCacheTestFile(t, root, "data/secret.json", `{"id": "2"}`)
+	writeCacheTestFile(t, root, "data/locked/c.json", `{"id": "3"}`)
+	for _, p := range []string{"data/secret.json", "data/locked"} {
+		full := filepath.Join(root, filepath.FromSlash(p))
+		if err := os.Chmod(full, 0); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chmod(full, 0o755) })
+	}
+	t.Chdir(root)
+
+	report := filepath.Join(t.TempDir(), "report.ndjson")
+	if code := RunValidate(false, Options{Version: "dev", Format: "ndjson", ReportFile: report}); code != ExitDataInvalid {
+		t.Fatalf("exit code = %d, want %d", code, ExitDataInvalid)
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		`"file":"data/locked","message":"cannot read \"data/locked\": permission denied"`,
+		`"file":"data/secret.json","message":"reading file:`,
+		`[unique] duplicate value`, // the readable files are still checked
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+)
+
+// csvKeptFields returns the top-level fields of td's CSV rows that are needed
+// after the rows were schema-validated, or nil when whole rows are needed:
+// the fields read by td's constraints, by foreign_key constraints of other
+// types that reference td, and the schema properties with a pattern or enum
+// that validate --fix may repair. Whole rows are kept for an exported type and
+// for a constraint selector that does not start with a field, such as "$".
+func csvKeptFields(cfg *config.Config, td *config.TypeDef) map[string]bool {
+	if td.Output != nil || cfg.Export.CombinedPath() != "" {
+		return nil
+	}
+
+	keep := make(map[string]bool)
+	whole := false
+	add := func(sel string) {
+		if sel == "" || whole {
+			return
+		}
+		s, err := selector.Parse(sel)
+		if err != nil {
+			whole = true
+			return
+		}
+		if _, ok := s.PathCapture(); ok {
+			return
+		}
+		name, ok := s.RootField()
+		if !ok {
+			whole = true
+			return
+		}
+		keep[name] = true
+	}
+
+	for _, cd := range td.Constraints {
+		add(cd.Key)
+		for _, k := range cd.Keys {
+			add(k)
+		}
+		add(cd.Left)
+		add(cd.Right)
+		switch cd.Type {
+		case "internal_reference", "path_equals_attr", "path_template_equals_attr":
+			if cd.References != nil {
+				add(cd.References.Key)
+			}
+		}
+	}
+	for _, other := range cfg.Types {
+		for _, cd := range other.Constraints {
+			if cd.Type == "foreign_key" && cd.References != nil && cd.References.Type == td.Name {
+				add(cd.References.Key)
+			}
+		}
+	}
+	if whole {
+		return nil
+	}
+
+	props, _ := td.Schema["properties"].(map[string]any)
+	for name, p := range props {
+		prop, _ := p.(map[string]any)
+		if _, ok := prop["pattern"]; ok {
+			keep[name] = true
+		}
+		if _, ok := prop["enum"]; ok {
+			keep[name] = true
+		}
+	}
+	return keep
+}
+
+// keepFields returns item with only the fields in keep, or item itself when
+// keep is nil. Kept strings are copied, since a CSV field shares its memory
+// with the rest of its record.
+func keepFields(item map[string]any, keep map[string]bool) map[string]any {
+	if keep == nil {
+		return item
+	}
+	out := make(map[string]any, len(keep))
+	for name := range keep {
+		v, ok := item[name]
+		if !ok {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			v = strings.Clone(s)
+		}
+		out[name] = v
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// RunConfigDump runs the config dump command: it loads and validates the
+// config like the other commands, then prints the effective config, with
+// extends and types_include merged and defaults applied, as YAML (the
+// default) or JSON. No file is discovered or parsed.
+// Returns exit code.
+func RunConfigDump(opts Options) int {
+	switch opts.Format {
+	case "", "yaml", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "error: --format %q is not valid for config dump; must be yaml or json\n", opts.Format)
+		return ExitConfigInvalid
+	}
+
+	cfg, rep, code := loadAndValidateConfig(opts)
+	if code != ExitOK {
+		return code
+	}
+
+	if err := writeConfigDump(os.Stdout, cfg, rep.format == "json"); err != nil {
+		rep.report([]reportEntry{{Level: "error", Type: "config", Message: fmt.Sprintf("dumping config: %v", err)}})
+		return ExitConfigInvalid
+	}
+	return ExitOK
+}
+
+// writeConfigDump writes cfg to w as YAML or, with asJSON, as indented JSON.
+// Both use the config's YAML field names, so the dump reads like a .datacur8
+// file with every default filled in.
+func writeConfigDump(w io.Writer, cfg *config.Config, asJSON bool) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	if !asJSON {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	var data any
+	if err := yaml.Unmarshal(buf.Bytes(), &data); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func TestWriteConfigDump_IncludesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	cfgText := `version: "1.0.0"
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/.*\\.yaml$"]
+    schema:
+      type: object
+    constraints:
+      - type: unique
+        key: "$.id"
+`
+	path := filepath.Join(dir, ".datacur8")
+	if err := os.WriteFile(path, []byte(cfgText), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := writeConfigDump(&out, cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"strict_mode: DISABLED\n", "        scope: type\n", "      against: path\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected YAML dump to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := writeConfigDump(&out, cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	var dumped struct {
+		StrictMode string `json:"strict_mode"`
+		Types      []struct {
+			Constraints []struct {
+				Scope string `json:"scope"`
+			} `json:"constraints"`
+		} `json:"types"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &dumped); err != nil {
+		t.Fatalf("JSON dump does not parse: %v\n%s", err, out.String())
+	}
+	if dumped.StrictMode != "DISABLED" || len(dumped.Types) != 1 || dumped.Types[0].Constraints[0].Scope != "type" {
+		t.Errorf("expected defaulted fields in JSON dump, got:\n%s", out.String())
+	}
+}
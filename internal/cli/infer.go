@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/infer"
+	"gopkg.in/yaml.v3"
+)
+
+// RunInfer runs the infer command: it parses the JSON, JSON5, and YAML files
+// selected by target, a directory (searched recursively) or a glob pattern,
+// and prints a candidate JSON Schema for them to stdout as YAML (the default)
+// or JSON. No config is read; the output is a starting point for a type's
+// schema.
+// Returns exit code.
+func RunInfer(target string, opts Options) int {
+	switch opts.Format {
+	case "", "yaml", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "error: --format %q is not valid for infer; must be yaml or json\n", opts.Format)
+		return ExitConfigInvalid
+	}
+	rep := reporter{format: "text"}
+
+	files, err := inferFiles(target)
+	if err != nil {
+		rep.report([]reportEntry{{Level: "error", Message: err.Error()}})
+		return ExitConfigInvalid
+	}
+
+	td := &config.TypeDef{Input: "auto"}
+	var samples []any
+	var parseErrs []reportEntry
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			parseErrs = append(parseErrs, reportEntry{Level: "error", File: f, Message: fmt.Sprintf("reading file: %v", err)})
+			continue
+		}
+		items, errs := parseDataFile(raw, "auto", td, f)
+		parseErrs = append(parseErrs, errs...)
+		for _, item := range items {
+			samples = append(samples, item)
+		}
+	}
+	if len(parseErrs) > 0 {
+		rep.report(parseErrs)
+		return ExitDataInvalid
+	}
+
+	if err := writeInferredSchema(os.Stdout, infer.Schema(samples), opts.Format == "json"); err != nil {
+		rep.report([]reportEntry{{Level: "error", Message: fmt.Sprintf("writing schema: %v", err)}})
+		return ExitDataInvalid
+	}
+	return ExitOK
+}
+
+// inferFiles returns the sorted JSON, JSON5, and YAML files under target when
+// it is a directory, or the files matching it as a glob pattern otherwise.
+// Matching no file is an error.
+func inferFiles(target string) ([]string, error) {
+	auto := &config.TypeDef{Input: "auto"}
+	var files []string
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		err := filepath.WalkDir(target, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && auto.InputFor(p) != "auto" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		matches, err := filepath.Glob(target)
+		if err != nil {
+			return nil, fmt.Errorf("--type %q is not a valid glob pattern: %v", target, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() {
+				files = append(files, m)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no JSON or YAML files match %q", target)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// writeInferredSchema writes schema to w as block YAML or, with asJSON, as
+// indented JSON. Both sort object keys.
+func writeInferredSchema(w io.Writer, schema map[string]any, asJSON bool) error {
+	if asJSON {
+		out, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(out, '\n'))
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(schema); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/constraints"
+	"github.com/UnitVectorY-Labs/datacur8/internal/schema"
+)
+
+// itemPath is the file name given to an item validated by ValidateItem.
+const itemPath = "<item>"
+
+// ValidateItem validates a single in-memory item against the schema of the
+// named type and the constraints that can be evaluated on that item alone,
+// for callers such as tests of data-generating code. Like validate --stdin it
+// skips constraints that need other items or files (foreign_key,
+// count_equals, path_equals_attr, path_template_equals_attr, sequence) or a
+// file path ($path selectors); file_exists is skipped too, so no file is
+// read. Warning-severity constraint violations are not returned.
+// Returns the schema errors followed by the constraint errors, or nil when
+// the item is valid.
+func ValidateItem(cfg *config.Config, typeName string, item map[string]any) []error {
+	var td *config.TypeDef
+	for i := range cfg.Types {
+		if cfg.Types[i].Name == typeName {
+			td = &cfg.Types[i]
+			break
+		}
+	}
+	if td == nil {
+		return []error{fmt.Errorf("type %q does not match any defined type", typeName)}
+	}
+
+	errs := schema.ValidateItem(td.Schema, item, cfg.StrictMode, cfg.SchemaDialectFor(td))
+
+	localType := *td
+	localType.Constraints = nil
+	for _, cd := range td.Constraints {
+		if cd.Type != "file_exists" && nonLocalReason(cd, typeName) == "" {
+			localType.Constraints = append(localType.Constraints, cd)
+		}
+	}
+	items := map[string][]constraints.Item{typeName: {{
+		TypeName:     typeName,
+		FilePath:     itemPath,
+		Data:         item,
+		PathCaptures: map[string]string{},
+		RowIndex:     -1,
+	}}}
+	for _, ce := range constraints.Evaluate(items, []config.TypeDef{localType}) {
+		if ce.Severity != "warning" {
+			errs = append(errs, &ce)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func TestValidateItem(t *testing.T) {
+	cfg := &config.Config{
+		StrictMode: "DISABLED",
+		Types: []config.TypeDef{{
+			Name:  "user",
+			Input: "json",
+			Schema: map[string]any{
+				"type":     "object",
+				"required": []any{"id", "email"},
+				"properties": map[string]any{
+					"id":    map[string]any{"type": "string"},
+					"email": map[string]any{"type": "string"},
+				},
+			},
+			Constraints: []config.ConstraintDef{
+				{Type: "format", Key: "$.email", Format: "email"},
+				{Type: "format", Key: "$.id", Format: "uuid", Severity: "warning"},
+				{Type: "foreign_key", Key: "$.team", References: &config.ReferenceDef{Type: "team", Key: "$.id"}},
+				{Type: "file_exists", Key: "$.avatar"},
+			},
+		}},
+	}
+
+	if errs := ValidateItem(cfg, "user", map[string]any{"id": "u1", "email": "a@example.com", "team": "t9", "avatar": "missing.png"}); errs != nil {
+		t.Fatalf("expected valid item, got %v", errs)
+	}
+
+	errs := ValidateItem(cfg, "user", map[string]any{"id": 7, "email": "not-an-email"})
+	if len(errs) != 2 {
+		t.Fatalf("expected a schema and a format error, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "id") {
+		t.Errorf("expected schema error for id first, got %v", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "not-an-email") {
+		t.Errorf("expected format error for email, got %v", errs[1])
+	}
+
+	// a $path selector has no file to read, so its constraint is skipped
+	cfg.Types[0].Constraints = []config.ConstraintDef{{Type: "contains", Key: "$path.region", Values: []string{"emea"}}}
+	if errs := ValidateItem(cfg, "user", map[string]any{"id": "u1", "email": "a@example.com"}); errs != nil {
+		t.Errorf("expected the path capture constraint to be skipped, got %v", errs)
+	}
+
+	errs = ValidateItem(cfg, "team", map[string]any{})
+	if len(errs) != 1 || errs[0].Error() != `type "team" does not match any defined type` {
+		t.Errorf("expected unknown type error, got %v", errs)
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/tidy"
+	"github.com/titanous/json5"
+)
+
+// unmarshalJSON decodes data into v like json.Unmarshal, but decodes numbers
+// as json.Number and then converts them with exactNumbers, so integers beyond
+// float64 precision (such as 19-digit snowflake IDs) are kept exactly. A key
+// repeated within one object is an error rather than silently keeping the
+// last value, matching YAML decoding.
+func unmarshalJSON(data []byte, v *map[string]any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		// report malformed input with json.Unmarshal's wording
+		return json.Unmarshal(data, v)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return json.Unmarshal(data, v)
+	}
+	if err := tidy.CheckJSONDuplicateKeys(data); err != nil {
+		return err
+	}
+	exactNumbers(*v)
+	return nil
+}
+
+// unmarshalJSON5 decodes JSON5 data into v with the same exact number
+// handling as unmarshalJSON. Hexadecimal integers such as 0x1F become ints;
+// Infinity and NaN become float64.
+func unmarshalJSON5(data []byte, v *map[string]any) error {
+	// json5.Unmarshal rejects trailing data, which a Decoder does not
+	if err := json5.Unmarshal(data, v); err != nil {
+		return err
+	}
+	*v = nil
+	dec := json5.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	exactNumbers(*v)
+	return nil
+}
+
+// exactNumbers replaces every json.Number (or json5.Number) nested in v, in
+// place, with the Go value YAML decoding produces for the same literal: an int
+// when it is an integer that fits, otherwise a float64.
+func exactNumbers(v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(val), 10, 0); err == nil {
+			return int(i)
+		}
+		f, _ := val.Float64()
+		return f
+	case json5.Number:
+		if i, err := strconv.ParseInt(string(val), 0, 0); err == nil {
+			return int(i)
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, e := range val {
+			val[k] = exactNumbers(e)
+		}
+	case []any:
+		for i, e := range val {
+			val[i] = exactNumbers(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+// plan describes what validate and export would do with the current config
+// and file layout, as printed by the plan command.
+type plan struct {
+	Types          []planType  `json:"types"`
+	CombinedOutput *planOutput `json:"combinedOutput,omitempty"`
+}
+
+// planType is the plan for one configured type.
+type planType struct {
+	Name        string           `json:"name"`
+	Input       string           `json:"input"`
+	FileCount   int              `json:"fileCount"`
+	Files       []string         `json:"files"`
+	Constraints []planConstraint `json:"constraints"`
+	Output      *planOutput      `json:"output,omitempty"`
+	Excluded    []planExclusion  `json:"excluded,omitempty"` // with --verbose
+}
+
+// planExclusion is a file that the type's include pattern matches but an
+// exclude pattern rules out.
+type planExclusion struct {
+	File    string `json:"file"`
+	Exclude int    `json:"exclude"` // index in match.exclude
+	Pattern string `json:"pattern"`
+}
+
+// planConstraint names a constraint the way constraint errors report it.
+type planConstraint struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// planOutput is an export target.
+type planOutput struct {
+	Path     string `json:"path"`
+	Format   string `json:"format"`
+	MaxLines int    `json:"maxLines,omitempty"`
+}
+
+// RunPlan runs the plan command: it validates the config and discovers files,
+// then prints the files matched per type, the constraints that apply, and the
+// outputs export would write. With opts.Verbose, the text plan also lists each
+// matched file, and both formats list the files each type's match.exclude
+// ruled out. No data file is parsed and nothing is written.
+// Returns exit code.
+func RunPlan(opts Options) int {
+	switch opts.Format {
+	case "", "text", "json":
+	default:
+		fmt.Fprintf(os.Stderr, "error: --format %q is not valid for plan; must be text or json\n", opts.Format)
+		return ExitConfigInvalid
+	}
+
+	cfg, rep, code := loadAndValidateConfig(opts)
+	if code != ExitOK {
+		return code
+	}
+
+	var excluded []discovery.ExcludedFile
+	if opts.Verbose {
+		opts.onExcluded = func(e discovery.ExcludedFile) { excluded = append(excluded, e) }
+	}
+	rootDir, _ := opts.rootDir() // checked by loadAndValidateConfig
+	files, discoverErrs := discover(rootDir, cfg, opts)
+	if len(discoverErrs) > 0 {
+		rep.report(discoveryErrorsToEntries(discoverErrs))
+		return ExitDiscoveryError
+	}
+
+	p := buildPlan(cfg, files, excluded)
+	if rep.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(p)
+		return ExitOK
+	}
+	writePlanText(os.Stdout, p, opts.Verbose)
+	return ExitOK
+}
+
+// buildPlan assembles the plan for cfg from the discovered and excluded
+// files, keeping the config's type order.
+func buildPlan(cfg *config.Config, files []discovery.DiscoveredFile, excluded []discovery.ExcludedFile) plan {
+	byType := make(map[string][]string)
+	for _, f := range files {
+		byType[f.TypeName] = append(byType[f.TypeName], f.Path)
+	}
+	excludedByType := make(map[string][]planExclusion)
+	for _, e := range excluded {
+		excludedByType[e.Type] = append(excludedByType[e.Type], planExclusion{File: e.Path, Exclude: e.Exclude, Pattern: e.Pattern})
+	}
+
+	p := plan{Types: []planType{}}
+	for _, td := range cfg.Types {
+		pt := planType{
+			Name:        td.Name,
+			Input:       td.Input,
+			FileCount:   len(byType[td.Name]),
+			Files:       byType[td.Name],
+			Constraints: []planConstraint{},
+			Excluded:    excludedByType[td.Name],
+		}
+		slices.SortFunc(pt.Excluded, func(a, b planExclusion) int { return strings.Compare(a.File, b.File) })
+		if pt.Files == nil {
+			pt.Files = []string{}
+		}
+		for ci, cd := range td.Constraints {
+			id := cd.ID
+			if id == "" {
+				id = fmt.Sprintf("#%d", ci)
+			}
+			pt.Constraints = append(pt.Constraints, planConstraint{ID: id, Type: cd.Type})
+		}
+		if td.Output != nil {
+			pt.Output = &planOutput{Path: td.Output.Path, Format: td.Output.Format, MaxLines: td.Output.MaxLines}
+		}
+		p.Types = append(p.Types, pt)
+	}
+	if path := cfg.Export.CombinedPath(); path != "" {
+		p.CombinedOutput = &planOutput{Path: path, Format: "jsonl"}
+	}
+	return p
+}
+
+// writePlanText prints p with one block per type. With verbose, each block
+// also lists the matched and excluded files.
+func writePlanText(w io.Writer, p plan, verbose bool) {
+	for _, t := range p.Types {
+		noun := "files"
+		if t.FileCount == 1 {
+			noun = "file"
+		}
+		fmt.Fprintf(w, "%s (%s): %d %s\n", t.Name, t.Input, t.FileCount, noun)
+		if verbose {
+			for _, f := range t.Files {
+				fmt.Fprintf(w, "  file: %s\n", f)
+			}
+			for _, e := range t.Excluded {
+				fmt.Fprintf(w, "  excluded: %s by match.exclude[%d] %q\n", e.File, e.Exclude, e.Pattern)
+			}
+		}
+		for _, c := range t.Constraints {
+			fmt.Fprintf(w, "  constraint %s: %s\n", c.ID, c.Type)
+		}
+		if t.Output != nil {
+			fmt.Fprintf(w, "  output: %s\n", formatPlanOutput(*t.Output))
+		}
+	}
+	if p.CombinedOutput != nil {
+		fmt.Fprintf(w, "combined output: %s\n", formatPlanOutput(*p.CombinedOutput))
+	}
+}
+
+func formatPlanOutput(o planOutput) string {
+	if o.MaxLines > 0 {
+		return fmt.Sprintf("%s (%s, split every %d lines)", o.Path, o.Format, o.MaxLines)
+	}
+	return fmt.Sprintf("%s (%s)", o.Path, o.Format)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+func TestBuildPlan(t *testing.T) {
+	cfg := &config.Config{
+		Types: []config.TypeDef{
+			{Name: "team", Input: "yaml",
+				Constraints: []config.ConstraintDef{{Type: "unique"}, {ID: "team-ref", Type: "foreign_key"}},
+				Output:      &config.OutputDef{Path: "out/teams.jsonl", Format: "jsonl", MaxLines: 100}},
+			{Name: "note", Input: "text"},
+		},
+	}
+	files := []discovery.DiscoveredFile{
+		{Path: "teams/a.yaml", TypeName: "team"},
+		{Path: "teams/b.yaml", TypeName: "team"},
+	}
+
+	p := buildPlan(cfg, files, nil)
+	if len(p.Types) != 2 || p.Types[0].FileCount != 2 || p.Types[1].FileCount != 0 {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+	if p.Types[1].Files == nil || p.Types[1].Constraints == nil {
+		t.Error("empty files and constraints should be non-nil so JSON output has []")
+	}
+
+	var b strings.Builder
+	writePlanText(&b, p, false)
+	want := `team (yaml): 2 files
+  constraint #0: unique
+  constraint team-ref: foreign_key
+  output: out/teams.jsonl (jsonl, split every 100 lines)
+note (text): 0 files
+`
+	if b.String() != want {
+		t.Errorf("text plan:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestBuildPlan_VerboseListsExcludedFiles(t *testing.T) {
+	cfg := &config.Config{
+		Types: []config.TypeDef{{Name: "team", Input: "yaml"}},
+	}
+	files := []discovery.DiscoveredFile{{Path: "teams/a.yaml", TypeName: "team"}}
+	excluded := []discovery.ExcludedFile{
+		{Path: "teams/old/b.yaml", Type: "team", Exclude: 1, Pattern: "/old/"},
+		{Path: "teams/draft.yaml", Type: "team", Exclude: 0, Pattern: "draft"},
+	}
+
+	p := buildPlan(cfg, files, excluded)
+	if got := p.Types[0].Excluded; len(got) != 2 || got[0].File != "teams/draft.yaml" {
+		t.Fatalf("expected exclusions sorted by file, got %+v", got)
+	}
+
+	var b strings.Builder
+	writePlanText(&b, p, true)
+	want := `team (yaml): 1 file
+  file: teams/a.yaml
+  excluded: teams/draft.yaml by match.exclude[0] "draft"
+  excluded: teams/old/b.yaml by match.exclude[1] "/old/"
+`
+	if b.String() != want {
+		t.Errorf("text plan:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
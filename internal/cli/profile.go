@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// profileStage is the timing recorded for one pipeline stage.
+type profileStage struct {
+	Stage      string  `json:"stage"`
+	DurationMs float64 `json:"durationMs"`
+	Files      int     `json:"files,omitempty"`
+	Items      int     `json:"items,omitempty"`
+}
+
+// profile records per-stage timings for --profile. A nil *profile records
+// nothing, so callers do not need to check whether profiling is enabled.
+type profile struct {
+	start  time.Time
+	stages []profileStage
+}
+
+// newProfile returns a profile started now, or nil when disabled.
+func newProfile(enabled bool) *profile {
+	if !enabled {
+		return nil
+	}
+	return &profile{start: time.Now()}
+}
+
+// record appends a stage with its duration and the files and items it handled.
+func (p *profile) record(stage string, d time.Duration, files, items int) {
+	if p == nil {
+		return
+	}
+	p.stages = append(p.stages, profileStage{
+		Stage:      stage,
+		DurationMs: float64(d.Microseconds()) / 1000,
+		Files:      files,
+		Items:      items,
+	})
+}
+
+// write prints the recorded stages and the total wall-clock time to w, as a
+// table for text output or as a JSON object for structured formats.
+func (p *profile) write(w io.Writer, format string) {
+	if p == nil {
+		return
+	}
+	total := float64(time.Since(p.start).Microseconds()) / 1000
+
+	if format != "text" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Stages  []profileStage `json:"stages"`
+			TotalMs float64        `json:"totalMs"`
+		}{p.stages, total})
+		return
+	}
+
+	fmt.Fprintln(w, "profile:")
+	for _, s := range p.stages {
+		line := fmt.Sprintf("  %-12s %10.3fms", s.Stage, s.DurationMs)
+		if s.Files > 0 {
+			line += fmt.Sprintf("  files=%d", s.Files)
+		}
+		if s.Items > 0 {
+			line += fmt.Sprintf("  items=%d", s.Items)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "  %-12s %10.3fms\n", "total", total)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileWrite_TextListsStages(t *testing.T) {
+	prof := newProfile(true)
+	prof.record("discovery", 2*time.Millisecond, 3, 0)
+	prof.record("parse", time.Millisecond, 3, 7)
+
+	var buf bytes.Buffer
+	prof.write(&buf, "text")
+	out := buf.String()
+
+	for _, want := range []string{"profile:", "discovery", "files=3", "parse", "items=7", "total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in profile output:\n%s", want, out)
+		}
+	}
+}
+
+func TestProfileWrite_StructuredIsJSON(t *testing.T) {
+	prof := newProfile(true)
+	prof.record("schema", 1500*time.Microsecond, 0, 4)
+
+	var buf bytes.Buffer
+	prof.write(&buf, "yaml")
+
+	var got struct {
+		Stages []profileStage `json:"stages"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding profile output: %v\n%s", err, buf.String())
+	}
+	want := []profileStage{{Stage: "schema", DurationMs: 1.5, Items: 4}}
+	if len(got.Stages) != 1 || got.Stages[0] != want[0] {
+		t.Fatalf("stages = %+v, want %+v", got.Stages, want)
+	}
+}
+
+func TestProfile_DisabledIsNoop(t *testing.T) {
+	prof := newProfile(false)
+	prof.record("parse", time.Millisecond, 1, 1)
+
+	var buf bytes.Buffer
+	prof.write(&buf, "text")
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output when disabled, got %q", buf.String())
+	}
+}
@@ -0,0 +1,371 @@
+package cli
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/constraints"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed report.schema.json
+var reportSchemaJSON []byte
+
+// ReportSchema returns the embedded JSON Schema describing the json report
+// output, both the flat array and the --format-by-type grouping.
+func ReportSchema() []byte {
+	return reportSchemaJSON
+}
+
+// reportEntry is a structured error/warning for JSON/YAML output.
+// Keep report.schema.json in sync when changing its fields.
+type reportEntry struct {
+	Level    string        `json:"level" yaml:"level"`
+	Type     string        `json:"type,omitempty" yaml:"type,omitempty"`
+	File     string        `json:"file,omitempty" yaml:"file,omitempty"`
+	Row      *int          `json:"row,omitempty" yaml:"row,omitempty"`
+	Instance string        `json:"instance,omitempty" yaml:"instance,omitempty"` // schema failures: JSON Pointer of the failing value in the item
+	Matches  []reportMatch `json:"matches,omitempty" yaml:"matches,omitempty"`   // ambiguous discovery: each type matching the file
+	Message  string        `json:"message" yaml:"message"`
+}
+
+// reportMatch is one type matching a file that several types match, with the
+// include pattern that matched it.
+type reportMatch struct {
+	Type    string `json:"type" yaml:"type"`
+	Include int    `json:"include" yaml:"include"` // index into the type's match.include
+	Pattern string `json:"pattern" yaml:"pattern"`
+}
+
+// reporter renders report entries according to the resolved output settings.
+type reporter struct {
+	format string // text, json, ndjson, yaml, or csv
+	byType bool   // nest json/yaml entries under their type name
+	color  bool   // colorize the level of text entries and tidy diffs
+
+	root     string // absolute repository root that reported paths are relative to
+	absPaths bool   // --path-style absolute: report file paths joined to root
+
+	file     *reportFile // --report-file: every reported entry is also written here
+	fileOnly bool        // --report-stdout=false: write entries only to file
+
+	collect *[]reportEntry // check: entries are gathered here instead of written
+}
+
+// reportFile is the destination of --report-file. It accumulates the entries
+// of every report call in a run and rewrites the file after each one, so it
+// always holds one complete document.
+type reportFile struct {
+	path    string
+	format  string // json, ndjson, yaml, or csv
+	entries []reportEntry
+}
+
+// reportFileFormat returns the format written to --report-file: the report
+// format when it is structured, otherwise json.
+func reportFileFormat(format string) string {
+	switch format {
+	case "json", "ndjson", "yaml", "csv":
+		return format
+	default:
+		return "json"
+	}
+}
+
+// write renders the accumulated entries to the file, creating its parent
+// directories as needed.
+func (f *reportFile) write(byType bool) error {
+	var buf bytes.Buffer
+	switch f.format {
+	case "csv":
+		writeCSVReport(&buf, f.entries)
+	case "ndjson":
+		writeNDJSONReport(&buf, f.entries)
+	default:
+		writeStructuredReport(&buf, f.format, byType, f.entries)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, buf.Bytes(), 0o644)
+}
+
+// report outputs entries using the reporter's format. Structured formats are
+// written to stdout; text is written to stderr. With --report-file the entries
+// are also written to that file.
+func (r reporter) report(entries []reportEntry) {
+	if r.collect != nil {
+		*r.collect = append(*r.collect, entries...)
+		return
+	}
+	if r.absPaths {
+		entries = slices.Clone(entries)
+		for i := range entries {
+			if entries[i].File != "" && entries[i].File != stdinPath {
+				entries[i].File = r.displayPath(entries[i].File)
+			}
+		}
+	}
+	if r.file != nil {
+		r.file.entries = append(r.file.entries, entries...)
+		if err := r.file.write(r.byType); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: writing --report-file: %v\n", err)
+		}
+		if r.fileOnly {
+			return
+		}
+	}
+	switch r.format {
+	case "json", "yaml":
+		writeStructuredReport(os.Stdout, r.format, r.byType, entries)
+	case "csv":
+		writeCSVReport(os.Stdout, entries)
+	case "ndjson":
+		writeNDJSONReport(os.Stdout, entries)
+	default:
+		writeTextReport(os.Stderr, entries, r.color)
+	}
+}
+
+// displayPath renders p, repo-relative or absolute, in the reporter's path
+// style: joined to root for --path-style absolute, otherwise relative to root
+// with forward slashes when p lies inside it.
+func (r reporter) displayPath(p string) string {
+	if r.root == "" {
+		return p
+	}
+	if r.absPaths {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(r.root, filepath.FromSlash(p))
+	}
+	if !filepath.IsAbs(p) {
+		return p
+	}
+	rel, err := filepath.Rel(r.root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ANSI escape sequences used to colorize text report levels.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+)
+
+// resolveColor decides whether to emit ANSI colors for a --color mode.
+// "always" and "never" are absolute; "auto" (or empty) colors only when the
+// output is a terminal and NO_COLOR is unset or empty.
+func resolveColor(mode string, terminal bool, noColor string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	default:
+		return terminal && noColor == ""
+	}
+}
+
+// isTerminal reports whether f is attached to a character device (a TTY).
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// writeStructuredReport encodes entries as JSON or YAML. When byType is set the
+// entries are grouped into an object keyed by type name.
+func writeStructuredReport(w io.Writer, format string, byType bool, entries []reportEntry) {
+	var v any = entries
+	if byType {
+		v = groupEntriesByType(entries)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(v)
+	case "yaml":
+		_ = yaml.NewEncoder(w).Encode(v)
+	}
+}
+
+// groupEntriesByType groups entries by their Type, preserving the relative
+// order of entries within each group.
+func groupEntriesByType(entries []reportEntry) map[string][]reportEntry {
+	grouped := make(map[string][]reportEntry)
+	for _, e := range entries {
+		grouped[e.Type] = append(grouped[e.Type], e)
+	}
+	return grouped
+}
+
+// writeNDJSONReport writes each entry as a minified JSON object on its own
+// line. Entries are never grouped by type, so every line stands alone.
+func writeNDJSONReport(w io.Writer, entries []reportEntry) {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		_ = enc.Encode(e)
+	}
+}
+
+// writeCSVReport writes a header row followed by one
+// level,type,file,row,message row per entry.
+func writeCSVReport(w io.Writer, entries []reportEntry) {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"level", "type", "file", "row", "message"})
+	for _, e := range entries {
+		row := ""
+		if e.Row != nil {
+			row = strconv.Itoa(*e.Row)
+		}
+		_ = cw.Write([]string{e.Level, e.Type, e.File, row, e.Message})
+	}
+	cw.Flush()
+}
+
+// writeTextReport writes one human-readable line per entry. When color is
+// set, the level is colored red for errors and yellow for warnings.
+func writeTextReport(w io.Writer, entries []reportEntry, color bool) {
+	for _, e := range entries {
+		level := e.Level + ":"
+		if color {
+			switch e.Level {
+			case "error":
+				level = ansiRed + level + ansiReset
+			case "warning":
+				level = ansiYellow + level + ansiReset
+			}
+		}
+		parts := []string{level}
+		if e.Type != "" {
+			parts = append(parts, fmt.Sprintf("[%s]", e.Type))
+		}
+		if e.File != "" {
+			parts = append(parts, e.File)
+		}
+		if e.Row != nil {
+			parts = append(parts, fmt.Sprintf("(row %d)", *e.Row))
+		}
+		parts = append(parts, e.Message)
+		fmt.Fprintln(w, strings.Join(parts, " "))
+	}
+}
+
+// hasErrorEntries reports whether any entry has the error level.
+func hasErrorEntries(entries []reportEntry) bool {
+	for _, e := range entries {
+		if e.Level == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// toReportEntries converts a slice of errors into reportEntry values.
+func toReportEntries(level, category string, errs []error) []reportEntry {
+	entries := make([]reportEntry, len(errs))
+	for i, e := range errs {
+		entries[i] = reportEntry{
+			Level:   level,
+			Type:    category,
+			Message: e.Error(),
+		}
+	}
+	return entries
+}
+
+// discoveryErrorsToEntries converts discovery errors to report entries. A file
+// matched by several types is reported against that file, with the type and
+// include pattern of each match.
+func discoveryErrorsToEntries(errs []error) []reportEntry {
+	entries := toReportEntries("error", "discovery", errs)
+	for i, err := range errs {
+		var unreadable *discovery.UnreadableError
+		if errors.As(err, &unreadable) {
+			entries[i].File = unreadable.Path
+			continue
+		}
+		var amb *discovery.AmbiguousMatchError
+		if !errors.As(err, &amb) {
+			continue
+		}
+		entries[i].File = amb.Path
+		for _, m := range amb.Matches {
+			entries[i].Matches = append(entries[i].Matches, reportMatch{Type: m.Type, Include: m.Include, Pattern: m.Pattern})
+		}
+	}
+	return entries
+}
+
+// sortEntries orders entries by level, file, row, and message, so a report
+// reads the same however its stages appended them. Entries without a row sort
+// before the rows of their file; the sort is stable for entries equal in all
+// four.
+func sortEntries(entries []reportEntry) {
+	row := func(e reportEntry) int {
+		if e.Row == nil {
+			return -1
+		}
+		return *e.Row
+	}
+	slices.SortStableFunc(entries, func(a, b reportEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Level, b.Level),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(row(a), row(b)),
+			cmp.Compare(a.Message, b.Message),
+		)
+	})
+}
+
+// splitUnreadable separates the files and directories that discovery could not
+// read, returned as error entries so the remaining files are still checked,
+// from the discovery errors that stop a run.
+func splitUnreadable(errs []error) ([]reportEntry, []error) {
+	var entries []reportEntry
+	var fatal []error
+	for _, err := range errs {
+		var unreadable *discovery.UnreadableError
+		if errors.As(err, &unreadable) {
+			entries = append(entries, discoveryErrorsToEntries([]error{err})...)
+			continue
+		}
+		fatal = append(fatal, err)
+	}
+	return entries, fatal
+}
+
+// constraintErrorsToEntries converts constraint errors to report entries.
+func constraintErrorsToEntries(errs []constraints.Error) []reportEntry {
+	entries := make([]reportEntry, len(errs))
+	for i, e := range errs {
+		entries[i] = reportEntry{
+			Level:   e.Severity,
+			Type:    e.TypeName,
+			File:    e.FilePath,
+			Message: fmt.Sprintf("[%s] %s", e.ConstraintType, e.Message),
+		}
+		if e.RowIndex >= 0 {
+			entries[i].Row = new(e.RowIndex)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteStructuredReport_ByTypeJSON(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "error", Type: "team", File: "teams/a.yaml", Message: "first"},
+		{Level: "error", Type: "service", File: "services/x.yaml", Message: "second"},
+		{Level: "error", Type: "team", File: "teams/b.yaml", Message: "third"},
+	}
+
+	var buf bytes.Buffer
+	writeStructuredReport(&buf, "json", true, entries)
+
+	var got map[string][]reportEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("parsing grouped JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 type groups, got %d: %v", len(got), got)
+	}
+	if len(got["team"]) != 2 || got["team"][0].File != "teams/a.yaml" || got["team"][1].File != "teams/b.yaml" {
+		t.Errorf("unexpected team group: %+v", got["team"])
+	}
+	if len(got["service"]) != 1 || got["service"][0].Message != "second" {
+		t.Errorf("unexpected service group: %+v", got["service"])
+	}
+}
+
+func TestWriteStructuredReport_ByTypeYAML(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "error", Type: "team", Message: "first"},
+		{Level: "error", Type: "service", Message: "second"},
+	}
+
+	var buf bytes.Buffer
+	writeStructuredReport(&buf, "yaml", true, entries)
+
+	var got map[string][]reportEntry
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("parsing grouped YAML: %v\n%s", err, buf.String())
+	}
+	if len(got["team"]) != 1 || len(got["service"]) != 1 {
+		t.Fatalf("unexpected grouped YAML: %v", got)
+	}
+}
+
+func TestWriteStructuredReport_FlatByDefault(t *testing.T) {
+	entries := []reportEntry{{Level: "error", Type: "team", Message: "first"}}
+
+	var buf bytes.Buffer
+	writeStructuredReport(&buf, "json", false, entries)
+
+	var got []reportEntry
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected flat JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+}
+
+func TestWriteCSVReport_HeaderAndQuoting(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "error", Type: "record", File: "data/records.csv", Row: new(3), Message: "bad value, expected integer"},
+		{Level: "error", Type: "config", Message: "plain"},
+	}
+
+	var buf bytes.Buffer
+	writeCSVReport(&buf, entries)
+
+	want := "level,type,file,row,message\n" +
+		"error,record,data/records.csv,3,\"bad value, expected integer\"\n" +
+		"error,config,,,plain\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV report:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteNDJSONReport_OneEntryPerLine(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "error", Type: "record", File: "data/records.csv", Row: new(3), Message: "bad value\nsecond line"},
+		{Level: "warning", Type: "legacy", File: "legacy/a.json", Message: "deprecated"},
+		{Level: "error", Type: "config", Message: "plain"},
+	}
+
+	var buf bytes.Buffer
+	writeNDJSONReport(&buf, entries)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(entries) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(entries), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var got reportEntry
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d does not parse on its own: %v\n%s", i, err, line)
+		}
+		if got.Message != entries[i].Message || got.File != entries[i].File {
+			t.Errorf("line %d = %+v, want %+v", i, got, entries[i])
+		}
+	}
+	if lines[0] != `{"level":"error","type":"record","file":"data/records.csv","row":3,"message":"bad value\nsecond line"}` {
+		t.Errorf("expected a minified entry, got %s", lines[0])
+	}
+
+	buf.Reset()
+	writeNDJSONReport(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output for no entries, got %q", buf.String())
+	}
+}
+
+func TestReportSchema_ValidatesReportOutput(t *testing.T) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(ReportSchema(), &s); err != nil {
+		t.Fatalf("decoding report schema: %v", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatalf("resolving report schema: %v", err)
+	}
+
+	entries := []reportEntry{
+		{Level: "error", Type: "record", File: "data/records.csv", Row: new(3), Message: "[unique] duplicate"},
+		{Level: "warning", Type: "legacy", File: "legacy/a.json", Message: "type \"legacy\" is deprecated: use v2"},
+		{Level: "error", Type: "config", Message: "invalid config"},
+	}
+
+	for _, byType := range []bool{false, true} {
+		var buf bytes.Buffer
+		writeStructuredReport(&buf, "json", byType, entries)
+		var instance any
+		if err := json.Unmarshal(buf.Bytes(), &instance); err != nil {
+			t.Fatalf("decoding report (byType=%v): %v", byType, err)
+		}
+		if err := resolved.Validate(instance); err != nil {
+			t.Errorf("report (byType=%v) does not match schema: %v", byType, err)
+		}
+	}
+}
+
+func TestReportSchema_RejectsUnknownLevel(t *testing.T) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(ReportSchema(), &s); err != nil {
+		t.Fatalf("decoding report schema: %v", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		t.Fatalf("resolving report schema: %v", err)
+	}
+
+	instance := []any{map[string]any{"level": "info", "message": "x"}}
+	if err := resolved.Validate(instance); err == nil {
+		t.Fatal("expected schema validation error for unknown level")
+	}
+}
+
+func TestResolveColor(t *testing.T) {
+	tests := []struct {
+		mode     string
+		terminal bool
+		noColor  string
+		want     bool
+	}{
+		{"always", false, "", true},
+		{"always", false, "1", true},
+		{"never", true, "", false},
+		{"auto", true, "", true},
+		{"auto", true, "1", false},
+		{"auto", false, "", false},
+		{"", true, "", true},
+	}
+	for _, tt := range tests {
+		if got := resolveColor(tt.mode, tt.terminal, tt.noColor); got != tt.want {
+			t.Errorf("resolveColor(%q, %v, %q) = %v, want %v", tt.mode, tt.terminal, tt.noColor, got, tt.want)
+		}
+	}
+}
+
+func TestWriteTextReport_Color(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "error", Type: "team", File: "teams/a.yaml", Message: "bad"},
+		{Level: "warning", Type: "legacy", Message: "old"},
+	}
+
+	var plain bytes.Buffer
+	writeTextReport(&plain, entries, false)
+	if strings.Contains(plain.String(), "\x1b[") {
+		t.Fatalf("expected no ANSI codes, got %q", plain.String())
+	}
+	if plain.String() != "error: [team] teams/a.yaml bad\nwarning: [legacy] old\n" {
+		t.Fatalf("unexpected plain output: %q", plain.String())
+	}
+
+	var colored bytes.Buffer
+	writeTextReport(&colored, entries, true)
+	want := ansiRed + "error:" + ansiReset + " [team] teams/a.yaml bad\n" +
+		ansiYellow + "warning:" + ansiReset + " [legacy] old\n"
+	if colored.String() != want {
+		t.Fatalf("colored output = %q, want %q", colored.String(), want)
+	}
+}
+
+func TestReporterDisplayPath(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "repo")
+	out := filepath.Join(root, "out", "teams.json")
+
+	rel := reporter{root: root}
+	if got := rel.displayPath(out); got != "out/teams.json" {
+		t.Errorf("relative: got %q", got)
+	}
+	if got := rel.displayPath("data/a.json"); got != "data/a.json" {
+		t.Errorf("relative: got %q", got)
+	}
+
+	abs := reporter{root: root, absPaths: true}
+	if got := abs.displayPath("data/a.json"); got != filepath.Join(root, "data", "a.json") {
+		t.Errorf("absolute: got %q", got)
+	}
+	if got := abs.displayPath(out); got != out {
+		t.Errorf("absolute: got %q", got)
+	}
+}
+
+func TestSortEntries_ShuffledInputSortsIdentically(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "warning", Type: "legacy", File: "legacy/a.json", Message: `type "legacy" is deprecated: use current`},
+		{Level: "error", Type: "item", File: "data/b.csv", Row: new(2), Message: "[unique] duplicate value"},
+		{Level: "error", Type: "item", File: "data/b.csv", Row: new(0), Message: "missing property"},
+		{Level: "error", Type: "item", File: "data/b.csv", Message: "parsing CSV: bad quote"},
+		{Level: "error", Type: "item", File: "data/a.json", Message: "[format] invalid email"},
+		{Level: "error", Type: "item", File: "data/a.json", Message: "[compare] expected $.a <= $.b"},
+		{Level: "error", Type: "discovery", Message: "walking directory: boom"},
+	}
+	want := []string{
+		"error  walking directory: boom",
+		"error data/a.json [compare] expected $.a <= $.b",
+		"error data/a.json [format] invalid email",
+		"error data/b.csv parsing CSV: bad quote",
+		"error data/b.csv missing property",
+		"error data/b.csv [unique] duplicate value",
+		`warning legacy/a.json type "legacy" is deprecated: use current`,
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for range 20 {
+		shuffled := slices.Clone(entries)
+		r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		sortEntries(shuffled)
+		got := make([]string, len(shuffled))
+		for i, e := range shuffled {
+			got[i] = e.Level + " " + e.File + " " + e.Message
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("sorted entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+// gitChangedFiles returns the repo-relative paths under rootDir that differ
+// from ref in the working tree (staged or not), plus untracked files that are
+// not ignored. Paths are read NUL-separated (-z), so git does not quote
+// names with non-ASCII or special characters.
+func gitChangedFiles(rootDir, ref string) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "-z", "--relative", ref, "--"},
+		{"ls-files", "-z", "--others", "--exclude-standard"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = rootDir
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("--since %q: git %s: %s", ref, args[0], msg)
+			}
+			return nil, fmt.Errorf("--since %q: git %s: %w", ref, args[0], err)
+		}
+		for p := range strings.SplitSeq(string(out), "\x00") {
+			if p != "" {
+				changed[p] = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+// sinceFiles narrows discovered files to those in changed, plus every file of
+// a type referenced by a foreign_key of a changed file's type so that
+// references to unchanged files still resolve. Constraints that look at all
+// items of a type also get every file of it: both types of a count_equals and
+// a type with a sequence or all_equal constraint, whenever any file of them is
+// checked. Report entries for the unchanged files are later dropped with
+// onlyChangedEntries.
+func sinceFiles(files []discovery.DiscoveredFile, changed map[string]bool, types []config.TypeDef) []discovery.DiscoveredFile {
+	checked := make(map[string]bool) // types with at least one file kept
+	full := make(map[string]bool)    // types with every file kept
+	for _, f := range files {
+		if !changed[f.Path] {
+			continue
+		}
+		checked[f.TypeName] = true
+		for _, cd := range f.TypeDef.Constraints {
+			if cd.Type == "foreign_key" && cd.References != nil {
+				full[cd.References.Type] = true
+			}
+		}
+	}
+	for name := range full {
+		checked[name] = true
+	}
+
+	// a type pulled in whole can bring in others, so repeat until stable
+	for grew := true; grew; {
+		grew = false
+		keep := func(name string) {
+			if !full[name] {
+				full[name], checked[name], grew = true, true, true
+			}
+		}
+		for _, td := range types {
+			for _, cd := range td.Constraints {
+				switch cd.Type {
+				case "count_equals":
+					if cd.References != nil && (checked[td.Name] || checked[cd.References.Type]) {
+						keep(td.Name)
+						keep(cd.References.Type)
+					}
+				case "sequence", "all_equal":
+					if checked[td.Name] {
+						keep(td.Name)
+					}
+				}
+			}
+		}
+	}
+
+	var out []discovery.DiscoveredFile
+	for _, f := range files {
+		if changed[f.Path] || full[f.TypeName] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// partialTypes returns the types of files that kept only some of their files
+// in narrowed.
+func partialTypes(files, narrowed []discovery.DiscoveredFile) map[string]bool {
+	left := make(map[string]int)
+	for _, f := range files {
+		left[f.TypeName]++
+	}
+	for _, f := range narrowed {
+		left[f.TypeName]--
+	}
+	partial := make(map[string]bool)
+	for name, n := range left {
+		if n > 0 {
+			partial[name] = true
+		}
+	}
+	return partial
+}
+
+// sinceWarnings returns a warning for each type-scoped unique constraint of a
+// type with changed files, since unchanged items are not part of the check.
+func sinceWarnings(files []discovery.DiscoveredFile, changed map[string]bool, types []config.TypeDef) []reportEntry {
+	changedTypes := make(map[string]bool)
+	for _, f := range files {
+		if changed[f.Path] {
+			changedTypes[f.TypeName] = true
+		}
+	}
+
+	var entries []reportEntry
+	for _, td := range types {
+		if !changedTypes[td.Name] {
+			continue
+		}
+		for ci, cd := range td.Constraints {
+			if cd.Type != "unique" || cd.Scope == "item" {
+				continue
+			}
+			entries = append(entries, reportEntry{
+				Level:   "warning",
+				Type:    td.Name,
+				Message: fmt.Sprintf("unique constraint %s is only checked among changed files with --since", constraintLabel(cd, ci)),
+			})
+		}
+	}
+	return entries
+}
+
+// onlyChangedEntries drops entries reported for files outside changed.
+// Entries without a file are kept.
+func onlyChangedEntries(entries []reportEntry, changed map[string]bool) []reportEntry {
+	var out []reportEntry
+	for _, e := range entries {
+		if e.File == "" || changed[e.File] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
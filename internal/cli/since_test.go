@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/discovery"
+)
+
+func TestSinceFiles_KeepsChangedAndReferencedTypes(t *testing.T) {
+	team := &config.TypeDef{Name: "team"}
+	region := &config.TypeDef{Name: "region"}
+	service := &config.TypeDef{Name: "service", Constraints: []config.ConstraintDef{
+		{Type: "foreign_key", Key: "$.team", References: &config.ReferenceDef{Type: "team", Key: "$.id"}},
+	}}
+	files := []discovery.DiscoveredFile{
+		{Path: "regions/eu.json", TypeName: "region", TypeDef: region},
+		{Path: "services/a.json", TypeName: "service", TypeDef: service},
+		{Path: "services/b.json", TypeName: "service", TypeDef: service},
+		{Path: "teams/x.json", TypeName: "team", TypeDef: team},
+		{Path: "teams/y.json", TypeName: "team", TypeDef: team},
+	}
+
+	got := sinceFiles(files, map[string]bool{"services/a.json": true, "README.md": true}, nil)
+	var paths []string
+	for _, f := range got {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"services/a.json", "teams/x.json", "teams/y.json"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("sinceFiles = %v, want %v", paths, want)
+	}
+
+	// teams are loaded whole; services and regions are not
+	partial := partialTypes(files, got)
+	if !maps.Equal(partial, map[string]bool{"region": true, "service": true}) {
+		t.Errorf("partialTypes = %v", partial)
+	}
+}
+
+func TestOnlyChangedEntries(t *testing.T) {
+	entries := []reportEntry{
+		{Level: "warning", Message: "no file"},
+		{Level: "error", File: "a.json", Message: "changed"},
+		{Level: "error", File: "b.json", Message: "unchanged"},
+	}
+	got := onlyChangedEntries(entries, map[string]bool{"a.json": true})
+	if len(got) != 2 || got[0].Message != "no file" || got[1].Message != "changed" {
+		t.Errorf("unexpected entries: %+v", got)
+	}
+}
+
+func TestSinceFiles_KeepsWholeTypesForCountAndSequence(t *testing.T) {
+	license := config.TypeDef{Name: "license"}
+	seat := config.TypeDef{Name: "seat", Constraints: []config.ConstraintDef{
+		{Type: "count_equals", References: &config.ReferenceDef{Type: "license"}},
+	}}
+	release := config.TypeDef{Name: "release", Constraints: []config.ConstraintDef{
+		{Type: "sequence", Key: "$.n"},
+	}}
+	other := config.TypeDef{Name: "other"}
+	types := []config.TypeDef{license, seat, release, other}
+	files := []discovery.DiscoveredFile{
+		{Path: "licenses/l1.yaml", TypeName: "license", TypeDef: &types[0]},
+		{Path: "licenses/l2.yaml", TypeName: "license", TypeDef: &types[0]},
+		{Path: "others/o1.yaml", TypeName: "other", TypeDef: &types[3]},
+		{Path: "others/o2.yaml", TypeName: "other", TypeDef: &types[3]},
+		{Path: "releases/1.yaml", TypeName: "release", TypeDef: &types[2]},
+		{Path: "releases/2.yaml", TypeName: "release", TypeDef: &types[2]},
+		{Path: "seats/s1.yaml", TypeName: "seat", TypeDef: &types[1]},
+		{Path: "seats/s2.yaml", TypeName: "seat", TypeDef: &types[1]},
+	}
+	paths := func(got []discovery.DiscoveredFile) []string {
+		var out []string
+		for _, f := range got {
+			out = append(out, f.Path)
+		}
+		return out
+	}
+
+	// a change on either side of count_equals loads both types
+	got := paths(sinceFiles(files, map[string]bool{"licenses/l1.yaml": true}, types))
+	want := []string{"licenses/l1.yaml", "licenses/l2.yaml", "seats/s1.yaml", "seats/s2.yaml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sinceFiles = %v, want %v", got, want)
+	}
+
+	got = paths(sinceFiles(files, map[string]bool{"releases/2.yaml": true, "others/o1.yaml": true}, types))
+	want = []string{"others/o1.yaml", "releases/1.yaml", "releases/2.yaml"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sinceFiles = %v, want %v", got, want)
+	}
+}
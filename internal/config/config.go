@@ -2,74 +2,231 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"path"
+	"strconv"
+	"strings"
 
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Version    string      `yaml:"version"`
-	StrictMode string      `yaml:"strict_mode,omitempty"`
-	Types      []TypeDef   `yaml:"types"`
-	Tidy       *TidyConfig `yaml:"tidy,omitempty"`
+	Version        string        `yaml:"version"`
+	StrictMode     string        `yaml:"strict_mode,omitempty"`
+	FollowSymlinks bool          `yaml:"follow_symlinks,omitempty"`
+	MaxFileSize    ByteSize      `yaml:"max_file_size,omitempty"`  // largest data file read; DefaultMaxFileSize when unset
+	SchemaDialect  string        `yaml:"schema_dialect,omitempty"` // "draft-07" or "2020-12"; unset keeps each schema's own $schema
+	Types          []TypeDef     `yaml:"types"`
+	Tidy           *TidyConfig   `yaml:"tidy,omitempty"`
+	Cache          *CacheConfig  `yaml:"cache,omitempty"`
+	Export         *ExportConfig `yaml:"export,omitempty"`
 }
 
 type TypeDef struct {
-	Name        string          `yaml:"name"`
-	Input       string          `yaml:"input"`
-	Match       MatchDef        `yaml:"match"`
-	Schema      map[string]any  `yaml:"schema"`
-	Constraints []ConstraintDef `yaml:"constraints,omitempty"`
-	Output      *OutputDef      `yaml:"output,omitempty"`
+	Name          string            `yaml:"name"`
+	Input         string            `yaml:"input"`
+	Match         MatchDef          `yaml:"match"`
+	Schema        map[string]any    `yaml:"schema"`
+	Constraints   []ConstraintDef   `yaml:"constraints,omitempty"`
+	Output        *OutputDef        `yaml:"output,omitempty"`
+	Deprecated    string            `yaml:"deprecated,omitempty"`
+	Coerce        bool              `yaml:"coerce,omitempty"`         // json/yaml only: convert string values to schema number/integer/boolean types
+	Encoding      string            `yaml:"encoding,omitempty"`       // character encoding of the data files; "utf-8" (default), "latin1", "utf-16", ...
+	MaxFileSize   ByteSize          `yaml:"max_file_size,omitempty"`  // overrides the top-level max_file_size for this type
+	Split         map[string]string `yaml:"split,omitempty"`          // csv only: property -> separator splitting its cells into an array
+	Fold          map[string]string `yaml:"fold,omitempty"`           // csv only: array property -> prefix of its <prefix>_<N>_<field> columns
+	StrictRows    bool              `yaml:"strict_rows,omitempty"`    // csv only: report rows whose field count differs from the header
+	SchemaDialect string            `yaml:"schema_dialect,omitempty"` // overrides the top-level schema_dialect for this type
 }
 
 type MatchDef struct {
 	Include []string `yaml:"include"`
 	Exclude []string `yaml:"exclude,omitempty"`
+	Against string   `yaml:"against,omitempty"`
 }
 
 type OutputDef struct {
-	Path   string `yaml:"path"`
-	Format string `yaml:"format"`
+	Path      string `yaml:"path"`
+	Format    string `yaml:"format"`
+	YAMLStyle string `yaml:"yaml_style,omitempty"` // yaml format only: "block" (default) or "flow"
+	Banner    string `yaml:"banner,omitempty"`     // yaml: leading comment; json: "_generated" marker
+	MaxLines  int    `yaml:"max_lines,omitempty"`  // jsonl only: split into numbered parts of at most this many lines
+	Pretty    *bool  `yaml:"pretty,omitempty"`     // json only: indent the output (default true); false minifies it
 }
 
 type ConstraintDef struct {
 	ID            string        `yaml:"id,omitempty"`
 	Type          string        `yaml:"type"`
+	Severity      string        `yaml:"severity,omitempty"` // "error" (default) or "warning"
 	Key           string        `yaml:"key,omitempty"`
+	Keys          []string      `yaml:"keys,omitempty"`
+	RequiredOne   bool          `yaml:"required_one,omitempty"`
+	Value         string        `yaml:"value,omitempty"`
+	Values        []string      `yaml:"values,omitempty"`
+	By            string        `yaml:"by,omitempty"`
+	Format        string        `yaml:"format,omitempty"`
 	CaseSensitive *bool         `yaml:"case_sensitive,omitempty"`
+	Compare       string        `yaml:"compare,omitempty"`
 	Scope         string        `yaml:"scope,omitempty"`
 	PathSelector  string        `yaml:"path_selector,omitempty"`
+	RequirePath   bool          `yaml:"require_path,omitempty"`
+	Delta         int           `yaml:"delta,omitempty"`    // count_equals only: allowed difference between the counts
+	BaseDir       string        `yaml:"base_dir,omitempty"` // file_exists only: repository directory the paths are relative to
+	Start         *int          `yaml:"start,omitempty"`    // sequence only: required first value; unset accepts any first value
+	Step          int           `yaml:"step,omitempty"`     // sequence only: difference between consecutive values (default 1)
+	Left          string        `yaml:"left,omitempty"`     // compare only: selector of the left-hand value
+	Right         string        `yaml:"right,omitempty"`    // compare only: selector of the right-hand value
+	Operator      string        `yaml:"operator,omitempty"` // compare only: lt, le, gt, ge, eq, or ne
+	Template      string        `yaml:"template,omitempty"` // path_template_equals_attr only: text with {path.<name>} placeholders
 	References    *ReferenceDef `yaml:"references,omitempty"`
 }
 
 type ReferenceDef struct {
-	Type string `yaml:"type,omitempty"`
-	Key  string `yaml:"key,omitempty"`
+	Type         string `yaml:"type,omitempty"`
+	Key          string `yaml:"key,omitempty"`
+	PathSelector string `yaml:"path_selector,omitempty"` // foreign_key only: match against target path captures instead of Key
+	Unique       bool   `yaml:"unique,omitempty"`        // foreign_key only: each key must match exactly one target item
+	Optional     bool   `yaml:"optional,omitempty"`      // foreign_key only: empty string and null keys are not looked up
+	Suggest      bool   `yaml:"suggest,omitempty"`       // foreign_key only: list valid target values when a key is not found
 }
 
 type TidyConfig struct {
-	Enabled *bool `yaml:"enabled,omitempty"`
+	Enabled          *bool `yaml:"enabled,omitempty"`
+	SortColumns      *bool `yaml:"sort_columns,omitempty"`
+	CanonicalNumbers bool  `yaml:"canonical_numbers,omitempty"` // rewrite CSV number and integer cells in canonical form
+}
+
+type CacheConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+}
+
+type ExportConfig struct {
+	Combined      *CombinedOutputDef `yaml:"combined,omitempty"`
+	Manifest      *ManifestDef       `yaml:"manifest,omitempty"`
+	GitAttributes *GitAttributesDef  `yaml:"gitattributes,omitempty"`
+}
+
+// CombinedOutputDef is a JSONL file holding the items of every type, each
+// line tagged with its type name under "_type".
+type CombinedOutputDef struct {
+	Path string `yaml:"path"`
+}
+
+// ManifestDef is a JSON file written after the outputs, listing each exported
+// file with its SHA-256 and item count.
+type ManifestDef struct {
+	Path string `yaml:"path"`
+}
+
+// GitAttributesDef is a .gitattributes file in which export keeps a block
+// marking every exported file as generated, so diffs collapse them.
+type GitAttributesDef struct {
+	Path string `yaml:"path"`
+}
+
+// DefaultMaxFileSize is the largest data file read when max_file_size is not
+// set.
+const DefaultMaxFileSize ByteSize = 256 << 20
+
+// ByteSize is a size in bytes, written in the config as an integer or as a
+// number with a B, KB, MB, or GB suffix (powers of 1024).
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	shift  uint
+}{{"GB", 30}, {"MB", 20}, {"KB", 10}, {"B", 0}}
+
+// UnmarshalYAML accepts an integer byte count or a string such as "512MB".
+func (b *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	s := node.Value
+	for _, u := range byteSizeUnits {
+		if num, ok := strings.CutSuffix(s, u.suffix); ok {
+			n, err := strconv.ParseInt(num, 10, 64)
+			if err != nil || n < 0 || n > (1<<63-1)>>u.shift {
+				break
+			}
+			*b = ByteSize(n << u.shift)
+			return nil
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size %q: want bytes or a number with B, KB, MB, or GB", s)
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+// SchemaDialectFor returns the JSON Schema dialect the schema of type t is
+// validated under: the type's schema_dialect, else the top-level one, else ""
+// for the schema's own $schema.
+func (c *Config) SchemaDialectFor(t *TypeDef) string {
+	if t != nil && t.SchemaDialect != "" {
+		return t.SchemaDialect
+	}
+	return c.SchemaDialect
+}
+
+// MaxFileSizeFor returns the largest data file of type t that is read: the
+// type's max_file_size, else the top-level one, else DefaultMaxFileSize.
+func (c *Config) MaxFileSizeFor(t *TypeDef) ByteSize {
+	if t != nil && t.MaxFileSize > 0 {
+		return t.MaxFileSize
+	}
+	if c.MaxFileSize > 0 {
+		return c.MaxFileSize
+	}
+	return DefaultMaxFileSize
 }
 
 // Load reads and parses a .datacur8 YAML config file at the given path.
+// When the file declares extends, the base config is loaded first and this
+// file is deep-merged on top of it before schema validation.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	cfg, _, err := load(path, false)
+	return cfg, err
+}
+
+// LoadLenient is Load for configs written for a newer CLI: top-level keys the
+// config schema does not declare are dropped, with a warning for each, instead
+// of failing schema validation. Any other schema failure is still an error.
+func LoadLenient(path string) (*Config, []string, error) {
+	return load(path, true)
+}
+
+func load(path string, lenient bool) (*Config, []string, error) {
+	cfgData, err := loadConfigData(path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("reading config file: %w", err)
+		return nil, nil, err
 	}
 
-	if err := validateAgainstEmbeddedSchema(data); err != nil {
-		return nil, err
+	var warnings []string
+	if lenient {
+		warnings, err = dropUnknownTopLevelKeys(cfgData)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := validateConfigData(cfgData); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := yaml.Marshal(cfgData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding merged config: %w", err)
 	}
 
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parsing config file: %w", err)
+		return nil, nil, fmt.Errorf("parsing config file: %w", err)
 	}
 
 	cfg.Defaults()
-	return &cfg, nil
+	return &cfg, warnings, nil
 }
 
 // Defaults applies default values to the config where fields are unset.
@@ -81,6 +238,10 @@ func (c *Config) Defaults() {
 	for i := range c.Types {
 		t := &c.Types[i]
 
+		if t.Match.Against == "" {
+			t.Match.Against = "path"
+		}
+
 		for j := range t.Constraints {
 			con := &t.Constraints[j]
 			if con.Scope == "" {
@@ -90,12 +251,158 @@ func (c *Config) Defaults() {
 	}
 }
 
+// InputFor returns the input format used to parse the file at p. For input
+// auto it is chosen by the file extension: "json" for .json, "json5" for
+// .json5, and "yaml" for .yaml or .yml. Any other extension leaves "auto",
+// which no parser accepts.
+func (t *TypeDef) InputFor(p string) string {
+	if t.Input != "auto" {
+		return t.Input
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".json":
+		return "json"
+	case ".json5":
+		return "json5"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return t.Input
+	}
+}
+
+// encodings maps each supported encoding name to its decoder. utf-8 maps to nil
+// because data files in UTF-8 are parsed as they are.
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        nil,
+	"latin1":       charmap.ISO8859_1,
+	"windows-1252": charmap.Windows1252,
+	"utf-16":       unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+}
+
+// Decoder returns a decoder converting the type's data files to UTF-8, or nil
+// when they are already UTF-8 (encoding unset or "utf-8"). utf-16 follows a
+// leading byte order mark and assumes little-endian without one.
+func (t *TypeDef) Decoder() *encoding.Decoder {
+	enc := encodings[t.Encoding]
+	if enc == nil {
+		return nil
+	}
+	return enc.NewDecoder()
+}
+
+// IsNumericCompare returns true if compare is set to numeric.
+func (c *ConstraintDef) IsNumericCompare() bool {
+	return c.Compare == "numeric"
+}
+
+// IsWarning returns true when violations of the constraint are reported as
+// warnings, which do not affect the exit code.
+func (c *ConstraintDef) IsWarning() bool {
+	return c.Severity == "warning"
+}
+
 // IsCaseSensitive returns true if case_sensitive is nil (unset) or explicitly true.
 func (c *ConstraintDef) IsCaseSensitive() bool {
 	return c.CaseSensitive == nil || *c.CaseSensitive
 }
 
+// RequiredValues returns Value (when set) followed by Values.
+func (c *ConstraintDef) RequiredValues() []string {
+	var out []string
+	if c.Value != "" {
+		out = append(out, c.Value)
+	}
+	return append(out, c.Values...)
+}
+
 // IsEnabled returns true if the TidyConfig is nil, Enabled is nil (unset), or explicitly true.
 func (t *TidyConfig) IsEnabled() bool {
 	return t == nil || t.Enabled == nil || *t.Enabled
 }
+
+// ShouldSortColumns returns true if the TidyConfig is nil, SortColumns is nil (unset), or explicitly true.
+func (t *TidyConfig) ShouldSortColumns() bool {
+	return t == nil || t.SortColumns == nil || *t.SortColumns
+}
+
+// ShouldCanonicalizeNumbers returns true if CanonicalNumbers is set on a non-nil TidyConfig.
+func (t *TidyConfig) ShouldCanonicalizeNumbers() bool {
+	return t != nil && t.CanonicalNumbers
+}
+
+// IsPretty returns true if pretty is nil (unset) or explicitly true.
+func (o *OutputDef) IsPretty() bool {
+	return o.Pretty == nil || *o.Pretty
+}
+
+// PartPath returns the path of part i of a split JSONL output: the part number
+// is inserted before the extension, so out/items.jsonl becomes out/items.2.jsonl.
+func (o *OutputDef) PartPath(i int) string {
+	ext := path.Ext(o.Path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(o.Path, ext), i, ext)
+}
+
+// IndexPath returns the path of the index listing the parts of a split JSONL
+// output, such as out/items.index.json for out/items.jsonl.
+func (o *OutputDef) IndexPath() string {
+	return strings.TrimSuffix(o.Path, path.Ext(o.Path)) + ".index.json"
+}
+
+// IsSplitPath reports whether p is the index or a part file of o when o is
+// split by max_lines.
+func (o *OutputDef) IsSplitPath(p string) bool {
+	if o.MaxLines <= 0 {
+		return false
+	}
+	if p == o.IndexPath() {
+		return true
+	}
+	ext := path.Ext(o.Path)
+	num, ok := strings.CutPrefix(p, strings.TrimSuffix(o.Path, ext)+".")
+	if !ok {
+		return false
+	}
+	num, ok = strings.CutSuffix(num, ext)
+	if !ok || num == "" {
+		return false
+	}
+	for _, r := range num {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CombinedPath returns the combined export path, or "" when none is configured.
+func (e *ExportConfig) CombinedPath() string {
+	if e == nil || e.Combined == nil {
+		return ""
+	}
+	return e.Combined.Path
+}
+
+// ManifestPath returns the export manifest path, or "" when none is configured.
+func (e *ExportConfig) ManifestPath() string {
+	if e == nil || e.Manifest == nil {
+		return ""
+	}
+	return e.Manifest.Path
+}
+
+// GitAttributesPath returns the export gitattributes path, or "" when none is
+// configured.
+func (e *ExportConfig) GitAttributesPath() string {
+	if e == nil || e.GitAttributes == nil {
+		return ""
+	}
+	return e.GitAttributes.Path
+}
+
+// IsEnabled returns true only if the CacheConfig is present and explicitly enabled.
+func (c *CacheConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
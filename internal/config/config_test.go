@@ -1,6 +1,7 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -199,6 +200,96 @@ func TestTidyIsEnabled(t *testing.T) {
 	}
 }
 
+func TestOutputSplitPaths(t *testing.T) {
+	o := &OutputDef{Path: "out/events.jsonl", Format: "jsonl"}
+	if got := o.PartPath(3); got != "out/events.3.jsonl" {
+		t.Errorf("PartPath(3) = %q", got)
+	}
+	if got := o.IndexPath(); got != "out/events.index.json" {
+		t.Errorf("IndexPath() = %q", got)
+	}
+	if o.IsSplitPath("out/events.0.jsonl") {
+		t.Error("paths should not be split paths without max_lines")
+	}
+
+	o.MaxLines = 100
+	for p, want := range map[string]bool{
+		"out/events.0.jsonl":    true,
+		"out/events.12.jsonl":   true,
+		"out/events.index.json": true,
+		"out/events.jsonl":      false,
+		"out/events.x.jsonl":    false,
+		"out/events..jsonl":     false,
+		"out/other.0.jsonl":     false,
+	} {
+		if got := o.IsSplitPath(p); got != want {
+			t.Errorf("IsSplitPath(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestMaxFileSizeFor(t *testing.T) {
+	path := writeTempConfig(t, `
+version: "1.0.0"
+max_file_size: 2MB
+types:
+  - name: small
+    input: json
+    max_file_size: 512
+    match:
+      include: ["^small/"]
+    schema: { type: object }
+  - name: large
+    input: json
+    match:
+      include: ["^large/"]
+    schema: { type: object }
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.MaxFileSizeFor(&cfg.Types[0]); got != 512 {
+		t.Errorf("type max_file_size = %d, want 512", got)
+	}
+	if got := cfg.MaxFileSizeFor(&cfg.Types[1]); got != 2<<20 {
+		t.Errorf("top-level max_file_size = %d, want %d", got, 2<<20)
+	}
+	if got := (&Config{}).MaxFileSizeFor(&cfg.Types[1]); got != DefaultMaxFileSize {
+		t.Errorf("unset max_file_size = %d, want DefaultMaxFileSize", got)
+	}
+}
+
+func TestLoad_ConfigSchemaRejectsInvalidMaxFileSize(t *testing.T) {
+	for _, size := range []string{`"1TB"`, `"12 MB"`, "0", `"0KB"`} {
+		path := writeTempConfig(t, "version: \"1.0.0\"\nmax_file_size: "+size+"\ntypes: []\n")
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "configuration does not match schema") {
+			t.Errorf("max_file_size %s: expected schema error, got: %v", size, err)
+		}
+	}
+}
+
+func TestInputFor(t *testing.T) {
+	auto := &TypeDef{Input: "auto"}
+	for p, want := range map[string]string{
+		"docs/a.json": "json",
+		"docs/a.JSON": "json",
+		"docs/a.yaml": "yaml",
+		"docs/a.yml":  "yaml",
+		"docs/a.csv":  "auto",
+		"<stdin>":     "auto",
+	} {
+		if got := auto.InputFor(p); got != want {
+			t.Errorf("InputFor(%q) = %q, want %q", p, got, want)
+		}
+	}
+
+	csv := &TypeDef{Input: "csv"}
+	if got := csv.InputFor("docs/a.json"); got != "csv" {
+		t.Errorf("non-auto InputFor = %q, want csv", got)
+	}
+}
+
 func TestLoadFileNotFound(t *testing.T) {
 	_, err := Load("/nonexistent/.datacur8")
 	if err == nil {
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// loadConfigData reads the config at path and, when it declares extends,
+// recursively loads the base config and merges this file on top of it. chain
+// holds the absolute paths of the configs that led here and is used to detect
+// extends cycles. The types of any types_include files are appended to the
+// file's own types before merging. The returned value has the JSON shape used
+// for schema validation, with extends and types_include removed.
+func loadConfigData(path string, chain []string) (any, error) {
+	abs := path
+	if !IsURL(path) {
+		var err error
+		abs, err = filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("resolving config path %q: %w", path, err)
+		}
+	}
+	for i, prev := range chain {
+		if prev == abs {
+			cycle := append(slices.Clone(chain[i:]), abs)
+			return nil, fmt.Errorf("config extends cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	raw, err := readConfigSource(path)
+	if err != nil {
+		if len(chain) > 0 {
+			return nil, fmt.Errorf("reading extended config %q: %w", path, err)
+		}
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	data, err := parseYAMLToJSONShape(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := data.(map[string]any)
+	if !ok {
+		return data, nil
+	}
+	if err := includeTypes(path, abs, m); err != nil {
+		return nil, err
+	}
+	ext, ok := m["extends"]
+	if !ok {
+		return data, nil
+	}
+	basePath, ok := ext.(string)
+	if !ok || basePath == "" {
+		return nil, fmt.Errorf("%s: extends must be a non-empty string path", path)
+	}
+	delete(m, "extends")
+	basePath = resolveConfigRef(abs, basePath)
+
+	base, err := loadConfigData(basePath, append(chain, abs))
+	if err != nil {
+		return nil, err
+	}
+	baseMap, ok := base.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("extended config %q must be a mapping", basePath)
+	}
+	return mergeConfigData(baseMap, m), nil
+}
+
+// mergeConfigData deep-merges overlay on top of base. Types are merged by
+// name; all other objects merge recursively and any other value in overlay
+// replaces the base value.
+func mergeConfigData(base, overlay map[string]any) map[string]any {
+	out := mergeMaps(base, overlay)
+	baseTypes, baseOK := base["types"].([]any)
+	overlayTypes, overlayOK := overlay["types"].([]any)
+	if baseOK && overlayOK {
+		out["types"] = mergeTypes(baseTypes, overlayTypes)
+	}
+	return out
+}
+
+// mergeTypes merges overlay type definitions into base by name. A type with a
+// name already defined in base is deep-merged onto it in place; other types
+// are appended in overlay order.
+func mergeTypes(base, overlay []any) []any {
+	out := append([]any(nil), base...)
+	index := make(map[string]int, len(base))
+	for i, t := range base {
+		if tm, ok := t.(map[string]any); ok {
+			if name, ok := tm["name"].(string); ok {
+				index[name] = i
+			}
+		}
+	}
+
+	for _, t := range overlay {
+		tm, ok := t.(map[string]any)
+		if !ok {
+			out = append(out, t)
+			continue
+		}
+		name, _ := tm["name"].(string)
+		if i, exists := index[name]; exists {
+			if bm, ok := out[i].(map[string]any); ok {
+				out[i] = mergeMaps(bm, tm)
+				continue
+			}
+		}
+		index[name] = len(out)
+		out = append(out, t)
+	}
+	return out
+}
+
+// mergeMaps returns a copy of base with overlay deep-merged on top.
+func mergeMaps(base, overlay map[string]any) map[string]any {
+	out := make(map[string]any, len(base)+len(overlay))
+	maps.Copy(out, base)
+	for k, v := range overlay {
+		bm, baseIsMap := out[k].(map[string]any)
+		om, overlayIsMap := v.(map[string]any)
+		if baseIsMap && overlayIsMap {
+			out[k] = mergeMaps(bm, om)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
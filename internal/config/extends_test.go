@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseConfig = `
+version: "0.0.0"
+strict_mode: ENABLED
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/.*\\.yaml$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+  - name: service
+    input: yaml
+    match:
+      include: ["^services/.*\\.yaml$"]
+    schema:
+      type: object
+`
+
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("creating config dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_ExtendsMergesTypesByName(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "shared/base.yaml", baseConfig)
+	path := writeConfigFile(t, dir, ".datacur8", `
+extends: shared/base.yaml
+types:
+  - name: service
+    match:
+      include: ["^svc/.*\\.yaml$"]
+    output:
+      path: out/services.json
+      format: json
+  - name: region
+    input: json
+    match:
+      include: ["^regions/.*\\.json$"]
+    schema:
+      type: object
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, td := range cfg.Types {
+		names = append(names, td.Name)
+	}
+	if strings.Join(names, ",") != "team,service,region" {
+		t.Fatalf("types = %v, want team,service,region", names)
+	}
+
+	svc := cfg.Types[1]
+	if svc.Input != "yaml" {
+		t.Errorf("service input = %q, want inherited yaml", svc.Input)
+	}
+	if len(svc.Match.Include) != 1 || svc.Match.Include[0] != `^svc/.*\.yaml$` {
+		t.Errorf("service match.include = %v, want overridden pattern", svc.Match.Include)
+	}
+	if svc.Output == nil || svc.Output.Path != "out/services.json" {
+		t.Errorf("service output = %+v, want added output", svc.Output)
+	}
+	if cfg.Types[0].Schema["properties"] == nil {
+		t.Error("team schema should be inherited unchanged")
+	}
+}
+
+func TestLoad_ExtendsOverridesScalar(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "base.yaml", baseConfig)
+	path := writeConfigFile(t, dir, ".datacur8", `
+extends: base.yaml
+version: "0.1.0"
+strict_mode: DISABLED
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != "0.1.0" {
+		t.Errorf("version = %q, want 0.1.0", cfg.Version)
+	}
+	if cfg.StrictMode != "DISABLED" {
+		t.Errorf("strict_mode = %q, want DISABLED", cfg.StrictMode)
+	}
+	if len(cfg.Types) != 2 {
+		t.Errorf("expected 2 inherited types, got %d", len(cfg.Types))
+	}
+}
+
+func TestLoad_ExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "a.yaml", "extends: b.yaml\nversion: \"0.0.0\"\ntypes: []\n")
+	writeConfigFile(t, dir, "b.yaml", "extends: a.yaml\n")
+	path := writeConfigFile(t, dir, ".datacur8", "extends: a.yaml\n")
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected extends cycle error")
+	}
+	if !strings.Contains(err.Error(), "config extends cycle") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_ExtendsMissingBase(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, dir, ".datacur8", "extends: missing.yaml\n")
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "reading extended config") {
+		t.Fatalf("expected missing base error, got: %v", err)
+	}
+}
+
+func TestLoad_ExtendsMergedResultIsSchemaValidated(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "base.yaml", baseConfig)
+	path := writeConfigFile(t, dir, ".datacur8", `
+extends: base.yaml
+unknown_field: true
+`)
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("expected schema validation error, got: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// compiledPattern is a cached regexp.Compile result.
+type compiledPattern struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// patternCache maps a match pattern to its compiledPattern so each distinct
+// pattern is compiled once, however many times the config is validated or
+// discovered against.
+var patternCache sync.Map
+
+// CompilePattern compiles a match.include or match.exclude pattern. Results,
+// including compile errors, are cached per pattern and the same
+// *regexp.Regexp is returned on every call.
+func CompilePattern(pat string) (*regexp.Regexp, error) {
+	if v, ok := patternCache.Load(pat); ok {
+		cp := v.(compiledPattern)
+		return cp.re, cp.err
+	}
+	re, err := regexp.Compile(pat)
+	v, _ := patternCache.LoadOrStore(pat, compiledPattern{re: re, err: err})
+	cp := v.(compiledPattern)
+	return cp.re, cp.err
+}
+
+// PatternError reports a match pattern that does not compile.
+type PatternError struct {
+	Field   string // "include" or "exclude"
+	Index   int
+	Pattern string
+	Err     error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("match.%s[%d] invalid regex: %v", e.Field, e.Index, e.Err)
+}
+
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// Compile returns the compiled include and exclude patterns of m. Invalid
+// patterns are left out of the result and reported as one *PatternError each.
+func (m MatchDef) Compile() (includes, excludes []*regexp.Regexp, errs []*PatternError) {
+	compile := func(field string, patterns []string) []*regexp.Regexp {
+		var out []*regexp.Regexp
+		for i, pat := range patterns {
+			re, err := CompilePattern(pat)
+			if err != nil {
+				errs = append(errs, &PatternError{Field: field, Index: i, Pattern: pat, Err: err})
+				continue
+			}
+			out = append(out, re)
+		}
+		return out
+	}
+	includes = compile("include", m.Include)
+	excludes = compile("exclude", m.Exclude)
+	return includes, excludes, errs
+}
+
+// maxPatternStrings bounds the number of distinct strings patternExtensions
+// expands a pattern's tail into before giving up.
+const maxPatternStrings = 64
+
+// patternExtensions returns the lowercase file extensions (without the dot)
+// of every path pat can match. It reports false when they cannot be
+// determined, which is the case unless each alternative of pat ends with $
+// preceded by a finite set of strings containing the extension's dot, as in
+// `\.json$` or `\.(json|ya?ml)$`.
+func patternExtensions(pat string) ([]string, bool) {
+	re, err := syntax.Parse(pat, syntax.Perl)
+	if err != nil {
+		return nil, false
+	}
+	return regexpExtensions(re.Simplify())
+}
+
+func regexpExtensions(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpCapture:
+		return regexpExtensions(re.Sub[0])
+	case syntax.OpAlternate:
+		var exts []string
+		for _, sub := range re.Sub {
+			e, ok := regexpExtensions(sub)
+			if !ok {
+				return nil, false
+			}
+			exts = append(exts, e...)
+		}
+		slices.Sort(exts)
+		return slices.Compact(exts), true
+	case syntax.OpConcat:
+	default:
+		return nil, false
+	}
+
+	n := len(re.Sub)
+	if n < 2 || re.Sub[n-1].Op != syntax.OpEndText {
+		return nil, false
+	}
+	suffixes := []string{""}
+	for i := n - 2; i >= 0; i-- {
+		strs, ok := finiteStrings(re.Sub[i])
+		if !ok {
+			return nil, false
+		}
+		var next []string
+		for _, p := range strs {
+			for _, s := range suffixes {
+				next = append(next, p+s)
+			}
+		}
+		if len(next) > maxPatternStrings {
+			return nil, false
+		}
+		suffixes = next
+		if !slices.ContainsFunc(suffixes, func(s string) bool { return !strings.Contains(s, ".") }) {
+			break
+		}
+	}
+
+	var exts []string
+	for _, s := range suffixes {
+		i := strings.LastIndex(s, ".")
+		if i < 0 || strings.Contains(s[i:], "/") {
+			return nil, false
+		}
+		exts = append(exts, strings.ToLower(s[i+1:]))
+	}
+	slices.Sort(exts)
+	return slices.Compact(exts), true
+}
+
+// finiteStrings expands re into every string it matches, reporting false when
+// that set is unbounded or larger than maxPatternStrings.
+func finiteStrings(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginText, syntax.OpBeginLine:
+		return []string{""}, true
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}, true
+	case syntax.OpCharClass:
+		var out []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(out) == maxPatternStrings {
+					return nil, false
+				}
+				out = append(out, string(r))
+			}
+		}
+		return out, true
+	case syntax.OpCapture:
+		return finiteStrings(re.Sub[0])
+	case syntax.OpQuest:
+		strs, ok := finiteStrings(re.Sub[0])
+		return append([]string{""}, strs...), ok
+	case syntax.OpAlternate:
+		var out []string
+		for _, sub := range re.Sub {
+			strs, ok := finiteStrings(sub)
+			if !ok || len(out)+len(strs) > maxPatternStrings {
+				return nil, false
+			}
+			out = append(out, strs...)
+		}
+		return out, true
+	case syntax.OpConcat:
+		out := []string{""}
+		for _, sub := range re.Sub {
+			strs, ok := finiteStrings(sub)
+			if !ok || len(out)*len(strs) > maxPatternStrings {
+				return nil, false
+			}
+			var next []string
+			for _, p := range out {
+				for _, s := range strs {
+					next = append(next, p+s)
+				}
+			}
+			out = next
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchCompile_Cached(t *testing.T) {
+	m := MatchDef{Include: []string{`^teams/(?P<team>[^/]+)\.yaml$`}, Exclude: []string{`draft`}}
+
+	inc1, exc1, errs := m.Compile()
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	inc2, exc2, _ := m.Compile()
+	if len(inc1) != 1 || len(exc1) != 1 {
+		t.Fatalf("expected 1 include and 1 exclude, got %d and %d", len(inc1), len(exc1))
+	}
+	if inc1[0] != inc2[0] || exc1[0] != exc2[0] {
+		t.Error("expected repeated Compile to return the cached regexps")
+	}
+
+	re, err := CompilePattern(m.Include[0])
+	if err != nil || re != inc1[0] {
+		t.Errorf("expected CompilePattern to share the cache, got %p (err %v), want %p", re, err, inc1[0])
+	}
+}
+
+func TestMatchCompile_InvalidPatterns(t *testing.T) {
+	m := MatchDef{Include: []string{`ok`, `[bad`}, Exclude: []string{`(unclosed`}}
+
+	for range 2 {
+		inc, exc, errs := m.Compile()
+		if len(inc) != 1 || len(exc) != 0 {
+			t.Fatalf("expected only the valid include, got %d includes and %d excludes", len(inc), len(exc))
+		}
+		if len(errs) != 2 {
+			t.Fatalf("expected one error per invalid pattern, got %v", errs)
+		}
+		if errs[0].Field != "include" || errs[0].Index != 1 || !strings.HasPrefix(errs[0].Error(), "match.include[1] invalid regex") {
+			t.Errorf("unexpected include error: %v", errs[0])
+		}
+		if errs[1].Field != "exclude" || errs[1].Index != 0 || !strings.HasPrefix(errs[1].Error(), "match.exclude[0] invalid regex") {
+			t.Errorf("unexpected exclude error: %v", errs[1])
+		}
+	}
+}
+
+func TestValidate_InvalidIncludeReportedOnce(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{`[bad`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "path_equals_attr", PathSelector: "path.team", References: &ReferenceDef{Key: "$.team"}},
+				},
+			},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	count := 0
+	for _, err := range errs {
+		if strings.Contains(err.Error(), "invalid regex") {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected the invalid include to be reported once, got %d in %v", count, errs)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// remoteTimeout bounds each fetch of a remote config.
+const remoteTimeout = 30 * time.Second
+
+// maxRemoteConfigSize is the largest remote config body that is read.
+const maxRemoteConfigSize = 16 << 20
+
+// remoteConfigs holds the body of every remote config fetched by this
+// process, keyed by URL, so a run fetches each URL at most once.
+var remoteConfigs sync.Map
+
+// IsURL reports whether path names a remote config: an http or https URL.
+func IsURL(path string) bool {
+	u, err := url.Parse(path)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// readConfigSource returns the contents of the config at path, fetching it
+// when path is a URL.
+func readConfigSource(path string) ([]byte, error) {
+	if IsURL(path) {
+		return fetchConfig(path)
+	}
+	return os.ReadFile(path)
+}
+
+// fetchConfig returns the body of the remote config at rawURL. Only a 200
+// response is accepted.
+func fetchConfig(rawURL string) ([]byte, error) {
+	if body, ok := remoteConfigs.Load(rawURL); ok {
+		return body.([]byte), nil
+	}
+
+	client := &http.Client{Timeout: remoteTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	if len(body) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("fetching %s: config exceeds %d bytes", rawURL, maxRemoteConfigSize)
+	}
+
+	remoteConfigs.Store(rawURL, body)
+	return body, nil
+}
+
+// resolveConfigRef resolves ref, an extends or types_include entry of the
+// config at base (an absolute path or a URL). URLs are used as they are; other
+// refs are relative to the directory of base, so a remote config refers to
+// files next to it on the same server.
+func resolveConfigRef(base, ref string) string {
+	if IsURL(ref) {
+		return ref
+	}
+	if IsURL(base) {
+		baseURL, _ := url.Parse(base)
+		refURL, err := url.Parse(filepath.ToSlash(ref))
+		if err != nil {
+			return ref
+		}
+		return baseURL.ResolveReference(refURL).String()
+	}
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(base), ref)
+}
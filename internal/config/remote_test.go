@@ -0,0 +1,121 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// serveConfigs starts a server returning files by URL path and counting the
+// requests for each.
+func serveConfigs(t *testing.T, files map[string]string) (*httptest.Server, map[string]*atomic.Int32) {
+	t.Helper()
+	hits := make(map[string]*atomic.Int32, len(files))
+	for p := range files {
+		hits[p] = &atomic.Int32{}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		hits[r.URL.Path].Add(1)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestLoad_RemoteConfig(t *testing.T) {
+	srv, hits := serveConfigs(t, map[string]string{
+		"/org/datacur8.yaml": `
+version: "0.0.0"
+types_include:
+  - types/teams.yaml
+types:
+  - name: region
+    input: yaml
+    match:
+      include: ["^regions/.*\\.yaml$"]
+    schema:
+      type: object
+`,
+		"/org/types/teams.yaml": `
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/.*\\.yaml$"]
+    schema:
+      type: object
+`,
+	})
+
+	url := srv.URL + "/org/datacur8.yaml"
+	for range 2 {
+		cfg, err := Load(url)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cfg.Types) != 2 || cfg.Types[0].Name != "region" || cfg.Types[1].Name != "team" {
+			t.Fatalf("unexpected types: %+v", cfg.Types)
+		}
+	}
+	for p, n := range hits {
+		if got := n.Load(); got != 1 {
+			t.Errorf("%s fetched %d times, expected once per run", p, got)
+		}
+	}
+}
+
+func TestLoad_RemoteConfigIsSchemaValidated(t *testing.T) {
+	srv, _ := serveConfigs(t, map[string]string{
+		"/.datacur8": `
+version: "0.0.0"
+unknown_key: true
+types: []
+`,
+	})
+
+	_, err := Load(srv.URL + "/.datacur8")
+	if err == nil || !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("expected schema validation error, got %v", err)
+	}
+}
+
+func TestLoad_RemoteConfigFetchErrors(t *testing.T) {
+	srv, _ := serveConfigs(t, map[string]string{})
+
+	_, err := Load(srv.URL + "/missing.yaml")
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 404 Not Found") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+
+	url := srv.URL + "/.datacur8"
+	srv.Close()
+	_, err = Load(url)
+	if err == nil || !strings.Contains(err.Error(), "reading config file: fetching "+url) {
+		t.Fatalf("expected network error, got %v", err)
+	}
+}
+
+func TestResolveConfigRef(t *testing.T) {
+	tests := []struct {
+		base, ref, want string
+	}{
+		{"https://example.com/org/.datacur8", "base.yaml", "https://example.com/org/base.yaml"},
+		{"https://example.com/org/.datacur8", "../shared/base.yaml", "https://example.com/shared/base.yaml"},
+		{"https://example.com/org/.datacur8", "https://other.example.com/base.yaml", "https://other.example.com/base.yaml"},
+		{"/repo/.datacur8", "https://example.com/base.yaml", "https://example.com/base.yaml"},
+		{"/repo/.datacur8", "types/teams.yaml", "/repo/types/teams.yaml"},
+		{"/repo/.datacur8", "/etc/base.yaml", "/etc/base.yaml"},
+	}
+	for _, tt := range tests {
+		if got := resolveConfigRef(tt.base, tt.ref); got != tt.want {
+			t.Errorf("resolveConfigRef(%q, %q) = %q, want %q", tt.base, tt.ref, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/google/jsonschema-go/jsonschema"
@@ -13,18 +15,20 @@ import (
 //go:embed config.schema.json
 var configSchemaJSON []byte
 
+// Schema returns the embedded JSON Schema for the .datacur8 configuration file.
+func Schema() []byte {
+	return configSchemaJSON
+}
+
 var (
 	configSchemaOnce sync.Once
 	configSchema     *jsonschema.Resolved
 	configSchemaErr  error
 )
 
-func validateAgainstEmbeddedSchema(rawYAML []byte) error {
-	cfgData, err := parseYAMLToJSONShape(rawYAML)
-	if err != nil {
-		return err
-	}
-
+// validateConfigData validates config data, already in JSON shape, against
+// the embedded config schema.
+func validateConfigData(cfgData any) error {
 	resolved, err := getConfigSchema()
 	if err != nil {
 		return err
@@ -36,6 +40,29 @@ func validateAgainstEmbeddedSchema(rawYAML []byte) error {
 	return nil
 }
 
+// dropUnknownTopLevelKeys deletes the keys of cfgData that are not properties
+// of the config schema and returns a warning naming each, in sorted order.
+func dropUnknownTopLevelKeys(cfgData any) ([]string, error) {
+	m, ok := cfgData.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(configSchemaJSON, &s); err != nil {
+		return nil, fmt.Errorf("decoding embedded config schema: %w", err)
+	}
+	var warnings []string
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if _, known := s.Properties[k]; !known {
+			delete(m, k)
+			warnings = append(warnings, fmt.Sprintf("unknown top-level key %q ignored", k))
+		}
+	}
+	return warnings, nil
+}
+
 func getConfigSchema() (*jsonschema.Resolved, error) {
 	configSchemaOnce.Do(func() {
 		var s jsonschema.Schema
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
 )
 
 func TestLoad_ConfigSchemaRejectsAdditionalTopLevelProperty(t *testing.T) {
@@ -24,6 +29,57 @@ extra_top_level: true
 	}
 }
 
+func TestLoadLenient_IgnoresUnknownTopLevelKeys(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/"]
+    schema:
+      type: object
+future_option: true
+another: {nested: 1}
+`
+
+	path := writeTempConfig(t, cfgText)
+	cfg, warnings, err := LoadLenient(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Types) != 1 || cfg.Types[0].Name != "team" {
+		t.Fatalf("unexpected types: %+v", cfg.Types)
+	}
+	want := []string{
+		`unknown top-level key "another" ignored`,
+		`unknown top-level key "future_option" ignored`,
+	}
+	if !slices.Equal(warnings, want) {
+		t.Fatalf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestLoadLenient_OtherSchemaErrorsStillFail(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/"]
+    schema:
+      type: object
+    unknown_type_key: true
+future_option: true
+`
+
+	path := writeTempConfig(t, cfgText)
+	if _, _, err := LoadLenient(path); err == nil || !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("expected schema validation error, got %v", err)
+	}
+}
+
 func TestLoad_ConfigSchemaRejectsMissingVersion(t *testing.T) {
 	cfgText := `
 types: []
@@ -102,6 +158,141 @@ types:
 	}
 }
 
+func TestLoad_ConfigSchemaRejectsNonStringDeprecated(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: records
+    input: json
+    deprecated: true
+    match:
+      include: ["^data/records\\.json$"]
+    schema:
+      type: object
+`
+
+	path := writeTempConfig(t, cfgText)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	if !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_ConfigSchemaRejectsNonBooleanPretty(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: records
+    input: json
+    match:
+      include: ["^data/records\\.json$"]
+    schema:
+      type: object
+    output:
+      path: out/records.json
+      format: json
+      pretty: "no"
+`
+
+	path := writeTempConfig(t, cfgText)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	if !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfgText = strings.Replace(cfgText, `pretty: "no"`, "pretty: false", 1)
+	cfg, err := Load(writeTempConfig(t, cfgText))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Types[0].Output.IsPretty() {
+		t.Error("expected pretty: false to disable pretty printing")
+	}
+}
+
+func TestLoad_ConfigSchemaTextTypeWithoutSchema(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: docs
+    input: text
+    match:
+      include: ["\\.md$"]
+`
+
+	path := writeTempConfig(t, cfgText)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if cfg.Types[0].Input != "text" || cfg.Types[0].Schema != nil {
+		t.Fatalf("unexpected type: %+v", cfg.Types[0])
+	}
+}
+
+func TestLoad_ConfigSchemaRejectsTextTypeWithSchema(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: docs
+    input: text
+    match:
+      include: ["\\.md$"]
+    schema:
+      type: object
+`
+
+	path := writeTempConfig(t, cfgText)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	if !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_ConfigSchemaRequiresSchemaForDataTypes(t *testing.T) {
+	cfgText := `
+version: "0.0.0"
+types:
+  - name: team
+    input: json
+    match:
+      include: ["\\.json$"]
+`
+
+	path := writeTempConfig(t, cfgText)
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected schema validation error")
+	}
+	if !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSchema_ReturnsEmbeddedConfigSchema(t *testing.T) {
+	out := Schema()
+	if !bytes.Equal(out, configSchemaJSON) {
+		t.Fatal("Schema() does not match the embedded config schema")
+	}
+
+	var s jsonschema.Schema
+	if err := json.Unmarshal(out, &s); err != nil {
+		t.Fatalf("decoding schema: %v", err)
+	}
+	if _, err := s.Resolve(nil); err != nil {
+		t.Fatalf("resolving schema: %v", err)
+	}
+}
+
 func writeTempConfig(t *testing.T, cfgText string) string {
 	t.Helper()
 
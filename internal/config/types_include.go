@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+)
+
+// includeTypes appends the types of every file listed in the types_include
+// key of m, the config at path, to m's own types and removes the key. Include
+// paths are relative to the directory of the config (abs is its absolute
+// path or URL). An include file may only hold a types list, and a type name may be
+// defined in just one of the files.
+func includeTypes(path, abs string, m map[string]any) error {
+	inc, ok := m["types_include"]
+	if !ok {
+		return nil
+	}
+	delete(m, "types_include")
+	list, ok := inc.([]any)
+	if !ok {
+		return fmt.Errorf("%s: types_include must be a list of file paths", path)
+	}
+
+	types, _ := m["types"].([]any)
+	owners := make(map[string]string)
+	addNames := func(file string, ts []any) error {
+		for _, t := range ts {
+			tm, _ := t.(map[string]any)
+			name, ok := tm["name"].(string)
+			if !ok {
+				continue
+			}
+			if prev, exists := owners[name]; exists {
+				return fmt.Errorf("type %q in %s is already defined in %s", name, file, prev)
+			}
+			owners[name] = file
+		}
+		return nil
+	}
+	if err := addNames(path, types); err != nil {
+		return err
+	}
+
+	for i, entry := range list {
+		incPath, ok := entry.(string)
+		if !ok || incPath == "" {
+			return fmt.Errorf("%s: types_include[%d] must be a non-empty string path", path, i)
+		}
+		incPath = resolveConfigRef(abs, incPath)
+		raw, err := readConfigSource(incPath)
+		if err != nil {
+			return fmt.Errorf("reading types_include file %q: %w", incPath, err)
+		}
+		data, err := parseYAMLToJSONShape(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", incPath, err)
+		}
+		fm, ok := data.(map[string]any)
+		if !ok {
+			return fmt.Errorf("types_include file %q must be a mapping with a types list", incPath)
+		}
+		for k := range fm {
+			if k != "types" {
+				return fmt.Errorf("types_include file %q: unexpected key %q; only types is allowed", incPath, k)
+			}
+		}
+		fileTypes, ok := fm["types"].([]any)
+		if !ok {
+			return fmt.Errorf("types_include file %q: types must be a list", incPath)
+		}
+		if err := addNames(incPath, fileTypes); err != nil {
+			return err
+		}
+		types = append(types, fileTypes...)
+	}
+	m["types"] = types
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoad_TypesIncludeAppendsTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "types/teams.yaml", `
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/.*\\.yaml$"]
+    schema:
+      type: object
+`)
+	writeConfigFile(t, dir, "types/services.yaml", `
+types:
+  - name: service
+    input: json
+    match:
+      include: ["^services/.*\\.json$"]
+    schema:
+      type: object
+`)
+	path := writeConfigFile(t, dir, ".datacur8", `
+version: "0.0.0"
+types_include:
+  - types/teams.yaml
+  - types/services.yaml
+types:
+  - name: region
+    input: yaml
+    match:
+      include: ["^regions/.*\\.yaml$"]
+    schema:
+      type: object
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, td := range cfg.Types {
+		names = append(names, td.Name)
+	}
+	if strings.Join(names, ",") != "region,team,service" {
+		t.Fatalf("types = %v, want region,team,service", names)
+	}
+	if cfg.Types[2].Input != "json" {
+		t.Errorf("service input = %q, want json", cfg.Types[2].Input)
+	}
+}
+
+func TestLoad_TypesIncludeDuplicateAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "a.yaml", `
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^a/.*\\.yaml$"]
+    schema:
+      type: object
+`)
+	writeConfigFile(t, dir, "b.yaml", `
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^b/.*\\.yaml$"]
+    schema:
+      type: object
+`)
+	path := writeConfigFile(t, dir, ".datacur8", `
+version: "0.0.0"
+types_include: [a.yaml, b.yaml]
+`)
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected duplicate type error")
+	}
+	if !strings.Contains(err.Error(), `type "team" in `) || !strings.Contains(err.Error(), "b.yaml is already defined in ") || !strings.Contains(err.Error(), "a.yaml") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_TypesIncludeOnlyAllowsTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "extra.yaml", "strict_mode: ENABLED\ntypes: []\n")
+	path := writeConfigFile(t, dir, ".datacur8", "version: \"0.0.0\"\ntypes_include: [extra.yaml]\ntypes: []\n")
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), `unexpected key "strict_mode"`) {
+		t.Fatalf("expected unexpected key error, got: %v", err)
+	}
+}
+
+func TestLoad_TypesIncludeResultIsSchemaValidated(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "extra.yaml", `
+types:
+  - name: team
+    input: yaml
+    match:
+      include: ["^teams/.*\\.yaml$"]
+    schema:
+      type: object
+    unknown_field: true
+`)
+	path := writeConfigFile(t, dir, ".datacur8", "version: \"0.0.0\"\ntypes_include: [extra.yaml]\n")
+
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "configuration does not match schema") {
+		t.Fatalf("expected schema validation error, got: %v", err)
+	}
+}
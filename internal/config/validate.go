@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
@@ -13,12 +16,21 @@ import (
 var (
 	semverRe       = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)$`)
 	typeNameRe     = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
-	pathSelectorRe = regexp.MustCompile(`^path\.(file|parent|ext|[a-zA-Z_][a-zA-Z0-9_]*)$`)
+	pathSelectorRe = regexp.MustCompile(`^path\.(file|parent|grandparent|dir|depth|ext|[a-zA-Z_][a-zA-Z0-9_]*)$`)
+	placeholderRe  = regexp.MustCompile(`\{(path\.[a-zA-Z_][a-zA-Z0-9_]*)\}`)
 )
 
+// SkipVersionCheck, passed to Validate as the CLI version, skips the
+// compatibility comparison while still requiring a well-formed config version.
+const SkipVersionCheck = "skip"
+
+// KnownFormats lists the value formats supported by the format constraint.
+var KnownFormats = []string{"email", "url", "uuid", "ipv4", "hostname"}
+
 // Validate checks cfg for structural and semantic errors.
 // cliVersion is the running binary version (e.g. "1.0.0"); pass "dev" or ""
-// to skip version comparison.
+// to skip version comparison with a warning, or SkipVersionCheck to skip it
+// silently.
 func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error) {
 	// 1. Version – must be valid semver
 	cfgParts := semverRe.FindStringSubmatch(cfg.Version)
@@ -28,9 +40,12 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 
 	// 2-3. Version comparison
 	if cfgParts != nil {
-		if cliVersion == "" || cliVersion == "dev" {
+		switch {
+		case cliVersion == SkipVersionCheck:
+			// requested explicitly, so there is nothing to warn about
+		case cliVersion == "" || cliVersion == "dev":
 			warnings = append(warnings, "CLI version is dev/empty; skipping config version compatibility check")
-		} else {
+		default:
 			cliParts := semverRe.FindStringSubmatch(cliVersion)
 			if cliParts == nil {
 				warnings = append(warnings, fmt.Sprintf("CLI version %q is not semver; skipping version comparison", cliVersion))
@@ -50,6 +65,9 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 	default:
 		errs = append(errs, fmt.Errorf("strict_mode %q is invalid; must be DISABLED, ENABLED, or FORCE", cfg.StrictMode))
 	}
+	if !validSchemaDialect(cfg.SchemaDialect) {
+		errs = append(errs, fmt.Errorf("schema_dialect %q is invalid; must be draft-07 or 2020-12", cfg.SchemaDialect))
+	}
 
 	// 5. types
 	typeNames := make(map[string]bool, len(cfg.Types))
@@ -71,28 +89,78 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 
 		// input format
 		switch t.Input {
-		case "json", "yaml", "csv":
+		case "json", "json5", "yaml", "csv", "text", "auto":
 		default:
-			errs = append(errs, fmt.Errorf("%s: input %q must be json, yaml, or csv", prefix, t.Input))
+			errs = append(errs, fmt.Errorf("%s: input %q must be json, json5, yaml, csv, text, or auto", prefix, t.Input))
+		}
+
+		if t.Coerce && t.Input != "json" && t.Input != "json5" && t.Input != "yaml" && t.Input != "auto" {
+			errs = append(errs, fmt.Errorf("%s: coerce is only supported for json, json5, and yaml input", prefix))
+		}
+
+		if !validSchemaDialect(t.SchemaDialect) {
+			errs = append(errs, fmt.Errorf("%s: schema_dialect %q is invalid; must be draft-07 or 2020-12", prefix, t.SchemaDialect))
+		}
+		if _, ok := encodings[t.Encoding]; t.Encoding != "" && !ok {
+			errs = append(errs, fmt.Errorf("%s: encoding %q must be utf-8, latin1, windows-1252, utf-16, utf-16le, or utf-16be", prefix, t.Encoding))
+		}
+
+		if len(t.Split) > 0 {
+			if t.Input != "csv" {
+				errs = append(errs, fmt.Errorf("%s: split is only supported for csv input", prefix))
+			} else {
+				errs = append(errs, validateSplit(prefix, t.Schema, t.Split)...)
+			}
+		}
+		if len(t.Fold) > 0 {
+			if t.Input != "csv" {
+				errs = append(errs, fmt.Errorf("%s: fold is only supported for csv input", prefix))
+			} else {
+				errs = append(errs, validateFold(prefix, t.Schema, t.Fold, t.Split)...)
+			}
+		}
+		if t.StrictRows && t.Input != "csv" {
+			errs = append(errs, fmt.Errorf("%s: strict_rows is only supported for csv input", prefix))
 		}
 
 		// match.include
 		if len(t.Match.Include) == 0 {
 			errs = append(errs, fmt.Errorf("%s: match.include must have at least 1 pattern", prefix))
 		}
-		for j, pat := range t.Match.Include {
-			if _, err := regexp.Compile(pat); err != nil {
-				errs = append(errs, fmt.Errorf("%s: match.include[%d] invalid regex: %v", prefix, j, err))
-			}
+		_, _, patErrs := t.Match.Compile()
+		for _, err := range patErrs {
+			errs = append(errs, fmt.Errorf("%s: %w", prefix, err))
 		}
-		for j, pat := range t.Match.Exclude {
-			if _, err := regexp.Compile(pat); err != nil {
-				errs = append(errs, fmt.Errorf("%s: match.exclude[%d] invalid regex: %v", prefix, j, err))
+		if t.Input == "auto" {
+			for i, pat := range t.Match.Include {
+				if _, err := CompilePattern(pat); err != nil {
+					continue // reported above
+				}
+				exts, ok := patternExtensions(pat)
+				if !ok || slices.ContainsFunc(exts, func(ext string) bool { return ext != "json" && ext != "json5" && ext != "yaml" && ext != "yml" }) {
+					errs = append(errs, fmt.Errorf("%s: match.include[%d] %q must only match .json, .json5, .yaml, or .yml files for input auto (end the pattern with an extension such as \\.(json|ya?ml)$)", prefix, i, pat))
+				}
 			}
 		}
+		switch t.Match.Against {
+		case "", "path", "basename":
+		default:
+			errs = append(errs, fmt.Errorf("%s: match.against %q must be path or basename", prefix, t.Match.Against))
+		}
 
 		// schema
-		if t.Schema == nil {
+		if t.Input == "text" {
+			// text files are only tidied; they have no items to validate
+			if t.Schema != nil {
+				errs = append(errs, fmt.Errorf("%s: schema is not supported for text input", prefix))
+			}
+			if len(t.Constraints) > 0 {
+				errs = append(errs, fmt.Errorf("%s: constraints are not supported for text input", prefix))
+			}
+			if t.Output != nil {
+				errs = append(errs, fmt.Errorf("%s: output is not supported for text input", prefix))
+			}
+		} else if t.Schema == nil {
 			errs = append(errs, fmt.Errorf("%s: schema is required", prefix))
 		} else if st, ok := t.Schema["type"]; !ok || st != "object" {
 			errs = append(errs, fmt.Errorf("%s: schema.type must be \"object\"", prefix))
@@ -105,6 +173,26 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 			default:
 				errs = append(errs, fmt.Errorf("%s: output.format %q must be json, yaml, or jsonl", prefix, t.Output.Format))
 			}
+			switch t.Output.YAMLStyle {
+			case "":
+			case "block", "flow":
+				if t.Output.Format != "yaml" {
+					errs = append(errs, fmt.Errorf("%s: output.yaml_style requires output.format yaml", prefix))
+				}
+			default:
+				errs = append(errs, fmt.Errorf("%s: output.yaml_style %q must be block or flow", prefix, t.Output.YAMLStyle))
+			}
+			if t.Output.Pretty != nil && t.Output.Format != "json" {
+				errs = append(errs, fmt.Errorf("%s: output.pretty requires output.format json", prefix))
+			}
+			if t.Output.Banner != "" && t.Output.Format == "jsonl" {
+				errs = append(errs, fmt.Errorf("%s: output.banner is not supported for jsonl output (use json or yaml)", prefix))
+			}
+			if t.Output.MaxLines < 0 {
+				errs = append(errs, fmt.Errorf("%s: output.max_lines must be positive", prefix))
+			} else if t.Output.MaxLines > 0 && t.Output.Format != "jsonl" {
+				errs = append(errs, fmt.Errorf("%s: output.max_lines requires output.format jsonl", prefix))
+			}
 			if prev, exists := outputPaths[t.Output.Path]; exists {
 				errs = append(errs, fmt.Errorf("%s: output.path %q conflicts with type %q", prefix, t.Output.Path, prev))
 			}
@@ -114,6 +202,11 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 		// constraints
 		for ci, con := range t.Constraints {
 			cprefix := fmt.Sprintf("%s.constraints[%d]", prefix, ci)
+			switch con.Severity {
+			case "", "error", "warning":
+			default:
+				errs = append(errs, fmt.Errorf("%s: severity %q must be error or warning", cprefix, con.Severity))
+			}
 			switch con.Type {
 			case "unique":
 				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
@@ -133,7 +226,71 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 					} else if !typeNames[con.References.Type] {
 						// referenced type might be defined later; collect for deferred check
 					}
+					if con.References.PathSelector != "" {
+						if con.References.Key != "" {
+							errs = append(errs, fmt.Errorf("%s: references.key and references.path_selector are mutually exclusive", cprefix))
+						}
+						if !pathSelectorRe.MatchString(con.References.PathSelector) {
+							errs = append(errs, fmt.Errorf("%s: references.path_selector %q is invalid", cprefix, con.References.PathSelector))
+						}
+					} else {
+						errs = append(errs, validateSelector(cprefix, "references.key", con.References.Key)...)
+					}
+				}
+
+			case "contains":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if sel, err := selector.Parse(con.Key); err == nil && sel.IsScalar() {
+					errs = append(errs, fmt.Errorf("%s: key %q must be a multi-value selector (use [*]) for contains", cprefix, con.Key))
+				}
+				if len(con.RequiredValues()) == 0 {
+					errs = append(errs, fmt.Errorf("%s: value or values is required for contains", cprefix))
+				}
+
+			case "forbidden":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if len(con.Values) == 0 {
+					errs = append(errs, fmt.Errorf("%s: values is required for forbidden", cprefix))
+				}
+
+			case "ordered":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if sel, err := selector.Parse(con.Key); err == nil && sel.IsScalar() {
+					errs = append(errs, fmt.Errorf("%s: key %q must be a multi-value selector (use [*]) for ordered", cprefix, con.Key))
+				}
+				if con.By != "" {
+					errs = append(errs, validateSelector(cprefix, "by", con.By)...)
+					if sel, err := selector.Parse(con.By); err == nil && !sel.IsScalar() {
+						errs = append(errs, fmt.Errorf("%s: by %q must be a scalar selector (no [*])", cprefix, con.By))
+					}
+					if pathKeyCapture(con.By) != "" {
+						errs = append(errs, fmt.Errorf("%s: by %q must select from each element, not a path capture", cprefix, con.By))
+					}
+				}
+
+			case "mutually_exclusive":
+				if len(con.Keys) < 2 {
+					errs = append(errs, fmt.Errorf("%s: keys must list at least two selectors for mutually_exclusive", cprefix))
+				}
+				for ki, key := range con.Keys {
+					errs = append(errs, validateSelector(cprefix, fmt.Sprintf("keys[%d]", ki), key)...)
+				}
+
+			case "format":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if !slices.Contains(KnownFormats, con.Format) {
+					errs = append(errs, fmt.Errorf("%s: format %q must be one of %s", cprefix, con.Format, strings.Join(KnownFormats, ", ")))
+				}
+
+			case "internal_reference":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if con.References == nil {
+					errs = append(errs, fmt.Errorf("%s: references is required for internal_reference", cprefix))
+				} else {
 					errs = append(errs, validateSelector(cprefix, "references.key", con.References.Key)...)
+					if con.References.Type != "" || con.References.PathSelector != "" {
+						errs = append(errs, fmt.Errorf("%s: internal_reference references only support key", cprefix))
+					}
 				}
 
 			case "path_equals_attr":
@@ -145,13 +302,18 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 				} else {
 					errs = append(errs, validateSelector(cprefix, "references.key", con.References.Key)...)
 				}
+				switch con.Compare {
+				case "", "string", "numeric":
+				default:
+					errs = append(errs, fmt.Errorf("%s: compare %q must be string or numeric", cprefix, con.Compare))
+				}
 
 				// capture group validation
 				captureName := extractCaptureName(con.PathSelector)
 				if captureName != "" {
 					groupName := captureName
 					for pi, pat := range t.Match.Include {
-						re, err := regexp.Compile(pat)
+						re, err := CompilePattern(pat)
 						if err != nil {
 							continue // already reported
 						}
@@ -163,27 +325,413 @@ func Validate(cfg *Config, cliVersion string) (warnings []string, errs []error)
 					}
 				}
 
+			case "path_template_equals_attr":
+				selectors := TemplateSelectors(con.Template)
+				if len(selectors) == 0 {
+					errs = append(errs, fmt.Errorf("%s: template %q must contain at least one {path.<name>} placeholder", cprefix, con.Template))
+				}
+				if con.References == nil {
+					errs = append(errs, fmt.Errorf("%s: references is required for path_template_equals_attr", cprefix))
+				} else {
+					errs = append(errs, validateSelector(cprefix, "references.key", con.References.Key)...)
+				}
+				for _, ps := range selectors {
+					captureName := extractCaptureName(ps)
+					if captureName == "" {
+						continue
+					}
+					for pi, pat := range t.Match.Include {
+						re, err := CompilePattern(pat)
+						if err != nil {
+							continue // already reported
+						}
+						if !hasNamedGroup(re, captureName) {
+							errs = append(errs, fmt.Errorf(
+								"%s: template uses capture %q but match.include[%d] does not define named group (?P<%s>...)",
+								cprefix, captureName, pi, captureName))
+						}
+					}
+				}
+
+			case "count_equals":
+				if con.References == nil || con.References.Type == "" {
+					errs = append(errs, fmt.Errorf("%s: references.type is required for count_equals", cprefix))
+				} else if con.References.Key != "" || con.References.PathSelector != "" {
+					errs = append(errs, fmt.Errorf("%s: count_equals references only support type", cprefix))
+				}
+				if con.Delta < 0 {
+					errs = append(errs, fmt.Errorf("%s: delta must not be negative", cprefix))
+				}
+
+			case "file_exists":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if con.BaseDir != "" && (path.IsAbs(con.BaseDir) || !filepath.IsLocal(filepath.FromSlash(con.BaseDir))) {
+					errs = append(errs, fmt.Errorf("%s: base_dir %q must be a relative path inside the repository", cprefix, con.BaseDir))
+				}
+
+			case "sequence":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if sel, err := selector.Parse(con.Key); err == nil && !sel.IsScalar() {
+					errs = append(errs, fmt.Errorf("%s: key %q must be a scalar selector (no [*]) for sequence", cprefix, con.Key))
+				}
+				if con.Step < 0 {
+					errs = append(errs, fmt.Errorf("%s: step must be positive", cprefix))
+				}
+
+			case "all_equal":
+				errs = append(errs, validateSelector(cprefix, "key", con.Key)...)
+				if sel, err := selector.Parse(con.Key); err == nil && !sel.IsScalar() {
+					errs = append(errs, fmt.Errorf("%s: key %q must be a scalar selector (no [*]) for all_equal", cprefix, con.Key))
+				}
+
+			case "compare":
+				for _, side := range []struct{ field, value string }{{"left", con.Left}, {"right", con.Right}} {
+					errs = append(errs, validateSelector(cprefix, side.field, side.value)...)
+					if sel, err := selector.Parse(side.value); err == nil && !sel.IsScalar() {
+						errs = append(errs, fmt.Errorf("%s: %s %q must be a scalar selector (no [*]) for compare", cprefix, side.field, side.value))
+					}
+				}
+				switch con.Operator {
+				case "lt", "le", "gt", "ge", "eq", "ne":
+				default:
+					errs = append(errs, fmt.Errorf("%s: operator %q must be lt, le, gt, ge, eq, or ne", cprefix, con.Operator))
+				}
+
 			default:
 				errs = append(errs, fmt.Errorf("%s: unknown constraint type %q", cprefix, con.Type))
 			}
+
+			if con.References != nil && con.References.Unique && con.Type != "foreign_key" {
+				errs = append(errs, fmt.Errorf("%s: references.unique is only supported for foreign_key", cprefix))
+			}
+			if con.References != nil && con.References.Optional && con.Type != "foreign_key" {
+				errs = append(errs, fmt.Errorf("%s: references.optional is only supported for foreign_key", cprefix))
+			}
+			if con.References != nil && con.References.Suggest && con.Type != "foreign_key" {
+				errs = append(errs, fmt.Errorf("%s: references.suggest is only supported for foreign_key", cprefix))
+			}
+
+			// $path.<name> selectors read captures that this type's patterns must define
+			errs = append(errs, validatePathKey(cprefix, "key", con.Key, t)...)
+			for ki, key := range con.Keys {
+				errs = append(errs, validatePathKey(cprefix, fmt.Sprintf("keys[%d]", ki), key, t)...)
+			}
+			errs = append(errs, validatePathKey(cprefix, "left", con.Left, t)...)
+			errs = append(errs, validatePathKey(cprefix, "right", con.Right, t)...)
+			if con.References != nil && (con.Type == "internal_reference" || con.Type == "path_equals_attr" || con.Type == "path_template_equals_attr") {
+				errs = append(errs, validatePathKey(cprefix, "references.key", con.References.Key, t)...)
+			}
 		}
 	}
 
-	// deferred check: foreign_key references must point to known type names
+	// deferred check: foreign_key and count_equals references must point to known type names
 	for i, t := range cfg.Types {
 		prefix := fmt.Sprintf("types[%d](%s)", i, t.Name)
 		for ci, con := range t.Constraints {
-			if con.Type == "foreign_key" && con.References != nil && con.References.Type != "" {
+			if (con.Type == "foreign_key" || con.Type == "count_equals") && con.References != nil && con.References.Type != "" {
 				if !typeNames[con.References.Type] {
 					errs = append(errs, fmt.Errorf("%s.constraints[%d]: references.type %q does not match any defined type", prefix, ci, con.References.Type))
+					continue
+				}
+				for _, rt := range cfg.Types {
+					if rt.Name == con.References.Type {
+						errs = append(errs, validatePathKey(fmt.Sprintf("%s.constraints[%d]", prefix, ci), "references.key", con.References.Key, rt)...)
+					}
+				}
+				// a path capture must be defined by every include pattern of the referenced type
+				captureName := extractCaptureName(con.References.PathSelector)
+				if captureName == "" {
+					continue
+				}
+				for _, rt := range cfg.Types {
+					if rt.Name != con.References.Type {
+						continue
+					}
+					for pi, pat := range rt.Match.Include {
+						re, err := CompilePattern(pat)
+						if err != nil {
+							continue // already reported
+						}
+						if !hasNamedGroup(re, captureName) {
+							errs = append(errs, fmt.Errorf(
+								"%s.constraints[%d]: references.path_selector uses capture %q but %s match.include[%d] does not define named group (?P<%s>...)",
+								prefix, ci, captureName, rt.Name, pi, captureName))
+						}
+					}
 				}
 			}
 		}
 	}
 
+	// combined export must not overwrite a per-type output
+	if combined := cfg.Export.CombinedPath(); combined != "" {
+		if prev, exists := outputPaths[combined]; exists {
+			errs = append(errs, fmt.Errorf("export.combined.path %q conflicts with output.path of type %q", combined, prev))
+		}
+	}
+
+	// the manifest must not overwrite any exported file
+	if manifest := cfg.Export.ManifestPath(); manifest != "" {
+		if prev, exists := outputPaths[manifest]; exists {
+			errs = append(errs, fmt.Errorf("export.manifest.path %q conflicts with output.path of type %q", manifest, prev))
+		}
+		if manifest == cfg.Export.CombinedPath() {
+			errs = append(errs, fmt.Errorf("export.manifest.path %q conflicts with export.combined.path", manifest))
+		}
+		for _, t := range cfg.Types {
+			if t.Output != nil && t.Output.IsSplitPath(manifest) {
+				errs = append(errs, fmt.Errorf("export.manifest.path %q conflicts with the split output of type %q", manifest, t.Name))
+			}
+		}
+	}
+
+	// nor must the gitattributes file
+	if attrs := cfg.Export.GitAttributesPath(); attrs != "" {
+		if prev, exists := outputPaths[attrs]; exists {
+			errs = append(errs, fmt.Errorf("export.gitattributes.path %q conflicts with output.path of type %q", attrs, prev))
+		}
+		if attrs == cfg.Export.CombinedPath() {
+			errs = append(errs, fmt.Errorf("export.gitattributes.path %q conflicts with export.combined.path", attrs))
+		}
+		if attrs == cfg.Export.ManifestPath() {
+			errs = append(errs, fmt.Errorf("export.gitattributes.path %q conflicts with export.manifest.path", attrs))
+		}
+		for _, t := range cfg.Types {
+			if t.Output != nil && t.Output.IsSplitPath(attrs) {
+				errs = append(errs, fmt.Errorf("export.gitattributes.path %q conflicts with the split output of type %q", attrs, t.Name))
+			}
+		}
+	}
+
+	warnings = append(warnings, Lint(cfg)...)
+
 	return warnings, errs
 }
 
+// Lint reports config smells that do not make the config invalid: patterns
+// that would select exported files, named capture groups that no
+// path_selector uses, and types that neither check constraints nor export.
+// Validate includes these in its warnings; validate --strict-config reports
+// them as errors instead.
+func Lint(cfg *Config) (warnings []string) {
+	// exported files should not look like inputs of any type
+	exported := make(map[string]string) // output path -> description
+	for _, ot := range cfg.Types {
+		if ot.Output != nil && ot.Output.Path != "" {
+			exported[ot.Output.Path] = fmt.Sprintf("output.path %q of type %q", ot.Output.Path, ot.Name)
+		}
+	}
+	if combined := cfg.Export.CombinedPath(); combined != "" {
+		exported[combined] = fmt.Sprintf("export.combined.path %q", combined)
+	}
+	if manifest := cfg.Export.ManifestPath(); manifest != "" {
+		exported[manifest] = fmt.Sprintf("export.manifest.path %q", manifest)
+	}
+	if attrs := cfg.Export.GitAttributesPath(); attrs != "" {
+		exported[attrs] = fmt.Sprintf("export.gitattributes.path %q", attrs)
+	}
+	for _, outPath := range slices.Sorted(maps.Keys(exported)) {
+		if filepath.IsAbs(outPath) {
+			continue
+		}
+		for i, t := range cfg.Types {
+			if matchesPath(t.Match, path.Clean(filepath.ToSlash(outPath))) {
+				warnings = append(warnings, fmt.Sprintf(
+					"types[%d](%s): match.include matches %s; exported files are skipped during discovery and should not be re-ingested",
+					i, t.Name, exported[outPath]))
+			}
+		}
+	}
+
+	// named capture groups must be consumed by a path_selector
+	usedCaptures := make(map[string]map[string]bool) // type name -> capture names
+	use := func(typeName, ps string) {
+		if name := extractCaptureName(ps); name != "" {
+			if usedCaptures[typeName] == nil {
+				usedCaptures[typeName] = make(map[string]bool)
+			}
+			usedCaptures[typeName][name] = true
+		}
+	}
+	for _, t := range cfg.Types {
+		for _, con := range t.Constraints {
+			switch {
+			case con.Type == "path_equals_attr":
+				use(t.Name, con.PathSelector)
+			case con.Type == "path_template_equals_attr":
+				for _, ps := range TemplateSelectors(con.Template) {
+					use(t.Name, ps)
+				}
+			case con.Type == "foreign_key" && con.References != nil:
+				use(con.References.Type, con.References.PathSelector)
+				use(con.References.Type, pathKeyCapture(con.References.Key))
+			case con.Type == "internal_reference" && con.References != nil:
+				use(t.Name, pathKeyCapture(con.References.Key))
+			}
+			use(t.Name, pathKeyCapture(con.Key))
+			for _, key := range con.Keys {
+				use(t.Name, pathKeyCapture(key))
+			}
+			use(t.Name, pathKeyCapture(con.Left))
+			use(t.Name, pathKeyCapture(con.Right))
+		}
+	}
+	for i, t := range cfg.Types {
+		for pi, pat := range t.Match.Include {
+			re, err := CompilePattern(pat)
+			if err != nil {
+				continue // reported by Validate
+			}
+			for _, name := range re.SubexpNames() {
+				if name != "" && !usedCaptures[t.Name][name] {
+					warnings = append(warnings, fmt.Sprintf(
+						"types[%d](%s): match.include[%d] named group %q is not used by any path_selector",
+						i, t.Name, pi, name))
+				}
+			}
+		}
+	}
+
+	// foreign_key fields should be declared by the schemas they are read from
+	typeIndex := make(map[string]int)
+	for i, t := range cfg.Types {
+		typeIndex[t.Name] = i
+	}
+	for i, t := range cfg.Types {
+		for ci, con := range t.Constraints {
+			if con.Type != "foreign_key" {
+				continue
+			}
+			prefix := fmt.Sprintf("types[%d](%s).constraints[%d]", i, t.Name, ci)
+			if field, ok := undeclaredRootField(t.Schema, con.Key); ok {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: key %q reads field %q, which is not in the schema properties",
+					prefix, con.Key, field))
+			}
+			if con.References == nil || con.References.Key == "" {
+				continue
+			}
+			ri, exists := typeIndex[con.References.Type]
+			if !exists {
+				continue // reported by Validate
+			}
+			if field, ok := undeclaredRootField(cfg.Types[ri].Schema, con.References.Key); ok {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: references.key %q reads field %q, which is not in the schema properties of type %q",
+					prefix, con.References.Key, field, con.References.Type))
+			}
+		}
+	}
+
+	// a type should check something beyond its schema or feed an export
+	for i, t := range cfg.Types {
+		if t.Input != "text" && len(t.Constraints) == 0 && t.Output == nil {
+			warnings = append(warnings, fmt.Sprintf(
+				"types[%d](%s): has no constraints and no output; its files are only checked against the schema",
+				i, t.Name))
+		}
+	}
+
+	return warnings
+}
+
+// matchesPath reports whether relPath matches at least one include pattern and
+// no exclude pattern of m. Invalid patterns are ignored; they are reported
+// separately.
+func matchesPath(m MatchDef, relPath string) bool {
+	subject := relPath
+	if m.Against == "basename" {
+		subject = path.Base(relPath)
+	}
+	includes, excludes, _ := m.Compile()
+	matchPattern := func(re *regexp.Regexp) bool {
+		return re.MatchString(subject)
+	}
+	return slices.ContainsFunc(includes, matchPattern) && !slices.ContainsFunc(excludes, matchPattern)
+}
+
+// validateSplit checks that every property named in split is an array
+// property of schema and has a non-empty separator.
+func validateSplit(prefix string, schema map[string]any, split map[string]string) []error {
+	var errs []error
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(split)) {
+		if split[name] == "" {
+			errs = append(errs, fmt.Errorf("%s: split separator for %q must not be empty", prefix, name))
+		}
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: split property %q not found in schema properties", prefix, name))
+			continue
+		}
+		if !schemaTypeIncludes(prop, "array") {
+			errs = append(errs, fmt.Errorf("%s: split property %q must have schema type array", prefix, name))
+		}
+	}
+	return errs
+}
+
+// validateFold checks that every property named in fold is an array property
+// of schema whose items are objects, has a non-empty column prefix, and is not
+// also split.
+func validateFold(prefix string, schema map[string]any, fold, split map[string]string) []error {
+	var errs []error
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(fold)) {
+		if fold[name] == "" {
+			errs = append(errs, fmt.Errorf("%s: fold prefix for %q must not be empty", prefix, name))
+		}
+		if _, ok := split[name]; ok {
+			errs = append(errs, fmt.Errorf("%s: fold property %q is also listed in split", prefix, name))
+		}
+		prop, ok := props[name].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: fold property %q not found in schema properties", prefix, name))
+			continue
+		}
+		if !schemaTypeIncludes(prop, "array") {
+			errs = append(errs, fmt.Errorf("%s: fold property %q must have schema type array", prefix, name))
+			continue
+		}
+		items, ok := prop["items"].(map[string]any)
+		if !ok || !schemaTypeIncludes(items, "object") {
+			errs = append(errs, fmt.Errorf("%s: fold property %q must have schema items of type object", prefix, name))
+		}
+	}
+	return errs
+}
+
+// schemaTypeIncludes reports whether the "type" of schema is want or a union
+// containing it.
+func schemaTypeIncludes(schema map[string]any, want string) bool {
+	if union, ok := schema["type"].([]any); ok {
+		return slices.Contains(union, any(want))
+	}
+	return schema["type"] == want
+}
+
+// undeclaredRootField returns the top-level field read by sel when schema
+// lists properties and that field is not among them. Schemas without
+// properties are permissive and never flag a field.
+func undeclaredRootField(schema map[string]any, sel string) (string, bool) {
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	s, err := selector.Parse(sel)
+	if err != nil {
+		return "", false // reported by Validate
+	}
+	field, ok := s.RootField()
+	if !ok {
+		return "", false
+	}
+	if _, declared := props[field]; declared {
+		return "", false
+	}
+	return field, true
+}
+
 func validateSelector(prefix, field, value string) []error {
 	if value == "" {
 		return []error{fmt.Errorf("%s: %s is required", prefix, field)}
@@ -210,15 +758,71 @@ func compareSemver(a, b []string) int {
 	return 0
 }
 
+// pathKeyCapture returns the capture key, such as "path.region", read by a
+// $path.region selector, or "" for any other selector.
+func pathKeyCapture(sel string) string {
+	s, err := selector.Parse(sel)
+	if err != nil {
+		return ""
+	}
+	key, _ := s.PathCapture()
+	return key
+}
+
+// validatePathKey checks that every match.include pattern of t, the type whose
+// items a $path.<name> selector is read from, defines the named group <name>.
+// Built-in captures and data selectors always pass.
+func validatePathKey(prefix, field, value string, t TypeDef) []error {
+	name := extractCaptureName(pathKeyCapture(value))
+	if name == "" {
+		return nil
+	}
+	var errs []error
+	for pi, pat := range t.Match.Include {
+		re, err := CompilePattern(pat)
+		if err != nil {
+			continue // already reported
+		}
+		if !hasNamedGroup(re, name) {
+			errs = append(errs, fmt.Errorf(
+				"%s: %s %q uses capture %q but %s match.include[%d] does not define named group (?P<%s>...)",
+				prefix, field, value, name, t.Name, pi, name))
+		}
+	}
+	return errs
+}
+
+// TemplateSelectors returns the path selectors named by the {path.<name>}
+// placeholders of a path_template_equals_attr template, in order of
+// appearance. A selector used more than once is listed once.
+func TemplateSelectors(template string) []string {
+	var selectors []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(template, -1) {
+		if !slices.Contains(selectors, m[1]) {
+			selectors = append(selectors, m[1])
+		}
+	}
+	return selectors
+}
+
+// validSchemaDialect reports whether d is a supported schema_dialect, or unset.
+func validSchemaDialect(d string) bool {
+	switch d {
+	case "", "draft-07", "2020-12":
+		return true
+	}
+	return false
+}
+
 // extractCaptureName returns the capture name from a path_selector like "path.<name>"
-// where name is not one of the built-in segments (file, parent, ext).
+// where name is not one of the built-in segments (file, parent, grandparent, dir, depth, ext).
 func extractCaptureName(ps string) string {
 	if !strings.HasPrefix(ps, "path.") {
 		return ""
 	}
 	name := ps[5:]
 	switch name {
-	case "file", "parent", "ext":
+	case "file", "parent", "grandparent", "dir", "depth", "ext":
 		return ""
 	}
 	return name
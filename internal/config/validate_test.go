@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -17,6 +19,7 @@ func TestValidate_ValidConfig(t *testing.T) {
 				Schema: map[string]any{
 					"type": "object",
 				},
+				Constraints: []ConstraintDef{{Type: "unique", Key: "$.id"}},
 			},
 		},
 	}
@@ -65,6 +68,18 @@ func TestValidate_EmptyCLISkipsVersionCheck(t *testing.T) {
 	requireWarning(t, warnings, "dev/empty")
 }
 
+func TestValidate_SkipVersionCheck(t *testing.T) {
+	cfg := &Config{Version: "1.0.0", Types: []TypeDef{}}
+	warnings, errs := Validate(cfg, SkipVersionCheck)
+	if len(errs) != 0 || len(warnings) != 0 {
+		t.Fatalf("expected no errors or warnings, got %v %v", errs, warnings)
+	}
+
+	cfg.Version = "1.0"
+	_, errs = Validate(cfg, SkipVersionCheck)
+	requireError(t, errs, "is not valid semver")
+}
+
 func TestValidate_StrictModeInvalid(t *testing.T) {
 	cfg := &Config{Version: "1.0.0", StrictMode: "INVALID", Types: []TypeDef{}}
 	_, errs := Validate(cfg, "dev")
@@ -114,7 +129,7 @@ func TestValidate_InputInvalid(t *testing.T) {
 		},
 	}
 	_, errs := Validate(cfg, "dev")
-	requireError(t, errs, "must be json, yaml, or csv")
+	requireError(t, errs, "must be json, json5, yaml, csv, text, or auto")
 }
 
 func TestValidate_EmptyInclude(t *testing.T) {
@@ -251,6 +266,64 @@ func TestValidate_ConstraintForeignKeyBadRef(t *testing.T) {
 	requireError(t, errs, "does not match any defined type")
 }
 
+func TestValidate_ConstraintCountEquals(t *testing.T) {
+	newCfg := func(con ConstraintDef) *Config {
+		return &Config{
+			Version: "1.0.0",
+			Types: []TypeDef{
+				{Name: "license", Input: "json", Match: MatchDef{Include: []string{"a"}},
+					Schema:      map[string]any{"type": "object"},
+					Constraints: []ConstraintDef{con}},
+				{Name: "seat", Input: "json", Match: MatchDef{Include: []string{"b"}},
+					Schema: map[string]any{"type": "object"}},
+			},
+		}
+	}
+
+	_, errs := Validate(newCfg(ConstraintDef{Type: "count_equals", Delta: 1, References: &ReferenceDef{Type: "seat"}}), "dev")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	_, errs = Validate(newCfg(ConstraintDef{Type: "count_equals", References: &ReferenceDef{Type: "nonexistent"}}), "dev")
+	requireError(t, errs, `references.type "nonexistent" does not match any defined type`)
+
+	_, errs = Validate(newCfg(ConstraintDef{Type: "count_equals"}), "dev")
+	requireError(t, errs, "references.type is required for count_equals")
+
+	_, errs = Validate(newCfg(ConstraintDef{Type: "count_equals", References: &ReferenceDef{Type: "seat", Key: "$.id"}}), "dev")
+	requireError(t, errs, "count_equals references only support type")
+
+	_, errs = Validate(newCfg(ConstraintDef{Type: "count_equals", Delta: -1, References: &ReferenceDef{Type: "seat"}}), "dev")
+	requireError(t, errs, "delta must not be negative")
+}
+
+func TestValidate_ConstraintFileExists(t *testing.T) {
+	newCfg := func(con ConstraintDef) *Config {
+		return &Config{
+			Version: "1.0.0",
+			Types: []TypeDef{
+				{Name: "app", Input: "json", Match: MatchDef{Include: []string{"a"}},
+					Schema:      map[string]any{"type": "object"},
+					Constraints: []ConstraintDef{con}},
+			},
+		}
+	}
+
+	_, errs := Validate(newCfg(ConstraintDef{Type: "file_exists", Key: "$.icon", BaseDir: "assets/icons"}), "dev")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	_, errs = Validate(newCfg(ConstraintDef{Type: "file_exists"}), "dev")
+	requireError(t, errs, "key is required")
+
+	for _, dir := range []string{"../assets", "/srv/assets", "assets/../../x"} {
+		_, errs = Validate(newCfg(ConstraintDef{Type: "file_exists", Key: "$.icon", BaseDir: dir}), "dev")
+		requireError(t, errs, fmt.Sprintf("base_dir %q must be a relative path inside the repository", dir))
+	}
+}
+
 func TestValidate_ConstraintPathEqualsAttr(t *testing.T) {
 	cfg := &Config{
 		Version: "1.0.0",
@@ -289,6 +362,46 @@ func TestValidate_ConstraintPathEqualsAttrMissingCapture(t *testing.T) {
 	requireError(t, errs, "does not define named group")
 }
 
+func TestValidate_ConstraintPathKey(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "site", Input: "json",
+				Match: MatchDef{Include: []string{`^(?P<region>[a-z]+)/.*\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "unique", Key: "$path.region", Scope: "type"},
+					{Type: "unique", Key: "$path.file", Scope: "type"},
+				}},
+		},
+	}
+	warnings, errs := Validate(cfg, SkipVersionCheck)
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("expected the capture to count as used, got warnings %v", warnings)
+	}
+}
+
+func TestValidate_ConstraintPathKeyMissingCapture(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "site", Input: "json",
+				Match: MatchDef{Include: []string{`^[a-z]+/.*\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "unique", Key: "$path.region", Scope: "type"},
+					{Type: "ordered", Key: "$.items[*]", By: "$path.region"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, SkipVersionCheck)
+	requireError(t, errs, `types[0](site).constraints[0]: key "$path.region" uses capture "region" but site match.include[0] does not define named group (?P<region>...)`)
+	requireError(t, errs, `types[0](site).constraints[1]: by "$path.region" must select from each element, not a path capture`)
+}
+
 func TestValidate_UnknownConstraintType(t *testing.T) {
 	cfg := &Config{
 		Version: "1.0.0",
@@ -405,6 +518,888 @@ func TestValidate_InvalidExcludeRegex(t *testing.T) {
 	requireError(t, errs, "match.exclude[0] invalid regex")
 }
 
+func TestValidate_ConstraintContains(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "contains", Key: "$.tags[*]", Value: "active"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("valid contains constraint should not error, got: %v", errs)
+	}
+}
+
+func TestValidate_ConstraintContainsScalarKey(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "contains", Key: "$.tags", Value: "active"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "must be a multi-value selector")
+}
+
+func TestValidate_ConstraintContainsMissingValue(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "contains", Key: "$.tags[*]"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "value or values is required")
+}
+
+func TestValidate_ConstraintOrderedScalarKey(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "ordered", Key: "$.members", By: "$.name"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "must be a multi-value selector (use [*]) for ordered")
+}
+
+func TestValidate_ConstraintOrderedInvalidBy(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "ordered", Key: "$.members[*]", By: "name"},
+					{Type: "ordered", Key: "$.members[*]", By: "$.names[*]"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `by "name" is not a valid selector`)
+	requireError(t, errs, `by "$.names[*]" must be a scalar selector`)
+}
+
+func TestValidate_ConstraintSequence(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "sequence", Key: "$.version", Start: new(1), Step: 1},
+					{Type: "sequence", Key: "$.versions[*]"},
+					{Type: "sequence", Key: "version"},
+					{Type: "sequence", Key: "$.version", Step: -1},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	requireError(t, errs, `key "$.versions[*]" must be a scalar selector (no [*]) for sequence`)
+	requireError(t, errs, `key "version" is not a valid selector`)
+	requireError(t, errs, "step must be positive")
+}
+
+func TestValidate_ConstraintAllEqual(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "all_equal", Key: "$.schema_version", CaseSensitive: new(false)},
+					{Type: "all_equal", Key: "$.tags[*]"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	requireError(t, errs, `types[0](t).constraints[1]: key "$.tags[*]" must be a scalar selector (no [*]) for all_equal`)
+}
+
+func TestValidate_ConstraintCompare(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "compare", Left: "$.start", Right: "$.end", Operator: "le"},
+					{Type: "compare", Left: "$.dates[*]", Right: "end", Operator: "before"},
+					{Type: "compare", Right: "$.end", Operator: "lt"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	requireError(t, errs, `types[0](t).constraints[1]: left "$.dates[*]" must be a scalar selector (no [*]) for compare`)
+	requireError(t, errs, `types[0](t).constraints[1]: right "end" is not a valid selector`)
+	requireError(t, errs, `types[0](t).constraints[1]: operator "before" must be lt, le, gt, ge, eq, or ne`)
+	requireError(t, errs, "types[0](t).constraints[2]: left is required")
+}
+
+func TestValidate_ConstraintComparePathCaptures(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "release", Input: "json", Match: MatchDef{Include: []string{`^releases/(?P<year>\d+)/[^/]+\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "compare", Left: "$.year", Right: "$path.year", Operator: "eq"},
+					{Type: "compare", Left: "$path.nonexistent", Right: "$.year", Operator: "eq"},
+				}},
+		},
+	}
+	warnings, errs := Validate(cfg, "dev")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	requireError(t, errs, `types[0](release).constraints[1]: left "$path.nonexistent" uses capture "nonexistent" but release match.include[0] does not define named group (?P<nonexistent>...)`)
+	for _, w := range warnings {
+		if strings.Contains(w, "named group") {
+			t.Errorf("a capture used by compare should not warn: %s", w)
+		}
+	}
+}
+
+func TestValidate_ConstraintPathTemplateEqualsAttr(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "service", Input: "yaml",
+				Match:  MatchDef{Include: []string{`^(?P<team>[^/]+)/[^/]+\.yaml$`, `^shared/[^/]+\.yaml$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "path_template_equals_attr", Template: "{path.parent}-{path.file}", References: &ReferenceDef{Key: "$.id"}},
+					{Type: "path_template_equals_attr", Template: "{path.team}/{path.file}", References: &ReferenceDef{Key: "$.id"}},
+					{Type: "path_template_equals_attr", Template: "static", References: &ReferenceDef{Key: "id"}},
+					{Type: "path_template_equals_attr", Template: "{path.file}"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	requireError(t, errs, `types[0](service).constraints[1]: template uses capture "team" but match.include[1] does not define named group (?P<team>...)`)
+	requireError(t, errs, `types[0](service).constraints[2]: template "static" must contain at least one {path.<name>} placeholder`)
+	requireError(t, errs, `types[0](service).constraints[2]: references.key "id" is not a valid selector`)
+	requireError(t, errs, "types[0](service).constraints[3]: references is required for path_template_equals_attr")
+}
+
+func TestValidate_SchemaDialect(t *testing.T) {
+	cfg := &Config{
+		Version:       "1.0.0",
+		SchemaDialect: "draft-04",
+		Types: []TypeDef{
+			{Name: "a", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"}, SchemaDialect: "draft-07"},
+			{Name: "b", Input: "json", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"}, SchemaDialect: "2019-09"},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	requireError(t, errs, `schema_dialect "draft-04" is invalid; must be draft-07 or 2020-12`)
+	requireError(t, errs, `types[1](b): schema_dialect "2019-09" is invalid; must be draft-07 or 2020-12`)
+
+	cfg.SchemaDialect = "2020-12"
+	if got := cfg.SchemaDialectFor(&cfg.Types[0]); got != "draft-07" {
+		t.Errorf("type dialect should override the top-level one, got %q", got)
+	}
+	cfg.Types[0].SchemaDialect = ""
+	if got := cfg.SchemaDialectFor(&cfg.Types[0]); got != "2020-12" {
+		t.Errorf("expected the top-level dialect, got %q", got)
+	}
+}
+
+func TestValidate_InvalidMatchAgainst(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}, Against: "dir"},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `match.against "dir" must be path or basename`)
+}
+
+func TestValidate_ConstraintForeignKeyPathSelectorMissingCapture(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "service", Input: "yaml",
+				Match: MatchDef{Include: []string{`^teams/[^/]+/services/[^/]+\.yaml$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "foreign_key", Key: "$.team",
+						References: &ReferenceDef{Type: "team", PathSelector: "path.team"}},
+				}},
+			{Name: "team", Input: "yaml",
+				Match: MatchDef{Include: []string{`^teams/[^/]+/team\.yaml$`}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "references.path_selector uses capture \"team\" but team match.include[0] does not define named group")
+}
+
+func TestValidate_ReferencesUniqueOnlyForForeignKey(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "graph", Input: "json",
+				Match: MatchDef{Include: []string{`^graph\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "internal_reference", Key: "$.edges[*].to",
+						References: &ReferenceDef{Key: "$.nodes[*].id", Unique: true}},
+					{Type: "foreign_key", Key: "$.id",
+						References: &ReferenceDef{Type: "graph", Key: "$.id", Unique: true}},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, SkipVersionCheck)
+	requireError(t, errs, "types[0](graph).constraints[0]: references.unique is only supported for foreign_key")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestValidate_ReferencesOptionalAndSuggestOnlyForForeignKey(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "graph", Input: "json",
+				Match: MatchDef{Include: []string{`^graph\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "internal_reference", Key: "$.edges[*].to",
+						References: &ReferenceDef{Key: "$.nodes[*].id", Optional: true, Suggest: true}},
+					{Type: "foreign_key", Key: "$.parent",
+						References: &ReferenceDef{Type: "graph", Key: "$.id", Optional: true}},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, SkipVersionCheck)
+	requireError(t, errs, "types[0](graph).constraints[0]: references.optional is only supported for foreign_key")
+	requireError(t, errs, "types[0](graph).constraints[0]: references.suggest is only supported for foreign_key")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", errs)
+	}
+}
+
+func TestValidate_ConstraintForeignKeyPathSelector(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "service", Input: "yaml",
+				Match: MatchDef{Include: []string{`^teams/[^/]+/services/[^/]+\.yaml$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "foreign_key", Key: "$.team",
+						References: &ReferenceDef{Type: "team", PathSelector: "path.team"}},
+				}},
+			{Name: "team", Input: "yaml",
+				Match: MatchDef{Include: []string{`^teams/(?P<team>[^/]+)/team\.yaml$`}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+}
+
+func TestValidate_ConstraintMutuallyExclusive(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "mutually_exclusive", Keys: []string{"$.email", "$.phone"}, RequiredOne: true},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+}
+
+func TestValidate_ConstraintMutuallyExclusiveInvalidKeys(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "mutually_exclusive", Keys: []string{"$.email", "email"}},
+					{Type: "mutually_exclusive", Keys: []string{"$.email"}},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `constraints[0]: keys[1] "email" is not a valid selector`)
+	requireError(t, errs, "constraints[1]: keys must list at least two selectors for mutually_exclusive")
+}
+
+func TestValidate_PathEqualsAttrBadCompare(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "path_equals_attr", PathSelector: "path.file",
+						References: &ReferenceDef{Key: "$.id"}, Compare: "fuzzy"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "compare \"fuzzy\" must be string or numeric")
+}
+
+func TestValidate_TextInputWithoutSchema(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "docs", Input: "text", Match: MatchDef{Include: []string{"\\.md$"}}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+}
+
+func TestValidate_TextInputRejectsConstraints(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "docs", Input: "text", Match: MatchDef{Include: []string{"\\.md$"}},
+				Constraints: []ConstraintDef{{Type: "unique", Key: "$.id"}}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "constraints are not supported for text input")
+}
+
+func TestValidate_OutputYAMLStyle(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "a.yaml", Format: "yaml", YAMLStyle: "compact"}},
+			{Name: "b", Input: "json", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "b.json", Format: "json", YAMLStyle: "flow"}},
+			{Name: "c", Input: "json", Match: MatchDef{Include: []string{"c"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "c.yaml", Format: "yaml", YAMLStyle: "flow"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "types[0](a): output.yaml_style \"compact\" must be block or flow")
+	requireError(t, errs, "types[1](b): output.yaml_style requires output.format yaml")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", errs)
+	}
+}
+
+func TestValidate_OutputPretty(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "a.json", Format: "json", Pretty: new(false)}},
+			{Name: "b", Input: "json", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "b.jsonl", Format: "jsonl", Pretty: new(false)}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "types[1](b): output.pretty requires output.format json")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestValidate_OutputBanner(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "a.jsonl", Format: "jsonl", Banner: "GENERATED"}},
+			{Name: "b", Input: "json", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "b.json", Format: "json", Banner: "GENERATED"}},
+			{Name: "c", Input: "json", Match: MatchDef{Include: []string{"c"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "c.yaml", Format: "yaml", Banner: "GENERATED"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "types[0](a): output.banner is not supported for jsonl output")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestValidate_OutputMaxLines(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "a.jsonl", Format: "jsonl", MaxLines: -1}},
+			{Name: "b", Input: "json", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "b.json", Format: "json", MaxLines: 10}},
+			{Name: "c", Input: "json", Match: MatchDef{Include: []string{"c"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "c.jsonl", Format: "jsonl", MaxLines: 10}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "types[0](a): output.max_lines must be positive")
+	requireError(t, errs, "types[1](b): output.max_lines requires output.format jsonl")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", errs)
+	}
+}
+
+func TestValidate_IncludeMatchesOutputPathWarning(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "teams", Input: "json", Match: MatchDef{Include: []string{`^data/teams/.*\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "./out/teams.json", Format: "json"}},
+			{Name: "exports", Input: "json", Match: MatchDef{Include: []string{`^out/.*\.json$`}},
+				Schema: map[string]any{"type": "object"}},
+			{Name: "skipped", Input: "json", Match: MatchDef{Include: []string{`\.json$`}, Exclude: []string{`^out/`}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	warnings, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+	requireWarning(t, warnings, `types[1](exports): match.include matches output.path "./out/teams.json" of type "teams"`)
+	for _, w := range warnings {
+		if strings.Contains(w, "match.include matches") && (strings.Contains(w, "types[0]") || strings.Contains(w, "types[2]")) {
+			t.Errorf("unexpected warning: %s", w)
+		}
+	}
+}
+
+func TestValidate_IncludeMatchesOutputBasename(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "teams", Input: "json", Match: MatchDef{Include: []string{`^team\.json$`}, Against: "basename"},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "dist/team.json", Format: "json"}},
+		},
+	}
+	warnings, _ := Validate(cfg, "dev")
+	requireWarning(t, warnings, `types[0](teams): match.include matches output.path "dist/team.json" of type "teams"`)
+}
+
+func TestValidate_CoerceRequiresJSONOrYAML(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "csv", Coerce: true, Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "coerce is only supported for json, json5, and yaml input")
+}
+
+func TestValidate_Encoding(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "csv", Encoding: "latin1", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"}},
+			{Name: "b", Input: "csv", Encoding: "ebcdic", Match: MatchDef{Include: []string{"b"}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	requireError(t, errs, `types[1](b): encoding "ebcdic" must be utf-8, latin1, windows-1252, utf-16, utf-16le, or utf-16be`)
+}
+
+func TestValidate_Split(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"tags": map[string]any{"type": "array"},
+			"name": map[string]any{"type": "string"},
+		},
+	}
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "csv", Split: map[string]string{"tags": ";"}, Match: MatchDef{Include: []string{"a"}}, Schema: schema},
+			{Name: "b", Input: "csv", Split: map[string]string{"name": ";", "labels": ","}, Match: MatchDef{Include: []string{"b"}}, Schema: schema},
+			{Name: "c", Input: "json", Split: map[string]string{"tags": ";"}, Match: MatchDef{Include: []string{"c"}}, Schema: schema},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	requireError(t, errs, `types[1](b): split property "labels" not found in schema properties`)
+	requireError(t, errs, `types[1](b): split property "name" must have schema type array`)
+	requireError(t, errs, "types[2](c): split is only supported for csv input")
+}
+
+func TestValidate_Fold(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"items": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"name":  map[string]any{"type": "string"},
+		},
+	}
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "csv", Fold: map[string]string{"items": "item"}, Match: MatchDef{Include: []string{"a"}}, Schema: schema},
+			{Name: "b", Input: "csv", Fold: map[string]string{"name": "n", "tags": "tag", "lines": "line"}, Match: MatchDef{Include: []string{"b"}}, Schema: schema},
+			{Name: "c", Input: "csv", Fold: map[string]string{"items": "item"}, Split: map[string]string{"items": ";"}, Match: MatchDef{Include: []string{"c"}}, Schema: schema},
+			{Name: "d", Input: "yaml", Fold: map[string]string{"items": "item"}, Match: MatchDef{Include: []string{"d"}}, Schema: schema},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 5 {
+		t.Fatalf("expected 5 errors, got %v", errs)
+	}
+	requireError(t, errs, `types[1](b): fold property "lines" not found in schema properties`)
+	requireError(t, errs, `types[1](b): fold property "name" must have schema type array`)
+	requireError(t, errs, `types[1](b): fold property "tags" must have schema items of type object`)
+	requireError(t, errs, `types[2](c): fold property "items" is also listed in split`)
+	requireError(t, errs, "types[3](d): fold is only supported for csv input")
+}
+
+func TestValidate_StrictRowsRequiresCSV(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "a", Input: "csv", StrictRows: true, Match: MatchDef{Include: []string{"a"}}, Schema: map[string]any{"type": "object"}},
+			{Name: "b", Input: "json", StrictRows: true, Match: MatchDef{Include: []string{"b"}}, Schema: map[string]any{"type": "object"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	requireError(t, errs, "types[1](b): strict_rows is only supported for csv input")
+}
+
+func TestValidate_CombinedExportConflictsWithOutput(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Export:  &ExportConfig{Combined: &CombinedOutputDef{Path: "out/all.jsonl"}},
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"^data/"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "out/all.jsonl", Format: "jsonl"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `export.combined.path "out/all.jsonl" conflicts with output.path of type "t"`)
+}
+
+func TestValidate_ManifestConflictsWithOutputs(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Export: &ExportConfig{
+			Combined: &CombinedOutputDef{Path: "out/all.jsonl"},
+			Manifest: &ManifestDef{Path: "out/t.json"},
+		},
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"^data/"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "out/t.json", Format: "json"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `export.manifest.path "out/t.json" conflicts with output.path of type "t"`)
+
+	cfg.Export.Manifest.Path = "out/all.jsonl"
+	_, errs = Validate(cfg, "dev")
+	requireError(t, errs, `export.manifest.path "out/all.jsonl" conflicts with export.combined.path`)
+
+	cfg.Types[0].Output = &OutputDef{Path: "out/t.jsonl", Format: "jsonl", MaxLines: 10}
+	cfg.Export.Manifest.Path = "out/t.index.json"
+	_, errs = Validate(cfg, "dev")
+	requireError(t, errs, `export.manifest.path "out/t.index.json" conflicts with the split output of type "t"`)
+}
+
+func TestValidate_GitAttributesConflictsWithOutputs(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Export: &ExportConfig{
+			Manifest:      &ManifestDef{Path: "out/manifest.json"},
+			GitAttributes: &GitAttributesDef{Path: "out/t.json"},
+		},
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"^data/"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "out/t.json", Format: "json"}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `export.gitattributes.path "out/t.json" conflicts with output.path of type "t"`)
+
+	cfg.Export.GitAttributes.Path = "out/manifest.json"
+	_, errs = Validate(cfg, "dev")
+	requireError(t, errs, `export.gitattributes.path "out/manifest.json" conflicts with export.manifest.path`)
+
+	cfg.Export.GitAttributes.Path = ".gitattributes"
+	if _, errs = Validate(cfg, "dev"); len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidate_IncludeMatchesCombinedExportWarning(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Export:  &ExportConfig{Combined: &CombinedOutputDef{Path: "data/all.jsonl"}},
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"^data/"}},
+				Schema: map[string]any{"type": "object"}},
+		},
+	}
+	warnings, _ := Validate(cfg, "dev")
+	requireWarning(t, warnings, `types[0](t): match.include matches export.combined.path "data/all.jsonl"`)
+}
+
+func TestValidate_FormatConstraint(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "t", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "format", Key: "$.email", Format: "email"},
+					{Type: "format", Key: "$.phone", Format: "phone"},
+				}},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `constraints[1]: format "phone" must be one of email, url, uuid, ipv4, hostname`)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestLint_UnusedCaptureGroups(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "team", Input: "json", Match: MatchDef{Include: []string{`^teams/(?P<team>[^/]+)/(?P<env>[^/]+)\.json$`}},
+				Schema:      map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{{Type: "path_equals_attr", PathSelector: "path.team", References: &ReferenceDef{Key: "$.id"}}}},
+			{Name: "app", Input: "json", Match: MatchDef{Include: []string{`^apps/(?P<app>[^/]+)\.json$`}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{{Type: "foreign_key", Key: "$.env",
+					References: &ReferenceDef{Type: "team", PathSelector: "path.env"}}}},
+		},
+	}
+	warnings, errs := Validate(cfg, "dev")
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got: %v", errs)
+	}
+	requireWarning(t, warnings, `types[1](app): match.include[0] named group "app" is not used by any path_selector`)
+	for _, w := range warnings {
+		if strings.Contains(w, "named group") && strings.Contains(w, "types[0]") {
+			t.Errorf("captures used by path_equals_attr and foreign_key should not warn: %s", w)
+		}
+	}
+}
+
+func TestLint_TypeWithoutConstraintsOrOutput(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "bare", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"}},
+			{Name: "exported", Input: "json", Match: MatchDef{Include: []string{"^data/b"}},
+				Schema: map[string]any{"type": "object"},
+				Output: &OutputDef{Path: "out/b.json", Format: "json"}},
+			{Name: "notes", Input: "text", Match: MatchDef{Include: []string{"c"}}},
+		},
+	}
+	lint := Lint(cfg)
+	if len(lint) != 1 || lint[0] != "types[0](bare): has no constraints and no output; its files are only checked against the schema" {
+		t.Fatalf("unexpected lint warnings: %v", lint)
+	}
+	warnings, _ := Validate(cfg, "dev")
+	requireWarning(t, warnings, "types[0](bare): has no constraints and no output")
+}
+
+func TestLint_ForeignKeyFieldsNotInSchema(t *testing.T) {
+	teamSchema := map[string]any{"type": "object", "properties": map[string]any{"id": map[string]any{"type": "string"}}}
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "team", Input: "json", Match: MatchDef{Include: []string{"^teams/"}}, Schema: teamSchema,
+				Output: &OutputDef{Path: "out/teams.json", Format: "json"}},
+			{Name: "service", Input: "json", Match: MatchDef{Include: []string{"^services/"}},
+				Schema: map[string]any{"type": "object", "properties": map[string]any{"team": map[string]any{"type": "object"}}},
+				Constraints: []ConstraintDef{
+					{Type: "foreign_key", Key: "$.team.id", References: &ReferenceDef{Type: "team", Key: "$.id"}},
+					{Type: "foreign_key", Key: "$.teem", References: &ReferenceDef{Type: "team", Key: "$.ident"}},
+				}},
+			{Name: "loose", Input: "json", Match: MatchDef{Include: []string{"^loose/"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "foreign_key", Key: "$.anything", References: &ReferenceDef{Type: "team", Key: "$.id"}},
+				}},
+		},
+	}
+	lint := Lint(cfg)
+	want := []string{
+		`types[1](service).constraints[1]: key "$.teem" reads field "teem", which is not in the schema properties`,
+		`types[1](service).constraints[1]: references.key "$.ident" reads field "ident", which is not in the schema properties of type "team"`,
+	}
+	if !slices.Equal(lint, want) {
+		t.Fatalf("unexpected lint warnings:\n%s", strings.Join(lint, "\n"))
+	}
+}
+
+func TestValidate_Severity(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "user", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "unique", Key: "$.id", Severity: "warning"},
+					{Type: "unique", Key: "$.name", Severity: "error"},
+					{Type: "unique", Key: "$.email", Severity: "info"},
+				},
+			},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `types[0](user).constraints[2]: severity "info" must be error or warning`)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestValidate_Forbidden(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "user", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "forbidden", Key: "$.username", Values: []string{"admin"}},
+					{Type: "forbidden", Key: "$.username"},
+				},
+			},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, "types[0](user).constraints[1]: values is required for forbidden")
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+}
+
+func TestValidate_InternalReference(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "graph", Input: "json", Match: MatchDef{Include: []string{"a"}},
+				Schema: map[string]any{"type": "object"},
+				Constraints: []ConstraintDef{
+					{Type: "internal_reference", Key: "$.edges[*].to", References: &ReferenceDef{Key: "$.nodes[*].id"}},
+					{Type: "internal_reference", Key: "$.edges[", References: &ReferenceDef{Key: "$.nodes[*].id"}},
+					{Type: "internal_reference", Key: "$.edges[*].to"},
+					{Type: "internal_reference", Key: "$.edges[*].to", References: &ReferenceDef{Type: "graph", Key: "$.id"}},
+				},
+			},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `types[0](graph).constraints[1]: key "$.edges[" is not a valid selector`)
+	requireError(t, errs, "types[0](graph).constraints[2]: references is required for internal_reference")
+	requireError(t, errs, "types[0](graph).constraints[3]: internal_reference references only support key")
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got: %v", errs)
+	}
+}
+
+func TestValidate_InputAutoPatterns(t *testing.T) {
+	cfg := &Config{
+		Version: "1.0.0",
+		Types: []TypeDef{
+			{Name: "docs", Input: "auto", Coerce: true,
+				Match: MatchDef{Include: []string{
+					`^docs/.*\.(json|ya?ml)$`,
+					`^a\.json$|^b\.YML$`,
+					`^docs/`,
+					`^docs/.*\.(json|csv)$`,
+					`^docs/.*json$`,
+					`^docs/.*\.json5$`,
+				}},
+				Schema: map[string]any{"type": "object"},
+			},
+		},
+	}
+	_, errs := Validate(cfg, "dev")
+	requireError(t, errs, `types[0](docs): match.include[2] "^docs/" must only match .json, .json5, .yaml, or .yml files for input auto`)
+	requireError(t, errs, "match.include[3]")
+	requireError(t, errs, "match.include[4]")
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got: %v", errs)
+	}
+}
+
 // helpers
 
 func requireError(t *testing.T, errs []error, substr string) {
@@ -0,0 +1,118 @@
+package constraints
+
+import (
+	"fmt"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+)
+
+// compareOperators maps each "compare" operator to the symbol used in
+// violation messages.
+var compareOperators = map[string]string{
+	"lt": "<",
+	"le": "<=",
+	"gt": ">",
+	"ge": ">=",
+	"eq": "==",
+	"ne": "!=",
+}
+
+// evalCompare checks the "compare" constraint: in each item the value of the
+// left selector must relate to the value of the right selector as operator
+// says. Two numbers compare numerically and two strings lexically, so ISO 8601
+// dates order correctly; any other pair is reported as a type mismatch. Items
+// where either selector resolves to no value or null are skipped.
+func evalCompare(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	fail := func(item Item, msg string) Error {
+		return Error{
+			ConstraintID:   constraintID,
+			ConstraintType: "compare",
+			TypeName:       typeName,
+			FilePath:       item.FilePath,
+			Message:        msg,
+			RowIndex:       item.RowIndex,
+		}
+	}
+	leftSel, err := selector.Parse(cd.Left)
+	if err != nil {
+		return []Error{fail(Item{RowIndex: -1}, fmt.Sprintf("invalid left selector %q: %v", cd.Left, err))}
+	}
+	rightSel, err := selector.Parse(cd.Right)
+	if err != nil {
+		return []Error{fail(Item{RowIndex: -1}, fmt.Sprintf("invalid right selector %q: %v", cd.Right, err))}
+	}
+	symbol, ok := compareOperators[cd.Operator]
+	if !ok {
+		return []Error{fail(Item{RowIndex: -1}, fmt.Sprintf("unknown operator %q", cd.Operator))}
+	}
+
+	var errs []Error
+	for _, item := range items {
+		lvals, _ := leftSel.Evaluate(source(leftSel, item))
+		rvals, _ := rightSel.Evaluate(source(rightSel, item))
+		if len(lvals) == 0 || len(rvals) == 0 || lvals[0] == nil || rvals[0] == nil {
+			continue
+		}
+		l, r := lvals[0], rvals[0]
+
+		lkind, rkind := compareKind(l), compareKind(r)
+		if lkind == "" || lkind != rkind {
+			errs = append(errs, fail(item, fmt.Sprintf("cannot compare %s (%s %q) with %s (%s %q)",
+				cd.Left, valueKind(l), fmt.Sprint(l), cd.Right, valueKind(r), fmt.Sprint(r))))
+			continue
+		}
+
+		c := compareOrdered(l, r, true)
+		var holds bool
+		switch cd.Operator {
+		case "lt":
+			holds = c < 0
+		case "le":
+			holds = c <= 0
+		case "gt":
+			holds = c > 0
+		case "ge":
+			holds = c >= 0
+		case "eq":
+			holds = c == 0
+		case "ne":
+			holds = c != 0
+		}
+		if !holds {
+			errs = append(errs, fail(item, fmt.Sprintf("expected %s %s %s, got %q and %q",
+				cd.Left, symbol, cd.Right, fmt.Sprint(l), fmt.Sprint(r))))
+		}
+	}
+	return errs
+}
+
+// compareKind returns "number" or "string" for the values the "compare"
+// constraint can order, and "" for any other value.
+func compareKind(v any) string {
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	if _, ok := v.(string); ok {
+		return "string"
+	}
+	return ""
+}
+
+// valueKind names the JSON type of a parsed value for messages.
+func valueKind(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	}
+	if _, ok := toFloat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,79 @@
+package constraints
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func compareItems(ranges ...map[string]any) map[string][]Item {
+	var items []Item
+	for i, data := range ranges {
+		items = append(items, Item{TypeName: "event", FilePath: fmt.Sprintf("events/%d.yaml", i), Data: data, RowIndex: -1})
+	}
+	return map[string][]Item{"event": items}
+}
+
+func compareDefs(operator string) []config.TypeDef {
+	return []config.TypeDef{{
+		Name:        "event",
+		Constraints: []config.ConstraintDef{{ID: "range", Type: "compare", Left: "$.start", Right: "$.end", Operator: operator}},
+	}}
+}
+
+func TestCompare_StartBeforeEndSatisfied(t *testing.T) {
+	items := compareItems(
+		map[string]any{"start": "2024-01-01", "end": "2024-03-31"},
+		map[string]any{"start": "2024-05-01", "end": "2024-05-01"},
+		map[string]any{"start": 9, "end": 10.5},
+		map[string]any{"start": "2024-06-01"}, // no end: skipped
+	)
+	if errs := Evaluate(items, compareDefs("le")); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %v", errs)
+	}
+}
+
+func TestCompare_StartBeforeEndViolated(t *testing.T) {
+	items := compareItems(
+		map[string]any{"start": "2024-01-01", "end": "2024-03-31"},
+		map[string]any{"start": "2024-05-02", "end": "2024-05-01"},
+		map[string]any{"start": 10, "end": 9}, // numeric, not lexical
+	)
+	errs := Evaluate(items, compareDefs("le"))
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "events/1.yaml" || errs[0].Message != `expected $.start <= $.end, got "2024-05-02" and "2024-05-01"` {
+		t.Errorf("unexpected error: %+v", errs[0])
+	}
+	if errs[1].FilePath != "events/2.yaml" || errs[1].Message != `expected $.start <= $.end, got "10" and "9"` {
+		t.Errorf("unexpected error: %+v", errs[1])
+	}
+}
+
+func TestCompare_Operators(t *testing.T) {
+	items := compareItems(map[string]any{"start": 1, "end": 2})
+	for op, wantErr := range map[string]bool{"lt": false, "le": false, "gt": true, "ge": true, "eq": true, "ne": false} {
+		if errs := Evaluate(items, compareDefs(op)); (len(errs) != 0) != wantErr {
+			t.Errorf("operator %s: expected error %v, got %v", op, wantErr, errs)
+		}
+	}
+}
+
+func TestCompare_TypeMismatch(t *testing.T) {
+	items := compareItems(
+		map[string]any{"start": "2024-01-01", "end": 20240331},
+		map[string]any{"start": true, "end": false},
+	)
+	errs := Evaluate(items, compareDefs("le"))
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Message != `cannot compare $.start (string "2024-01-01") with $.end (number "20240331")` {
+		t.Errorf("unexpected error: %+v", errs[0])
+	}
+	if errs[1].Message != `cannot compare $.start (boolean "true") with $.end (boolean "false")` {
+		t.Errorf("unexpected error: %+v", errs[1])
+	}
+}
@@ -1,8 +1,14 @@
 package constraints
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
@@ -22,6 +28,7 @@ type Item struct {
 type Error struct {
 	ConstraintID   string
 	ConstraintType string
+	Severity       string // "error" or "warning", from the constraint's severity
 	TypeName       string
 	FilePath       string
 	Message        string
@@ -36,11 +43,27 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("[%s] %s %s: %s", e.TypeName, e.ConstraintType, e.FilePath, e.Message)
 }
 
-// Evaluate evaluates all constraints across all items.
+// Evaluate evaluates all constraints across all items, resolving file_exists
+// paths against the working directory.
 // items is a map from type name to slice of items.
 // Returns errors sorted deterministically.
 func Evaluate(items map[string][]Item, typeDefs []config.TypeDef) []Error {
+	return EvaluateIn(".", items, typeDefs)
+}
+
+// EvaluateIn is Evaluate with file_exists paths resolved against rootDir, the
+// repository root holding the config.
+func EvaluateIn(rootDir string, items map[string][]Item, typeDefs []config.TypeDef) []Error {
+	return EvaluatePartial(rootDir, items, typeDefs, nil)
+}
+
+// EvaluatePartial is EvaluateIn when only some of the items of the types in
+// partial were loaded, as for validate --since or --stdin. A selector that
+// resolves in none of those items says nothing about the rest of the type, so
+// the warning that a constraint checks nothing is left out for them.
+func EvaluatePartial(rootDir string, items map[string][]Item, typeDefs []config.TypeDef, partial map[string]bool) []Error {
 	var errs []Error
+	refIndexes := refIndexCache{}
 
 	for _, td := range typeDefs {
 		typeItems := items[td.Name]
@@ -54,15 +77,53 @@ func Evaluate(items map[string][]Item, typeDefs []config.TypeDef) []Error {
 			case "unique":
 				ces = evalUnique(td.Name, constraintID, cd, typeItems)
 			case "foreign_key":
-				ces = evalForeignKey(td.Name, constraintID, cd, typeItems, items)
+				ces = evalForeignKey(td.Name, constraintID, cd, typeItems, items, refIndexes)
+			case "contains":
+				ces = evalContains(td.Name, constraintID, cd, typeItems)
+			case "ordered":
+				ces = evalOrdered(td.Name, constraintID, cd, typeItems)
+			case "mutually_exclusive":
+				ces = evalMutuallyExclusive(td.Name, constraintID, cd, typeItems)
+			case "format":
+				ces = evalFormat(td.Name, constraintID, cd, typeItems)
+			case "internal_reference":
+				ces = evalInternalReference(td.Name, constraintID, cd, typeItems)
+			case "forbidden":
+				ces = evalForbidden(td.Name, constraintID, cd, typeItems)
 			case "path_equals_attr":
 				ces = evalPathEqualsAttr(td.Name, constraintID, cd, typeItems)
+			case "path_template_equals_attr":
+				ces = evalPathTemplateEqualsAttr(td.Name, constraintID, cd, typeItems)
+			case "count_equals":
+				ces = evalCountEquals(td.Name, constraintID, cd, typeItems, items)
+			case "file_exists":
+				ces = evalFileExists(td.Name, constraintID, cd, typeItems, rootDir)
+			case "sequence":
+				ces = evalSequence(td.Name, constraintID, cd, typeItems)
+			case "all_equal":
+				ces = evalAllEqual(td.Name, constraintID, cd, typeItems)
+			case "compare":
+				ces = evalCompare(td.Name, constraintID, cd, typeItems)
+			}
+			if cd.RequirePath {
+				ces = append(ces, evalRequirePath(td.Name, constraintID, cd, typeItems)...)
+			}
+			severity := "error"
+			if cd.IsWarning() {
+				severity = "warning"
+			}
+			for i := range ces {
+				ces[i].Severity = severity
 			}
 			errs = append(errs, ces...)
+			if !partial[td.Name] {
+				errs = append(errs, evalDeadSelectors(td.Name, constraintID, cd, typeItems)...)
+			}
 		}
 	}
 
-	sort.Slice(errs, func(i, j int) bool {
+	// stable, so violations within one item keep their evaluation order
+	sort.SliceStable(errs, func(i, j int) bool {
 		if errs[i].TypeName != errs[j].TypeName {
 			return errs[i].TypeName < errs[j].TypeName
 		}
@@ -78,15 +139,113 @@ func Evaluate(items map[string][]Item, typeDefs []config.TypeDef) []Error {
 	return errs
 }
 
-// normalizeKey converts a value to a string key for comparison.
+// normalizeKey converts a value to a string key for comparison. Objects and
+// arrays, such as the whole item selected by "$", use their canonical JSON.
 func normalizeKey(v any, caseSensitive bool) string {
-	s := fmt.Sprintf("%v", v)
+	var s string
+	switch v.(type) {
+	case map[string]any, []any:
+		s = canonicalJSON(v)
+	default:
+		s = fmt.Sprintf("%v", v)
+	}
 	if !caseSensitive {
 		s = strings.ToLower(s)
 	}
 	return s
 }
 
+// canonicalJSON serializes v as JSON, which sorts object keys, so values that
+// differ only in key order get the same key, while 1 and "1" do not (as they
+// would with %v). Integers and equal floats serialize alike.
+func canonicalJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v) // NaN or Infinity, from JSON5
+	}
+	return string(b)
+}
+
+// evalRequirePath reports items whose constraint selector is broken at an
+// intermediate segment (for example $.meta.id when $.meta is absent), which
+// would otherwise be treated the same as a missing value.
+func evalRequirePath(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	key := cd.Key
+	if key == "" && cd.References != nil {
+		key = cd.References.Key
+	}
+	sel, err := selector.Parse(key)
+	if err != nil {
+		return nil // reported by the constraint itself
+	}
+
+	var errs []Error
+	for _, item := range items {
+		_, err := sel.EvaluateStrict(source(sel, item))
+		var missing *selector.MissingPathError
+		if errors.As(err, &missing) && !missing.Leaf {
+			errs = append(errs, Error{
+				ConstraintID:   constraintID,
+				ConstraintType: cd.Type,
+				TypeName:       typeName,
+				FilePath:       item.FilePath,
+				Message:        fmt.Sprintf("required path %s not found: intermediate field %q is missing", key, missing.Field),
+				RowIndex:       item.RowIndex,
+			})
+		}
+	}
+	return errs
+}
+
+// skippedSelectors returns the selectors of cd that it reads from each item
+// and skips the item for when they resolve to nothing. The others either
+// report such an item (contains, path_equals_attr, path_template_equals_attr),
+// expect the value to be absent (forbidden, mutually_exclusive), or read no
+// values (count_equals).
+func skippedSelectors(cd config.ConstraintDef) []string {
+	switch cd.Type {
+	case "contains", "path_equals_attr", "path_template_equals_attr", "forbidden", "mutually_exclusive", "count_equals":
+		return nil
+	case "compare":
+		return []string{cd.Left, cd.Right}
+	}
+	return append([]string{cd.Key}, cd.Keys...)
+}
+
+// evalDeadSelectors warns about each selector of cd that resolves to no value
+// in any of items. Such a constraint, typically one with a misspelled key,
+// never finds a violation, so it passes without checking anything. The
+// warning is reported whatever the constraint's severity; nothing is reported
+// for a type without items.
+func evalDeadSelectors(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	if len(items) == 0 {
+		return nil
+	}
+	var errs []Error
+	for _, key := range skippedSelectors(cd) {
+		sel, err := selector.Parse(key)
+		if err != nil {
+			continue // reported by the constraint itself
+		}
+		resolves := slices.ContainsFunc(items, func(item Item) bool {
+			vals, _ := sel.Evaluate(source(sel, item))
+			return len(vals) > 0
+		})
+		if !resolves {
+			errs = append(errs, Error{
+				ConstraintID:   constraintID,
+				ConstraintType: cd.Type,
+				Severity:       "warning",
+				TypeName:       typeName,
+				FilePath:       "",
+				Message:        fmt.Sprintf("selector %s did not resolve to a value in any of %d item(s); the constraint checks nothing", key, len(items)),
+				RowIndex:       -1,
+			})
+		}
+	}
+	return errs
+}
+
 // evalUnique checks the "unique" constraint.
 func evalUnique(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
 	sel, err := selector.Parse(cd.Key)
@@ -121,7 +280,7 @@ func evalUniqueTypeScope(typeName, constraintID string, cd config.ConstraintDef,
 	index := make(map[string][]seen)
 
 	for _, item := range items {
-		vals, _ := sel.Evaluate(item.Data)
+		vals, _ := sel.Evaluate(source(sel, item))
 		if len(vals) == 0 {
 			continue
 		}
@@ -134,13 +293,17 @@ func evalUniqueTypeScope(typeName, constraintID string, cd config.ConstraintDef,
 		if len(entries) < 2 {
 			continue
 		}
+		msg := fmt.Sprintf("duplicate value %q for key %s", key, cd.Key)
+		if cd.Key == "$" {
+			msg = fmt.Sprintf("duplicate item: identical in every field to %d other item(s)", len(entries)-1)
+		}
 		for _, e := range entries {
 			errs = append(errs, Error{
 				ConstraintID:   constraintID,
 				ConstraintType: "unique",
 				TypeName:       typeName,
 				FilePath:       e.filePath,
-				Message:        fmt.Sprintf("duplicate value %q for key %s", key, cd.Key),
+				Message:        msg,
 				RowIndex:       e.rowIndex,
 			})
 		}
@@ -149,26 +312,28 @@ func evalUniqueTypeScope(typeName, constraintID string, cd config.ConstraintDef,
 	return errs
 }
 
-// evalUniqueItemScope enforces uniqueness within each individual item.
+// evalUniqueItemScope enforces uniqueness within each individual item. Each
+// repeated value is reported with the path of the duplicate and of its first
+// occurrence, such as $.members[3].id and $.members[0].id.
 func evalUniqueItemScope(typeName, constraintID string, cd config.ConstraintDef, sel *selector.Selector, caseSensitive bool, items []Item) []Error {
 	var errs []Error
 
 	for _, item := range items {
-		vals, _ := sel.Evaluate(item.Data)
-		seen := make(map[string]bool)
-		for _, v := range vals {
-			key := normalizeKey(v, caseSensitive)
-			if seen[key] {
+		first := make(map[string]string) // normalized value -> path of its first occurrence
+		for _, m := range sel.EvaluateMatches(source(sel, item)) {
+			key := normalizeKey(m.Value, caseSensitive)
+			if firstPath, ok := first[key]; ok {
 				errs = append(errs, Error{
 					ConstraintID:   constraintID,
 					ConstraintType: "unique",
 					TypeName:       typeName,
 					FilePath:       item.FilePath,
-					Message:        fmt.Sprintf("duplicate value %q for key %s within item", key, cd.Key),
+					Message:        fmt.Sprintf("duplicate value %q for key %s within item at %s (first at %s)", key, cd.Key, m.Path, firstPath),
 					RowIndex:       item.RowIndex,
 				})
+				continue
 			}
-			seen[key] = true
+			first[key] = m.Path
 		}
 	}
 
@@ -176,7 +341,7 @@ func evalUniqueItemScope(typeName, constraintID string, cd config.ConstraintDef,
 }
 
 // evalForeignKey checks the "foreign_key" constraint.
-func evalForeignKey(typeName, constraintID string, cd config.ConstraintDef, items []Item, allItems map[string][]Item) []Error {
+func evalForeignKey(typeName, constraintID string, cd config.ConstraintDef, items []Item, allItems map[string][]Item, refIndexes refIndexCache) []Error {
 	if cd.References == nil {
 		return []Error{{
 			ConstraintID:   constraintID,
@@ -200,31 +365,25 @@ func evalForeignKey(typeName, constraintID string, cd config.ConstraintDef, item
 		}}
 	}
 
-	refSel, err := selector.Parse(cd.References.Key)
+	refIndex, err := refIndexes.get(*cd.References, allItems)
 	if err != nil {
 		return []Error{{
 			ConstraintID:   constraintID,
 			ConstraintType: "foreign_key",
 			TypeName:       typeName,
 			FilePath:       "",
-			Message:        fmt.Sprintf("invalid references.key selector %q: %v", cd.References.Key, err),
+			Message:        err.Error(),
 			RowIndex:       -1,
 		}}
 	}
-
-	// Build lookup index from referenced type
-	refItems := allItems[cd.References.Type]
-	refIndex := make(map[string]bool)
-	for _, ri := range refItems {
-		vals, _ := refSel.Evaluate(ri.Data)
-		if len(vals) == 1 {
-			refIndex[normalizeKey(vals[0], true)] = true
-		}
+	refName := cd.References.Key
+	if cd.References.PathSelector != "" {
+		refName = cd.References.PathSelector
 	}
 
 	var errs []Error
 	for _, item := range items {
-		vals, _ := keySel.Evaluate(item.Data)
+		vals, _ := keySel.Evaluate(source(keySel, item))
 		if len(vals) == 0 {
 			continue
 		}
@@ -239,14 +398,197 @@ func evalForeignKey(typeName, constraintID string, cd config.ConstraintDef, item
 			})
 			continue
 		}
+		if cd.References.Optional && (vals[0] == nil || vals[0] == "") {
+			continue
+		}
 		key := normalizeKey(vals[0], true)
-		if !refIndex[key] {
+		var msg string
+		switch n := refIndex[key]; {
+		case n == 0:
+			msg = fmt.Sprintf("foreign key %q not found in %s.%s", key, cd.References.Type, refName)
+			if cd.References.Suggest {
+				msg += suggestValues(refIndex)
+			}
+		case n > 1 && cd.References.Unique:
+			msg = fmt.Sprintf("foreign key %q matches %d items in %s.%s; expected exactly one", key, n, cd.References.Type, refName)
+		default:
+			continue
+		}
+		errs = append(errs, Error{
+			ConstraintID:   constraintID,
+			ConstraintType: "foreign_key",
+			TypeName:       typeName,
+			FilePath:       item.FilePath,
+			Message:        msg,
+			RowIndex:       item.RowIndex,
+		})
+	}
+
+	return errs
+}
+
+// maxSuggestions is the most target values a foreign_key with
+// references.suggest lists for a key that is not found.
+const maxSuggestions = 10
+
+// suggestValues describes the valid values of a foreign key index for an
+// error message: all of them in sorted order when there are at most
+// maxSuggestions, otherwise the first maxSuggestions and how many more exist.
+func suggestValues(refIndex map[string]int) string {
+	if len(refIndex) == 0 {
+		return "; no valid values exist"
+	}
+	values := slices.Sorted(maps.Keys(refIndex))
+	quoted := make([]string, 0, min(len(values), maxSuggestions))
+	for _, v := range values[:min(len(values), maxSuggestions)] {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	if len(values) <= maxSuggestions {
+		return "; valid values: " + strings.Join(quoted, ", ")
+	}
+	return fmt.Sprintf("; valid values include: %s (and %d more)", strings.Join(quoted, ", "), len(values)-maxSuggestions)
+}
+
+// refIndexCache holds the foreign key lookup indexes built during one
+// Evaluate call, so constraints referencing the same type and key share a
+// single scan of the referenced items.
+type refIndexCache map[config.ReferenceDef]map[string]int
+
+// get returns the number of referenced items holding each normalized
+// reference value for ref, keyed by a data selector or, with
+// references.path_selector, by a path capture of each referenced file. The
+// index is built on first use and cached.
+func (c refIndexCache) get(ref config.ReferenceDef, allItems map[string][]Item) (map[string]int, error) {
+	ref.Unique, ref.Optional, ref.Suggest = false, false, false // applied by the caller; the index is the same
+	if index, ok := c[ref]; ok {
+		return index, nil
+	}
+
+	refItems := allItems[ref.Type]
+	index := make(map[string]int)
+	if ref.PathSelector != "" {
+		for _, ri := range refItems {
+			if v, ok := resolvePathSelector(ref.PathSelector, ri.PathCaptures); ok {
+				index[normalizeKey(v, true)]++
+			}
+		}
+	} else {
+		refSel, err := selector.Parse(ref.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid references.key selector %q: %v", ref.Key, err)
+		}
+		for _, ri := range refItems {
+			vals, _ := refSel.Evaluate(source(refSel, ri))
+			if len(vals) == 1 {
+				index[normalizeKey(vals[0], true)]++
+			}
+		}
+	}
+
+	c[ref] = index
+	return index, nil
+}
+
+// evalCountEquals checks the "count_equals" constraint: the number of items of
+// this type must equal the number of items of references.type, or differ from
+// it by at most delta.
+func evalCountEquals(typeName, constraintID string, cd config.ConstraintDef, items []Item, allItems map[string][]Item) []Error {
+	if cd.References == nil || cd.References.Type == "" {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "count_equals",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        "missing references.type",
+			RowIndex:       -1,
+		}}
+	}
+
+	refType := cd.References.Type
+	count, refCount := len(items), len(allItems[refType])
+	diff := count - refCount
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff <= cd.Delta {
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s has %d items but %s has %d", typeName, count, refType, refCount)
+	if cd.Delta > 0 {
+		msg += fmt.Sprintf("; counts may differ by at most %d", cd.Delta)
+	}
+	return []Error{{
+		ConstraintID:   constraintID,
+		ConstraintType: "count_equals",
+		TypeName:       typeName,
+		FilePath:       "",
+		Message:        msg,
+		RowIndex:       -1,
+	}}
+}
+
+// evalInternalReference checks the "internal_reference" constraint: within
+// each item, every value resolved by key must be among the values resolved by
+// references.key in the same item. Missing or null source values are skipped.
+func evalInternalReference(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	if cd.References == nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "internal_reference",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        "missing references definition",
+			RowIndex:       -1,
+		}}
+	}
+
+	srcSel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "internal_reference",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid key selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+	targetSel, err := selector.Parse(cd.References.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "internal_reference",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid references.key selector %q: %v", cd.References.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	var errs []Error
+	for _, item := range items {
+		targets, _ := targetSel.Evaluate(source(targetSel, item))
+		index := make(map[string]bool, len(targets))
+		for _, v := range targets {
+			index[normalizeKey(v, true)] = true
+		}
+
+		srcs, _ := srcSel.Evaluate(source(srcSel, item))
+		for _, v := range srcs {
+			if v == nil {
+				continue
+			}
+			key := normalizeKey(v, true)
+			if index[key] {
+				continue
+			}
 			errs = append(errs, Error{
 				ConstraintID:   constraintID,
-				ConstraintType: "foreign_key",
+				ConstraintType: "internal_reference",
 				TypeName:       typeName,
 				FilePath:       item.FilePath,
-				Message:        fmt.Sprintf("foreign key %q not found in %s.%s", key, cd.References.Type, cd.References.Key),
+				Message:        fmt.Sprintf("value %q of %s not found in %s", key, cd.Key, cd.References.Key),
 				RowIndex:       item.RowIndex,
 			})
 		}
@@ -255,6 +597,395 @@ func evalForeignKey(typeName, constraintID string, cd config.ConstraintDef, item
 	return errs
 }
 
+// evalContains checks the "contains" constraint: every required value must
+// appear among the values resolved by the multi-value key of each item.
+func evalContains(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "contains",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	caseSensitive := cd.IsCaseSensitive()
+	required := cd.RequiredValues()
+
+	var errs []Error
+	for _, item := range items {
+		vals, _ := sel.Evaluate(source(sel, item))
+		present := make(map[string]bool, len(vals))
+		for _, v := range vals {
+			present[normalizeKey(v, caseSensitive)] = true
+		}
+		for _, want := range required {
+			if present[normalizeKey(want, caseSensitive)] {
+				continue
+			}
+			errs = append(errs, Error{
+				ConstraintID:   constraintID,
+				ConstraintType: "contains",
+				TypeName:       typeName,
+				FilePath:       item.FilePath,
+				Message:        fmt.Sprintf("required value %q not found in %s", want, cd.Key),
+				RowIndex:       item.RowIndex,
+			})
+		}
+	}
+
+	return errs
+}
+
+// evalForbidden checks the "forbidden" constraint: no value resolved by key may
+// equal one of Values. Each matching value is reported, so a multi-value key
+// can produce several errors for one item.
+func evalForbidden(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "forbidden",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	caseSensitive := cd.IsCaseSensitive()
+	forbidden := make(map[string]bool, len(cd.Values))
+	for _, v := range cd.Values {
+		forbidden[normalizeKey(v, caseSensitive)] = true
+	}
+
+	var errs []Error
+	for _, item := range items {
+		vals, _ := sel.Evaluate(source(sel, item))
+		for _, v := range vals {
+			if v == nil || !forbidden[normalizeKey(v, caseSensitive)] {
+				continue
+			}
+			errs = append(errs, Error{
+				ConstraintID:   constraintID,
+				ConstraintType: "forbidden",
+				TypeName:       typeName,
+				FilePath:       item.FilePath,
+				Message:        fmt.Sprintf("forbidden value %q found in %s", normalizeKey(v, true), cd.Key),
+				RowIndex:       item.RowIndex,
+			})
+		}
+	}
+
+	return errs
+}
+
+// evalMutuallyExclusive checks the "mutually_exclusive" constraint: at most one
+// of the Keys selectors may be set in each item, and with RequiredOne at least
+// one must be. A key is set when it resolves to a value other than null or "".
+func evalMutuallyExclusive(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sels := make([]*selector.Selector, len(cd.Keys))
+	for i, key := range cd.Keys {
+		sel, err := selector.Parse(key)
+		if err != nil {
+			return []Error{{
+				ConstraintID:   constraintID,
+				ConstraintType: "mutually_exclusive",
+				TypeName:       typeName,
+				FilePath:       "",
+				Message:        fmt.Sprintf("invalid selector %q: %v", key, err),
+				RowIndex:       -1,
+			}}
+		}
+		sels[i] = sel
+	}
+
+	var errs []Error
+	for _, item := range items {
+		var set []string
+		for i, sel := range sels {
+			vals, _ := sel.Evaluate(source(sel, item))
+			if slices.ContainsFunc(vals, func(v any) bool { return v != nil && v != "" }) {
+				set = append(set, cd.Keys[i])
+			}
+		}
+
+		var msg string
+		switch {
+		case len(set) > 1:
+			msg = fmt.Sprintf("only one of %s may be set, found %s", strings.Join(cd.Keys, ", "), strings.Join(set, ", "))
+		case len(set) == 0 && cd.RequiredOne:
+			msg = fmt.Sprintf("one of %s must be set", strings.Join(cd.Keys, ", "))
+		default:
+			continue
+		}
+		errs = append(errs, Error{
+			ConstraintID:   constraintID,
+			ConstraintType: "mutually_exclusive",
+			TypeName:       typeName,
+			FilePath:       item.FilePath,
+			Message:        msg,
+			RowIndex:       item.RowIndex,
+		})
+	}
+
+	return errs
+}
+
+// evalOrdered checks the "ordered" constraint: the elements selected by Key
+// must be in non-decreasing order of the By sub-selector (the element itself
+// when By is empty). Elements whose By value is missing are skipped. Only the
+// first out-of-order element of each item is reported.
+func evalOrdered(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "ordered",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+	by := cd.By
+	if by == "" {
+		by = "$"
+	}
+	bySel, err := selector.Parse(by)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "ordered",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid by selector %q: %v", by, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	caseSensitive := cd.IsCaseSensitive()
+
+	var errs []Error
+	for _, item := range items {
+		elems, _ := sel.Evaluate(source(sel, item))
+		var prev any
+		prevIdx := -1
+		for i, elem := range elems {
+			vals, _ := bySel.Evaluate(elem)
+			if len(vals) == 0 {
+				continue
+			}
+			cur := vals[0]
+			if prevIdx >= 0 && compareOrdered(prev, cur, caseSensitive) > 0 {
+				errs = append(errs, Error{
+					ConstraintID:   constraintID,
+					ConstraintType: "ordered",
+					TypeName:       typeName,
+					FilePath:       item.FilePath,
+					Message: fmt.Sprintf("element %d of %s is out of order by %s: %q sorts before %q at element %d",
+						i, cd.Key, by, normalizeKey(cur, true), normalizeKey(prev, true), prevIdx),
+					RowIndex: item.RowIndex,
+				})
+				break
+			}
+			prev, prevIdx = cur, i
+		}
+	}
+
+	return errs
+}
+
+// compareOrdered compares two values for the "ordered" constraint. Two numbers
+// compare numerically; anything else compares by its normalized string form.
+func compareOrdered(a, b any, caseSensitive bool) int {
+	if ai, ok := a.(int); ok {
+		if bi, ok := b.(int); ok {
+			return cmp.Compare(ai, bi) // exact for integers beyond float64 precision
+		}
+	}
+	af, aNum := toFloat(a)
+	bf, bNum := toFloat(b)
+	if aNum && bNum {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(normalizeKey(a, caseSensitive), normalizeKey(b, caseSensitive))
+}
+
+// toFloat converts the numeric types produced by the JSON, YAML, and CSV
+// parsers to float64.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// evalSequence checks the "sequence" constraint: with the items sorted by file
+// path (and row), the integer values at key must start at start, when set,
+// and each be step greater than the previous one. Only the first gap,
+// duplicate, or out-of-order value is reported. Missing or null values are
+// skipped.
+func evalSequence(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "sequence",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+	step := cd.Step
+	if step <= 0 {
+		step = 1
+	}
+
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b Item) int {
+		return cmp.Or(strings.Compare(a.FilePath, b.FilePath), cmp.Compare(a.RowIndex, b.RowIndex))
+	})
+
+	var prev Item
+	prevVal, seen := 0, false
+	for _, item := range sorted {
+		vals, _ := sel.Evaluate(source(sel, item))
+		if len(vals) == 0 || vals[0] == nil {
+			continue
+		}
+		fail := func(msg string) []Error {
+			return []Error{{
+				ConstraintID:   constraintID,
+				ConstraintType: "sequence",
+				TypeName:       typeName,
+				FilePath:       item.FilePath,
+				Message:        msg,
+				RowIndex:       item.RowIndex,
+			}}
+		}
+		v, ok := sequenceValue(vals[0])
+		if !ok {
+			return fail(fmt.Sprintf("value %q of %s is not an integer", normalizeKey(vals[0], true), cd.Key))
+		}
+		switch {
+		case !seen && cd.Start != nil && v != *cd.Start:
+			return fail(fmt.Sprintf("sequence for key %s starts at %d, expected %d", cd.Key, v, *cd.Start))
+		case seen && v == prevVal:
+			return fail(fmt.Sprintf("duplicate sequence value %d for key %s (first at %s)", v, cd.Key, describeItem(prev)))
+		case seen && v != prevVal+step:
+			return fail(fmt.Sprintf("sequence gap for key %s: %d follows %d at %s, expected %d", cd.Key, v, prevVal, describeItem(prev), prevVal+step))
+		}
+		prev, prevVal, seen = item, v, true
+	}
+	return nil
+}
+
+// sequenceValue returns v as an integer for the "sequence" constraint. Strings
+// holding an integer, such as path captures or CSV cells, are accepted.
+func sequenceValue(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	f, ok := toFloat(v)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// describeItem names an item in a message: its file path, plus the row for
+// CSV items.
+func describeItem(item Item) string {
+	if item.RowIndex >= 0 {
+		return fmt.Sprintf("%s (row %d)", item.FilePath, item.RowIndex)
+	}
+	return item.FilePath
+}
+
+// evalAllEqual checks the "all_equal" constraint: every item of the type must
+// have the same value at key. When more than one distinct value is found,
+// every item with a value is reported, naming all the values in the order
+// they were first seen. Missing or null values are skipped.
+func evalAllEqual(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "all_equal",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	caseSensitive := cd.IsCaseSensitive()
+	type valued struct {
+		item  Item
+		value string
+	}
+	var withValue []valued
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		vals, _ := sel.Evaluate(source(sel, item))
+		if len(vals) == 0 || vals[0] == nil {
+			continue
+		}
+		v := fmt.Sprint(vals[0])
+		withValue = append(withValue, valued{item: item, value: v})
+		if key := normalizeKey(v, caseSensitive); !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, strconv.Quote(v))
+		}
+	}
+	if len(distinct) < 2 {
+		return nil
+	}
+
+	found := strings.Join(distinct, ", ")
+	var errs []Error
+	for _, w := range withValue {
+		errs = append(errs, Error{
+			ConstraintID:   constraintID,
+			ConstraintType: "all_equal",
+			TypeName:       typeName,
+			FilePath:       w.item.FilePath,
+			Message:        fmt.Sprintf("key %s must be equal across items: found %s; this item has %q", cd.Key, found, w.value),
+			RowIndex:       w.item.RowIndex,
+		})
+	}
+	return errs
+}
+
 // evalPathEqualsAttr checks the "path_equals_attr" constraint.
 func evalPathEqualsAttr(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
 	if cd.References == nil {
@@ -297,7 +1028,7 @@ func evalPathEqualsAttr(typeName, constraintID string, cd config.ConstraintDef,
 			continue
 		}
 
-		vals, _ := attrSel.Evaluate(item.Data)
+		vals, _ := attrSel.Evaluate(source(attrSel, item))
 		if len(vals) == 0 {
 			errs = append(errs, Error{
 				ConstraintID:   constraintID,
@@ -321,19 +1052,26 @@ func evalPathEqualsAttr(typeName, constraintID string, cd config.ConstraintDef,
 			continue
 		}
 
-		attrVal := normalizeKey(vals[0], caseSensitive)
-		pv := pathVal
-		if !caseSensitive {
-			pv = strings.ToLower(pv)
+		var match bool
+		if cd.IsNumericCompare() {
+			pn, pok := parseNumber(pathVal)
+			an, aok := parseNumber(vals[0])
+			match = pok && aok && pn == an
+		} else {
+			pv := pathVal
+			if !caseSensitive {
+				pv = strings.ToLower(pv)
+			}
+			match = pv == normalizeKey(vals[0], caseSensitive)
 		}
 
-		if pv != attrVal {
+		if !match {
 			errs = append(errs, Error{
 				ConstraintID:   constraintID,
 				ConstraintType: "path_equals_attr",
 				TypeName:       typeName,
 				FilePath:       item.FilePath,
-				Message:        fmt.Sprintf("path value %q does not match attribute value %q", pathVal, vals[0]),
+				Message:        fmt.Sprintf("path value %q does not match attribute value %q", pathVal, fmt.Sprint(vals[0])),
 				RowIndex:       item.RowIndex,
 			})
 		}
@@ -342,9 +1080,32 @@ func evalPathEqualsAttr(typeName, constraintID string, cd config.ConstraintDef,
 	return errs
 }
 
+// parseNumber interprets v as a number: JSON numbers as-is and strings such as
+// "01" or "1.5" parsed as float64. Any other value is not numeric.
+func parseNumber(v any) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return toFloat(v)
+}
+
+// source returns what sel is evaluated against for item: the item data, or
+// for a $path.<name> selector the item's path captures, keyed as path.<name>.
+func source(sel *selector.Selector, item Item) any {
+	if _, ok := sel.PathCapture(); !ok {
+		return item.Data
+	}
+	captures := make(map[string]any, len(item.PathCaptures))
+	for k, v := range item.PathCaptures {
+		captures[k] = v
+	}
+	return captures
+}
+
 // resolvePathSelector extracts the value from path captures for the given path_selector.
 func resolvePathSelector(pathSelector string, captures map[string]string) (string, bool) {
-	// Built-in selectors: path.file, path.parent, path.ext
+	// Built-in selectors: path.file, path.parent, path.grandparent, path.dir, path.depth, path.ext
 	// Custom captures: path.<capture_name> maps to captures[<capture_name>]
 	// All are stored in captures with the full key (e.g., "path.file")
 	v, ok := captures[pathSelector]
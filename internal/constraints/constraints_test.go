@@ -1,6 +1,10 @@
 package constraints
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
@@ -94,6 +98,48 @@ func TestUnique_ScalarTypeScope_CaseSensitive(t *testing.T) {
 	}
 }
 
+func TestUnique_WholeItem_IdenticalObjects(t *testing.T) {
+	items := map[string][]Item{
+		"user": {
+			{TypeName: "user", FilePath: "a.json", Data: map[string]any{"id": "1", "tags": []any{"x"}, "meta": map[string]any{"a": 1, "b": 2}}, RowIndex: -1},
+			// same fields, keys in another order, and 2.0 decoded as a float
+			{TypeName: "user", FilePath: "b.yaml", Data: map[string]any{"meta": map[string]any{"b": 2.0, "a": 1}, "tags": []any{"x"}, "id": "1"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name:        "user",
+		Constraints: []config.ConstraintDef{{ID: "no-dup", Type: "unique", Key: "$", Scope: "type"}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 2 {
+		t.Fatalf("expected both identical items reported, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "a.json" || errs[1].FilePath != "b.yaml" {
+		t.Errorf("unexpected files: %v", errs)
+	}
+	if want := "duplicate item: identical in every field to 1 other item(s)"; errs[0].Message != want {
+		t.Errorf("message = %q, want %q", errs[0].Message, want)
+	}
+}
+
+func TestUnique_WholeItem_NearDuplicates(t *testing.T) {
+	items := map[string][]Item{
+		"user": {
+			{TypeName: "user", FilePath: "a.json", Data: map[string]any{"id": "1", "n": 1}, RowIndex: -1},
+			{TypeName: "user", FilePath: "b.json", Data: map[string]any{"id": "1", "n": "1"}, RowIndex: -1},
+			{TypeName: "user", FilePath: "c.json", Data: map[string]any{"id": "1", "n": 1, "extra": nil}, RowIndex: -1},
+			{TypeName: "user", FilePath: "d.json", Data: map[string]any{"id": "1", "n": 2}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name:        "user",
+		Constraints: []config.ConstraintDef{{ID: "no-dup", Type: "unique", Key: "$", Scope: "type"}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected near-duplicates to pass, got %d: %v", len(errs), errs)
+	}
+}
+
 func TestUnique_MultiValue_ItemScope(t *testing.T) {
 	items := map[string][]Item{
 		"config": {
@@ -121,6 +167,40 @@ func TestUnique_MultiValue_ItemScope(t *testing.T) {
 	}
 }
 
+func TestUnique_ItemScope_ReportsDuplicatePosition(t *testing.T) {
+	items := map[string][]Item{
+		"team": {
+			{TypeName: "team", FilePath: "t.json", Data: map[string]any{
+				"members": []any{
+					map[string]any{"id": "m1"},
+					map[string]any{"id": "m2"},
+					map[string]any{"id": "m1"},
+					map[string]any{"id": "m1"},
+				},
+			}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "team",
+		Constraints: []config.ConstraintDef{{
+			ID: "unique-member", Type: "unique", Key: "$.members[*].id", Scope: "item",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	want := []string{
+		`duplicate value "m1" for key $.members[*].id within item at $.members[2].id (first at $.members[0].id)`,
+		`duplicate value "m1" for key $.members[*].id within item at $.members[3].id (first at $.members[0].id)`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, w := range want {
+		if errs[i].Message != w {
+			t.Errorf("error %d message = %q, want %q", i, errs[i].Message, w)
+		}
+	}
+}
+
 func TestUnique_ItemScope_Explicit(t *testing.T) {
 	items := map[string][]Item{
 		"config": {
@@ -141,6 +221,37 @@ func TestUnique_ItemScope_Explicit(t *testing.T) {
 	}
 }
 
+func TestUnique_PathCaptureKey(t *testing.T) {
+	item := func(path, region string) Item {
+		return Item{TypeName: "site", FilePath: path, Data: map[string]any{"region": "ignored"},
+			PathCaptures: map[string]string{"path.region": region}, RowIndex: -1}
+	}
+	items := map[string][]Item{
+		"site": {item("us/a.json", "us"), item("eu/b.json", "eu"), item("us/c.json", "us")},
+	}
+	defs := []config.TypeDef{{
+		Name: "site",
+		Constraints: []config.ConstraintDef{{
+			ID: "one-per-region", Type: "unique", Key: "$path.region", Scope: "type",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	for _, e := range errs {
+		if e.Message != `duplicate value "us" for key $path.region` {
+			t.Errorf("unexpected message: %q", e.Message)
+		}
+	}
+
+	// a data field of the same name is not read
+	defs[0].Constraints[0].Key = "$.region"
+	if errs := Evaluate(items, defs); len(errs) != 3 {
+		t.Fatalf("expected 3 errors for the data key, got %d: %v", len(errs), errs)
+	}
+}
+
 func TestUnique_InvalidSelector(t *testing.T) {
 	items := map[string][]Item{
 		"user": {{TypeName: "user", FilePath: "a.json", Data: map[string]any{"id": "1"}, RowIndex: -1}},
@@ -237,6 +348,203 @@ func TestForeignKey_Missing(t *testing.T) {
 	}
 }
 
+func TestForeignKey_Suggest(t *testing.T) {
+	statuses := func(ids ...string) []Item {
+		var items []Item
+		for _, id := range ids {
+			items = append(items, Item{TypeName: "status", FilePath: "statuses.csv", Data: map[string]any{"id": id}, RowIndex: len(items)})
+		}
+		return items
+	}
+	defs := []config.TypeDef{{
+		Name: "ticket",
+		Constraints: []config.ConstraintDef{{
+			ID: "status", Type: "foreign_key", Key: "$.status",
+			References: &config.ReferenceDef{Type: "status", Key: "$.id", Suggest: true},
+		}},
+	}}
+	tickets := []Item{{TypeName: "ticket", FilePath: "t1.json", Data: map[string]any{"status": "opne"}, RowIndex: -1}}
+
+	tests := []struct {
+		name     string
+		statuses []Item
+		want     string
+	}{
+		{"small set", statuses("open", "closed", "blocked"),
+			`foreign key "opne" not found in status.$.id; valid values: "blocked", "closed", "open"`},
+		{"large set", statuses("s00", "s01", "s02", "s03", "s04", "s05", "s06", "s07", "s08", "s09", "s10", "s11"),
+			`foreign key "opne" not found in status.$.id; valid values include: "s00", "s01", "s02", "s03", "s04", "s05", "s06", "s07", "s08", "s09" (and 2 more)`},
+		{"empty set", nil,
+			`foreign key "opne" not found in status.$.id; no valid values exist`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Evaluate(map[string][]Item{"ticket": tickets, "status": tt.statuses}, defs)
+			if len(errs) != 1 || errs[0].Message != tt.want {
+				t.Fatalf("expected %q, got %v", tt.want, errs)
+			}
+		})
+	}
+}
+
+func TestForeignKey_UniqueTarget(t *testing.T) {
+	items := map[string][]Item{
+		"order": {
+			{TypeName: "order", FilePath: "o1.json", Data: map[string]any{"user_id": "u1"}, RowIndex: -1},
+			{TypeName: "order", FilePath: "o2.json", Data: map[string]any{"user_id": "u2"}, RowIndex: -1},
+			{TypeName: "order", FilePath: "o3.json", Data: map[string]any{"user_id": "u3"}, RowIndex: -1},
+		},
+		"user": {
+			{TypeName: "user", FilePath: "u1.json", Data: map[string]any{"id": "u1"}, RowIndex: -1},
+			{TypeName: "user", FilePath: "u2a.json", Data: map[string]any{"id": "u2"}, RowIndex: -1},
+			{TypeName: "user", FilePath: "u2b.json", Data: map[string]any{"id": "u2"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "order",
+		Constraints: []config.ConstraintDef{{
+			ID: "fk-user", Type: "foreign_key", Key: "$.user_id",
+			References: &config.ReferenceDef{Type: "user", Key: "$.id", Unique: true},
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	want := map[string]string{
+		"o2.json": `foreign key "u2" matches 2 items in user.$.id; expected exactly one`,
+		"o3.json": `foreign key "u3" not found in user.$.id`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for _, e := range errs {
+		if want[e.FilePath] != e.Message {
+			t.Errorf("%s: message = %q, want %q", e.FilePath, e.Message, want[e.FilePath])
+		}
+	}
+
+	// without unique, a duplicated target still satisfies the key
+	defs[0].Constraints[0].References.Unique = false
+	if errs := Evaluate(items, defs); len(errs) != 1 || errs[0].FilePath != "o3.json" {
+		t.Fatalf("expected only the missing key without unique, got %v", errs)
+	}
+}
+
+func TestForeignKey_UniqueTargetClean(t *testing.T) {
+	items := map[string][]Item{
+		"order": {
+			{TypeName: "order", FilePath: "o1.json", Data: map[string]any{"user_id": "u1"}, RowIndex: -1},
+			{TypeName: "order", FilePath: "o2.json", Data: map[string]any{"user_id": "u1"}, RowIndex: -1},
+		},
+		"user": {
+			{TypeName: "user", FilePath: "u1.json", Data: map[string]any{"id": "u1"}, RowIndex: -1},
+			{TypeName: "user", FilePath: "u2.json", Data: map[string]any{"id": "u2"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "order",
+		Constraints: []config.ConstraintDef{{
+			Type: "foreign_key", Key: "$.user_id",
+			References: &config.ReferenceDef{Type: "user", Key: "$.id", Unique: true},
+		}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %v", errs)
+	}
+}
+
+func TestForeignKey_EmptyKey(t *testing.T) {
+	items := map[string][]Item{
+		"order": {
+			{TypeName: "order", FilePath: "o1.json", Data: map[string]any{"user_id": ""}, RowIndex: -1},
+			{TypeName: "order", FilePath: "o2.json", Data: map[string]any{"user_id": nil}, RowIndex: -1},
+			{TypeName: "order", FilePath: "o3.json", Data: map[string]any{"user_id": "u9"}, RowIndex: -1},
+		},
+		"user": {
+			{TypeName: "user", FilePath: "u1.json", Data: map[string]any{"id": "u1"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "order",
+		Constraints: []config.ConstraintDef{{
+			Type: "foreign_key", Key: "$.user_id",
+			References: &config.ReferenceDef{Type: "user", Key: "$.id"},
+		}},
+	}}
+
+	// by default an empty string must resolve like any other key
+	errs := Evaluate(items, defs)
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "o1.json" || errs[0].Message != `foreign key "" not found in user.$.id` {
+		t.Errorf("unexpected error for empty key: %v", errs[0])
+	}
+
+	defs[0].Constraints[0].References.Optional = true
+	errs = Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error with optional, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "o3.json" {
+		t.Errorf("expected only the unknown key to be reported, got %v", errs[0])
+	}
+}
+
+func TestForeignKey_ReferencesPathSelector(t *testing.T) {
+	items := map[string][]Item{
+		"service": {
+			{TypeName: "service", FilePath: "teams/core/services/api.yaml", Data: map[string]any{"team": "core"}, RowIndex: -1},
+			{TypeName: "service", FilePath: "teams/web/services/ui.yaml", Data: map[string]any{"team": "ghost"}, RowIndex: -1},
+		},
+		"team": {
+			{TypeName: "team", FilePath: "teams/core/team.yaml", Data: map[string]any{"name": "Core"}, RowIndex: -1,
+				PathCaptures: map[string]string{"path.team": "core"}},
+			{TypeName: "team", FilePath: "teams/web/team.yaml", Data: map[string]any{"name": "Web"}, RowIndex: -1,
+				PathCaptures: map[string]string{"path.team": "web"}},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "service",
+		Constraints: []config.ConstraintDef{{
+			ID: "fk-team", Type: "foreign_key", Key: "$.team",
+			References: &config.ReferenceDef{Type: "team", PathSelector: "path.team"},
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "teams/web/services/ui.yaml" {
+		t.Errorf("expected error for ui.yaml, got %s", errs[0].FilePath)
+	}
+	if !strings.Contains(errs[0].Message, `"ghost" not found in team.path.team`) {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestForeignKey_PathCaptureKey(t *testing.T) {
+	items := map[string][]Item{
+		"service": {
+			{TypeName: "service", FilePath: "teams/alpha/api.json", Data: map[string]any{},
+				PathCaptures: map[string]string{"path.team": "alpha"}, RowIndex: -1},
+			{TypeName: "service", FilePath: "teams/gamma/web.json", Data: map[string]any{},
+				PathCaptures: map[string]string{"path.team": "gamma"}, RowIndex: -1},
+		},
+		"team": {
+			{TypeName: "team", FilePath: "teams.json", Data: map[string]any{"id": "alpha"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "service",
+		Constraints: []config.ConstraintDef{{
+			Type: "foreign_key", Key: "$path.team", References: &config.ReferenceDef{Type: "team", Key: "$.id"},
+		}},
+	}, {Name: "team"}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 || errs[0].FilePath != "teams/gamma/web.json" {
+		t.Fatalf("expected 1 error for teams/gamma/web.json, got %v", errs)
+	}
+}
+
 func TestForeignKey_MultipleValuesError(t *testing.T) {
 	items := map[string][]Item{
 		"order": {
@@ -302,6 +610,74 @@ func TestForeignKey_EmptyRefType(t *testing.T) {
 	}
 }
 
+// --- count_equals constraint tests ---
+
+func countEqualsItems(licenses, seats int) map[string][]Item {
+	items := map[string][]Item{}
+	for i := range licenses {
+		items["license"] = append(items["license"], Item{TypeName: "license", FilePath: fmt.Sprintf("l%d.json", i), Data: map[string]any{}, RowIndex: -1})
+	}
+	for i := range seats {
+		items["seat"] = append(items["seat"], Item{TypeName: "seat", FilePath: fmt.Sprintf("s%d.json", i), Data: map[string]any{}, RowIndex: -1})
+	}
+	return items
+}
+
+func TestCountEquals_Equal(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "license",
+		Constraints: []config.ConstraintDef{{
+			ID: "per-seat", Type: "count_equals",
+			References: &config.ReferenceDef{Type: "seat"},
+		}},
+	}}
+	errs := Evaluate(countEqualsItems(3, 3), defs)
+	if len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestCountEquals_Unequal(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "license",
+		Constraints: []config.ConstraintDef{{
+			ID: "per-seat", Type: "count_equals",
+			References: &config.ReferenceDef{Type: "seat"},
+		}},
+	}}
+	errs := Evaluate(countEqualsItems(3, 2), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	e := errs[0]
+	if e.ConstraintType != "count_equals" || e.FilePath != "" || e.RowIndex != -1 {
+		t.Errorf("unexpected error fields: %+v", e)
+	}
+	if e.Message != "license has 3 items but seat has 2" {
+		t.Errorf("unexpected message: %s", e.Message)
+	}
+}
+
+func TestCountEquals_Delta(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "license",
+		Constraints: []config.ConstraintDef{{
+			ID: "per-seat", Type: "count_equals", Delta: 1,
+			References: &config.ReferenceDef{Type: "seat"},
+		}},
+	}}
+	if errs := Evaluate(countEqualsItems(2, 3), defs); len(errs) != 0 {
+		t.Fatalf("expected counts within delta to pass, got: %v", errs)
+	}
+	errs := Evaluate(countEqualsItems(1, 3), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if want := "license has 1 items but seat has 3; counts may differ by at most 1"; errs[0].Message != want {
+		t.Errorf("message = %q, want %q", errs[0].Message, want)
+	}
+}
+
 // --- path_equals_attr constraint tests ---
 
 func TestPathEqualsAttr_Match(t *testing.T) {
@@ -768,3 +1144,793 @@ func TestPathEqualsAttr_ExtSelector(t *testing.T) {
 		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
 	}
 }
+
+// --- contains constraint tests ---
+
+func TestContains_Present(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"tags": []any{"active", "beta"}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "has-active", Type: "contains", Key: "$.tags[*]", Value: "active",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestContains_Absent(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"tags": []any{"active"}}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "b.json", Data: map[string]any{"tags": []any{"beta"}}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "c.json", Data: map[string]any{}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "has-active", Type: "contains", Key: "$.tags[*]", Value: "active",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "b.json" || errs[1].FilePath != "c.json" {
+		t.Errorf("expected errors for b.json and c.json, got %s and %s", errs[0].FilePath, errs[1].FilePath)
+	}
+	if errs[0].Message != `required value "active" not found in $.tags[*]` {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestContains_ValuesAllOf(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"tags": []any{"active"}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "tags", Type: "contains", Key: "$.tags[*]", Values: []string{"active", "owned"},
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestContains_CaseInsensitive(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"tags": []any{"Active"}}, RowIndex: -1},
+		},
+	}
+	sensitive := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "has-active", Type: "contains", Key: "$.tags[*]", Value: "active",
+		}},
+	}}
+	if errs := Evaluate(items, sensitive); len(errs) != 1 {
+		t.Fatalf("expected 1 error (case-sensitive), got %d: %v", len(errs), errs)
+	}
+
+	insensitive := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "has-active", Type: "contains", Key: "$.tags[*]", Value: "active",
+			CaseSensitive: new(false),
+		}},
+	}}
+	if errs := Evaluate(items, insensitive); len(errs) != 0 {
+		t.Fatalf("expected 0 errors (case-insensitive), got %d: %v", len(errs), errs)
+	}
+}
+
+// --- require_path tests ---
+
+func TestRequirePath_IntermediateMissing(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"meta": map[string]any{"id": "1"}}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "b.json", Data: map[string]any{"meta": map[string]any{}}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "c.json", Data: map[string]any{"name": "c"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "unique-meta", Type: "unique", Key: "$.meta.id", Scope: "type", RequirePath: true,
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error (only the missing intermediate object), got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "c.json" {
+		t.Errorf("expected error for c.json, got %s", errs[0].FilePath)
+	}
+	if errs[0].Message != `required path $.meta.id not found: intermediate field "meta" is missing` {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestRequirePath_DisabledByDefault(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "c.json", Data: map[string]any{"name": "c"}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "d.json", Data: map[string]any{"name": "d", "meta": map[string]any{"id": "d"}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "unique-meta", Type: "unique", Key: "$.meta.id", Scope: "type",
+		}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestEvaluate_DeadSelectorWarning(t *testing.T) {
+	items := map[string][]Item{
+		"user": {
+			{TypeName: "user", FilePath: "a.json", Data: map[string]any{"email": "a@example.com"}, RowIndex: -1},
+			{TypeName: "user", FilePath: "b.json", Data: map[string]any{"email": "a@example.com"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{ID: "typo", Type: "unique", Key: "$.emial", Scope: "type"},
+			{ID: "real", Type: "unique", Key: "$.email", Scope: "type"},
+			{ID: "never", Type: "forbidden", Key: "$.password"},
+		},
+	}}
+	errs := Evaluate(items, defs)
+
+	var dead []Error
+	for _, e := range errs {
+		if e.ConstraintID == "typo" {
+			dead = append(dead, e)
+		}
+	}
+	if len(dead) != 1 {
+		t.Fatalf("expected 1 warning for the typo'd selector, got %v", errs)
+	}
+	want := "selector $.emial did not resolve to a value in any of 2 item(s); the constraint checks nothing"
+	if dead[0].Severity != "warning" || dead[0].Message != want || dead[0].FilePath != "" {
+		t.Errorf("unexpected dead-constraint warning: %+v", dead[0])
+	}
+	// the working constraint still reports its duplicates, and forbidden
+	// keys are expected to be absent
+	if len(errs) != 3 {
+		t.Errorf("expected the warning and 2 duplicate errors, got %v", errs)
+	}
+
+	// a type without items has nothing to resolve against
+	if errs := Evaluate(map[string][]Item{}, defs); len(errs) != 0 {
+		t.Errorf("expected no warnings without items, got %v", errs)
+	}
+
+	// some items of a type say nothing about the others
+	if errs := EvaluatePartial(".", items, defs, map[string]bool{"user": true}); len(errs) != 2 {
+		t.Errorf("expected only the 2 duplicate errors for a partly loaded type, got %v", errs)
+	}
+
+	// every selector of keys is checked
+	defs[0].Constraints = []config.ConstraintDef{{ID: "pair", Type: "unique", Keys: []string{"$.email", "$.phnoe"}}}
+	var warnings []string
+	for _, e := range Evaluate(items, defs) {
+		if e.Severity == "warning" {
+			warnings = append(warnings, e.Message)
+		}
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "selector $.phnoe did not resolve") {
+		t.Errorf("expected a warning for the unresolved keys entry only, got %v", warnings)
+	}
+}
+
+// --- ordered constraint tests ---
+
+func TestOrdered_Sorted(t *testing.T) {
+	items := map[string][]Item{
+		"team": {
+			{TypeName: "team", FilePath: "a.json", Data: map[string]any{"members": []any{
+				map[string]any{"name": "alice"},
+				map[string]any{"name": "bob"},
+				map[string]any{"name": "bob"},
+				map[string]any{"name": "carol"},
+			}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "team",
+		Constraints: []config.ConstraintDef{{
+			ID: "members-sorted", Type: "ordered", Key: "$.members[*]", By: "$.name",
+		}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestOrdered_Unsorted(t *testing.T) {
+	items := map[string][]Item{
+		"team": {
+			{TypeName: "team", FilePath: "a.json", Data: map[string]any{"members": []any{
+				map[string]any{"name": "alice"},
+				map[string]any{"name": "carol"},
+				map[string]any{"name": "bob"},
+				map[string]any{"name": "aaron"},
+			}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "team",
+		Constraints: []config.ConstraintDef{{
+			ID: "members-sorted", Type: "ordered", Key: "$.members[*]", By: "$.name",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error (first out-of-order element only), got %d: %v", len(errs), errs)
+	}
+	want := `element 2 of $.members[*] is out of order by $.name: "bob" sorts before "carol" at element 1`
+	if errs[0].Message != want {
+		t.Errorf("unexpected message:\n got: %s\nwant: %s", errs[0].Message, want)
+	}
+}
+
+func TestOrdered_NumericAndScalarElements(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"ports": []any{float64(9), float64(10), float64(80)}}, RowIndex: -1},
+			{TypeName: "doc", FilePath: "b.json", Data: map[string]any{"ports": []any{float64(80), float64(9)}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "doc",
+		Constraints: []config.ConstraintDef{{
+			ID: "ports-sorted", Type: "ordered", Key: "$.ports[*]",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "b.json" {
+		t.Errorf("expected error for b.json, got %s", errs[0].FilePath)
+	}
+}
+
+func TestOrdered_LargeIntegersCompareExactly(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			// both ids round to the same float64
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"ids": []any{1234567890123456789, 1234567890123456788}}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name:        "doc",
+		Constraints: []config.ConstraintDef{{Type: "ordered", Key: "$.ids[*]"}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestOrdered_CaseInsensitive(t *testing.T) {
+	items := map[string][]Item{
+		"doc": {
+			{TypeName: "doc", FilePath: "a.json", Data: map[string]any{"tags": []any{"alpha", "Beta", "gamma"}}, RowIndex: -1},
+		},
+	}
+	sensitive := []config.TypeDef{{
+		Name:        "doc",
+		Constraints: []config.ConstraintDef{{ID: "tags", Type: "ordered", Key: "$.tags[*]"}},
+	}}
+	if errs := Evaluate(items, sensitive); len(errs) != 1 {
+		t.Fatalf("expected 1 error (case-sensitive), got %d: %v", len(errs), errs)
+	}
+
+	insensitive := []config.TypeDef{{
+		Name:        "doc",
+		Constraints: []config.ConstraintDef{{ID: "tags", Type: "ordered", Key: "$.tags[*]", CaseSensitive: new(false)}},
+	}}
+	if errs := Evaluate(items, insensitive); len(errs) != 0 {
+		t.Fatalf("expected 0 errors (case-insensitive), got %d: %v", len(errs), errs)
+	}
+}
+
+// --- mutually_exclusive constraint tests ---
+
+func mutuallyExclusiveItems() map[string][]Item {
+	return map[string][]Item{
+		"contact": {
+			{TypeName: "contact", FilePath: "both.json", Data: map[string]any{"email": "a@example.com", "phone": "555"}, RowIndex: -1},
+			{TypeName: "contact", FilePath: "email.json", Data: map[string]any{"email": "b@example.com"}, RowIndex: -1},
+			{TypeName: "contact", FilePath: "phone.json", Data: map[string]any{"phone": "555", "email": ""}, RowIndex: -1},
+			{TypeName: "contact", FilePath: "none.json", Data: map[string]any{"name": "c", "phone": nil}, RowIndex: -1},
+		},
+	}
+}
+
+func TestMutuallyExclusive_BothSetFails(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "contact",
+		Constraints: []config.ConstraintDef{{
+			ID: "contact-method", Type: "mutually_exclusive", Keys: []string{"$.email", "$.phone"},
+		}},
+	}}
+	errs := Evaluate(mutuallyExclusiveItems(), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "both.json" {
+		t.Errorf("expected error for both.json, got %s", errs[0].FilePath)
+	}
+	if errs[0].Message != "only one of $.email, $.phone may be set, found $.email, $.phone" {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestMutuallyExclusive_RequiredOne(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "contact",
+		Constraints: []config.ConstraintDef{{
+			ID: "contact-method", Type: "mutually_exclusive", Keys: []string{"$.email", "$.phone"}, RequiredOne: true,
+		}},
+	}}
+	errs := Evaluate(mutuallyExclusiveItems(), defs)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "both.json" || errs[1].FilePath != "none.json" {
+		t.Errorf("expected errors for both.json and none.json, got %s and %s", errs[0].FilePath, errs[1].FilePath)
+	}
+	if errs[1].Message != "one of $.email, $.phone must be set" {
+		t.Errorf("unexpected message: %s", errs[1].Message)
+	}
+}
+
+// --- forbidden constraint tests ---
+
+func userItems() map[string][]Item {
+	return map[string][]Item{
+		"user": {
+			{TypeName: "user", FilePath: "alice.json", RowIndex: -1, Data: map[string]any{"username": "alice", "aliases": []any{"ally", "root"}}},
+			{TypeName: "user", FilePath: "admin.json", RowIndex: -1, Data: map[string]any{"username": "Admin", "aliases": []any{}}},
+		},
+	}
+}
+
+func TestEvaluate_Severity(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{ID: "strict", Type: "forbidden", Key: "$.aliases[*]", Values: []string{"root"}},
+			{ID: "advisory", Type: "forbidden", Key: "$.username", Values: []string{"admin"}, CaseSensitive: new(false), Severity: "warning"},
+		},
+	}}
+	got := map[string]string{}
+	for _, e := range Evaluate(userItems(), defs) {
+		got[e.ConstraintID+" "+e.FilePath] = e.Severity
+	}
+	want := map[string]string{
+		"strict alice.json":   "error",
+		"advisory admin.json": "warning",
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("severities = %v, want %v", got, want)
+	}
+}
+
+func TestForbidden_ValuePresentFails(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{Type: "forbidden", Key: "$.aliases[*]", Values: []string{"root", "admin"}},
+		},
+	}}
+	errs := Evaluate(userItems(), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "alice.json" || errs[0].Message != `forbidden value "root" found in $.aliases[*]` {
+		t.Errorf("unexpected error: %s %s", errs[0].FilePath, errs[0].Message)
+	}
+}
+
+func TestForbidden_ValueAbsentPasses(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{Type: "forbidden", Key: "$.username", Values: []string{"admin", "root"}},
+		},
+	}}
+	if errs := Evaluate(userItems(), defs); len(errs) != 0 {
+		t.Fatalf("expected case-sensitive match to pass, got %v", errs)
+	}
+}
+
+func TestForbidden_CaseInsensitive(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{Type: "forbidden", Key: "$.username", Values: []string{"admin"}, CaseSensitive: new(false)},
+		},
+	}}
+	errs := Evaluate(userItems(), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "admin.json" || errs[0].Message != `forbidden value "Admin" found in $.username` {
+		t.Errorf("unexpected error: %s %s", errs[0].FilePath, errs[0].Message)
+	}
+}
+
+// --- internal_reference constraint tests ---
+
+func graphItems() map[string][]Item {
+	nodes := []any{map[string]any{"id": "a"}, map[string]any{"id": "b"}, map[string]any{"id": "c"}}
+	return map[string][]Item{
+		"graph": {
+			{TypeName: "graph", FilePath: "connected.json", RowIndex: -1, Data: map[string]any{
+				"nodes": nodes,
+				"edges": []any{
+					map[string]any{"from": "a", "to": "b"},
+					map[string]any{"from": "b", "to": "c"},
+				},
+			}},
+			{TypeName: "graph", FilePath: "dangling.json", RowIndex: -1, Data: map[string]any{
+				"nodes": nodes,
+				"edges": []any{
+					map[string]any{"from": "a", "to": "z"},
+					map[string]any{"from": "c", "to": nil},
+				},
+			}},
+		},
+	}
+}
+
+func TestInternalReference_DanglingEdge(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name: "graph",
+		Constraints: []config.ConstraintDef{
+			{ID: "edge-from", Type: "internal_reference", Key: "$.edges[*].from", References: &config.ReferenceDef{Key: "$.nodes[*].id"}},
+			{ID: "edge-to", Type: "internal_reference", Key: "$.edges[*].to", References: &config.ReferenceDef{Key: "$.nodes[*].id"}},
+		},
+	}}
+	errs := Evaluate(graphItems(), defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "dangling.json" || errs[0].ConstraintID != "edge-to" {
+		t.Errorf("expected edge-to error for dangling.json, got %s %s", errs[0].ConstraintID, errs[0].FilePath)
+	}
+	if errs[0].Message != `value "z" of $.edges[*].to not found in $.nodes[*].id` {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestInternalReference_TargetsAreScopedToItem(t *testing.T) {
+	items := map[string][]Item{
+		"graph": {
+			{TypeName: "graph", FilePath: "one.json", RowIndex: -1, Data: map[string]any{
+				"nodes": []any{map[string]any{"id": "a"}},
+				"edges": []any{map[string]any{"to": "a"}},
+			}},
+			{TypeName: "graph", FilePath: "two.json", RowIndex: -1, Data: map[string]any{
+				"nodes": []any{map[string]any{"id": "b"}},
+				"edges": []any{map[string]any{"to": "a"}},
+			}},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "graph",
+		Constraints: []config.ConstraintDef{
+			{Type: "internal_reference", Key: "$.edges[*].to", References: &config.ReferenceDef{Key: "$.nodes[*].id"}},
+		},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 || errs[0].FilePath != "two.json" {
+		t.Fatalf("expected 1 error for two.json, got %v", errs)
+	}
+}
+
+func TestPathEqualsAttr_NumericCompare(t *testing.T) {
+	items := map[string][]Item{
+		"release": {
+			{
+				TypeName: "release", FilePath: "releases/01.json",
+				Data:         map[string]any{"seq": float64(1)},
+				PathCaptures: map[string]string{"path.file": "01"},
+				RowIndex:     -1,
+			},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "release",
+		Constraints: []config.ConstraintDef{{
+			ID: "path-seq", Type: "path_equals_attr", PathSelector: "path.file",
+			References: &config.ReferenceDef{Key: "$.seq"},
+			Compare:    "numeric",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 0 {
+		t.Fatalf("expected 0 errors (numeric compare), got %d: %v", len(errs), errs)
+	}
+
+	defs[0].Constraints[0].Compare = ""
+	errs = Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error (string compare), got %d: %v", len(errs), errs)
+	}
+}
+
+func TestPathEqualsAttr_NumericCompareNonNumeric(t *testing.T) {
+	items := map[string][]Item{
+		"release": {
+			{
+				TypeName: "release", FilePath: "releases/a.json",
+				Data:         map[string]any{"seq": float64(1)},
+				PathCaptures: map[string]string{"path.file": "a"},
+				RowIndex:     -1,
+			},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "release",
+		Constraints: []config.ConstraintDef{{
+			ID: "path-seq", Type: "path_equals_attr", PathSelector: "path.file",
+			References: &config.ReferenceDef{Key: "$.seq"},
+			Compare:    "numeric",
+		}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Message, `path value "a" does not match attribute value "1"`) {
+		t.Errorf("unexpected message: %s", errs[0].Message)
+	}
+}
+
+func TestForeignKey_SharedReferenceIndex(t *testing.T) {
+	items := map[string][]Item{
+		"team": {
+			{TypeName: "team", FilePath: "teams/a.json", Data: map[string]any{"id": "a", "code": "A1"}, RowIndex: -1},
+			{TypeName: "team", FilePath: "teams/b.json", Data: map[string]any{"id": "b", "code": "B1"}, RowIndex: -1},
+		},
+		"service": {
+			{TypeName: "service", FilePath: "services/s1.json", Data: map[string]any{"owner": "a", "backup": "b", "code": "A1"}, RowIndex: -1},
+			{TypeName: "service", FilePath: "services/s2.json", Data: map[string]any{"owner": "x", "backup": "b", "code": "b"}, RowIndex: -1},
+		},
+	}
+	ref := &config.ReferenceDef{Type: "team", Key: "$.id"}
+	defs := []config.TypeDef{
+		{Name: "team"},
+		{Name: "service", Constraints: []config.ConstraintDef{
+			{ID: "owner", Type: "foreign_key", Key: "$.owner", References: ref},
+			{ID: "backup", Type: "foreign_key", Key: "$.backup", References: &config.ReferenceDef{Type: "team", Key: "$.id"}},
+			{ID: "code", Type: "foreign_key", Key: "$.code", References: &config.ReferenceDef{Type: "team", Key: "$.code"}},
+		}},
+	}
+
+	errs := Evaluate(items, defs)
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.ConstraintID+" "+e.FilePath+" "+e.Message)
+	}
+	want := []string{
+		`code services/s2.json foreign key "b" not found in team.$.code`,
+		`owner services/s2.json foreign key "x" not found in team.$.id`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unexpected errors:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func BenchmarkEvaluate_ForeignKeysIntoSameType(b *testing.B) {
+	const teams, services, fks = 5000, 200, 20
+
+	items := map[string][]Item{}
+	for i := range teams {
+		items["team"] = append(items["team"], Item{
+			TypeName: "team", FilePath: fmt.Sprintf("teams/%d.json", i),
+			Data: map[string]any{"id": fmt.Sprintf("t%d", i)}, RowIndex: -1,
+		})
+	}
+	var cds []config.ConstraintDef
+	for f := range fks {
+		cds = append(cds, config.ConstraintDef{
+			Type: "foreign_key", Key: fmt.Sprintf("$.ref%d", f),
+			References: &config.ReferenceDef{Type: "team", Key: "$.id"},
+		})
+	}
+	for i := range services {
+		data := map[string]any{}
+		for f := range fks {
+			data[fmt.Sprintf("ref%d", f)] = fmt.Sprintf("t%d", (i+f)%teams)
+		}
+		items["service"] = append(items["service"], Item{
+			TypeName: "service", FilePath: fmt.Sprintf("services/%d.json", i),
+			Data: data, RowIndex: -1,
+		})
+	}
+	defs := []config.TypeDef{{Name: "team"}, {Name: "service", Constraints: cds}}
+
+	for b.Loop() {
+		if errs := Evaluate(items, defs); len(errs) != 0 {
+			b.Fatalf("unexpected errors: %v", errs)
+		}
+	}
+}
+
+// --- sequence constraint tests ---
+
+func migrations(versions ...any) []Item {
+	var items []Item
+	for i, v := range versions {
+		items = append(items, Item{TypeName: "migration", FilePath: fmt.Sprintf("migrations/%03d.yaml", i+1), Data: map[string]any{"version": v}, RowIndex: -1})
+	}
+	return items
+}
+
+func TestSequence_Contiguous(t *testing.T) {
+	typeItems := migrations(1, 2, 3, 4)
+	slices.Reverse(typeItems) // evaluated in file path order regardless of input order
+	items := map[string][]Item{"migration": typeItems}
+	defs := []config.TypeDef{{
+		Name:        "migration",
+		Constraints: []config.ConstraintDef{{ID: "seq", Type: "sequence", Key: "$.version", Start: new(1)}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestSequence_Gap(t *testing.T) {
+	items := map[string][]Item{"migration": migrations(1, 2, 4, 6)}
+	defs := []config.TypeDef{{
+		Name:        "migration",
+		Constraints: []config.ConstraintDef{{ID: "seq", Type: "sequence", Key: "$.version"}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error (first gap only), got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "migrations/003.yaml" {
+		t.Errorf("expected error for migrations/003.yaml, got %s", errs[0].FilePath)
+	}
+	want := "sequence gap for key $.version: 4 follows 2 at migrations/002.yaml, expected 3"
+	if errs[0].Message != want {
+		t.Errorf("unexpected message:\n got: %s\nwant: %s", errs[0].Message, want)
+	}
+}
+
+func TestSequence_Duplicate(t *testing.T) {
+	items := map[string][]Item{"migration": migrations(1, 2, 2, 3)}
+	defs := []config.TypeDef{{
+		Name:        "migration",
+		Constraints: []config.ConstraintDef{{ID: "seq", Type: "sequence", Key: "$.version"}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	want := "duplicate sequence value 2 for key $.version (first at migrations/002.yaml)"
+	if errs[0].FilePath != "migrations/003.yaml" || errs[0].Message != want {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+}
+
+func TestSequence_StartAndStep(t *testing.T) {
+	defs := []config.TypeDef{{
+		Name:        "migration",
+		Constraints: []config.ConstraintDef{{ID: "seq", Type: "sequence", Key: "$.version", Start: new(10), Step: 10}},
+	}}
+	if errs := Evaluate(map[string][]Item{"migration": migrations(10, 20, float64(30))}, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+
+	errs := Evaluate(map[string][]Item{"migration": migrations(0, 10, 20)}, defs)
+	if len(errs) != 1 || errs[0].Message != "sequence for key $.version starts at 0, expected 10" {
+		t.Fatalf("expected start error, got %v", errs)
+	}
+}
+
+func TestSequence_PathCaptureAndNonInteger(t *testing.T) {
+	items := map[string][]Item{
+		"migration": {
+			{TypeName: "migration", FilePath: "migrations/0001_init.sql", PathCaptures: map[string]string{"path.version": "0001"}, RowIndex: -1},
+			{TypeName: "migration", FilePath: "migrations/0002_users.sql", PathCaptures: map[string]string{"path.version": "0002"}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name:        "migration",
+		Constraints: []config.ConstraintDef{{ID: "seq", Type: "sequence", Key: "$path.version"}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+
+	bad := map[string][]Item{"migration": migrations(1, 1.5)}
+	defs[0].Constraints[0].Key = "$.version"
+	errs := Evaluate(bad, defs)
+	if len(errs) != 1 || errs[0].Message != `value "1.5" of $.version is not an integer` {
+		t.Fatalf("expected non-integer error, got %v", errs)
+	}
+}
+
+func schemaVersions(versions ...any) []Item {
+	var items []Item
+	for i, v := range versions {
+		data := map[string]any{}
+		if v != nil {
+			data["schema_version"] = v
+		}
+		items = append(items, Item{TypeName: "service", FilePath: fmt.Sprintf("services/%d.yaml", i), Data: data, RowIndex: -1})
+	}
+	return items
+}
+
+func TestAllEqual_Equal(t *testing.T) {
+	items := map[string][]Item{"service": schemaVersions("v2", "v2", nil, "v2")}
+	defs := []config.TypeDef{{
+		Name:        "service",
+		Constraints: []config.ConstraintDef{{ID: "same", Type: "all_equal", Key: "$.schema_version"}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestAllEqual_SingleDivergentFile(t *testing.T) {
+	items := map[string][]Item{"service": schemaVersions("v2", "v2", "v1", nil)}
+	defs := []config.TypeDef{{
+		Name:        "service",
+		Constraints: []config.ConstraintDef{{ID: "same", Type: "all_equal", Key: "$.schema_version"}},
+	}}
+	errs := Evaluate(items, defs)
+	if len(errs) != 3 {
+		t.Fatalf("expected an error on each of the 3 items with a value, got %d: %v", len(errs), errs)
+	}
+	want := `key $.schema_version must be equal across items: found "v2", "v1"; this item has "v1"`
+	if errs[2].FilePath != "services/2.yaml" || errs[2].Message != want {
+		t.Errorf("unexpected error for divergent file: %+v", errs[2])
+	}
+	if !strings.HasSuffix(errs[0].Message, `this item has "v2"`) {
+		t.Errorf("unexpected error: %+v", errs[0])
+	}
+}
+
+func TestAllEqual_CaseInsensitive(t *testing.T) {
+	items := map[string][]Item{"service": schemaVersions("Beta", "beta", "BETA")}
+	defs := []config.TypeDef{{
+		Name:        "service",
+		Constraints: []config.ConstraintDef{{Type: "all_equal", Key: "$.schema_version", CaseSensitive: new(false)}},
+	}}
+	if errs := Evaluate(items, defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %v", errs)
+	}
+
+	defs[0].Constraints[0].CaseSensitive = nil
+	if errs := Evaluate(items, defs); len(errs) != 3 {
+		t.Fatalf("expected 3 errors when case sensitive, got %v", errs)
+	}
+}
@@ -0,0 +1,70 @@
+package constraints
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+)
+
+// evalFileExists checks the "file_exists" constraint: every value resolved by
+// the key selector must be a forward-slash path, relative to base_dir within
+// rootDir, naming a file that exists. Paths that are absolute or climb out of
+// the repository with ".." are reported instead of being looked up. Items where
+// the key resolves to no values are skipped.
+func evalFileExists(typeName, constraintID string, cd config.ConstraintDef, items []Item, rootDir string) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "file_exists",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+
+	var errs []Error
+	for _, item := range items {
+		vals, _ := sel.Evaluate(source(sel, item))
+		for _, v := range vals {
+			if msg := checkFileExists(v, cd, rootDir); msg != "" {
+				errs = append(errs, Error{
+					ConstraintID:   constraintID,
+					ConstraintType: "file_exists",
+					TypeName:       typeName,
+					FilePath:       item.FilePath,
+					Message:        msg,
+					RowIndex:       item.RowIndex,
+				})
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkFileExists returns the violation message for the path value v, or ""
+// when it names an existing file.
+func checkFileExists(v any, cd config.ConstraintDef, rootDir string) string {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return fmt.Sprintf("value %q for key %s is not a file path", fmt.Sprint(v), cd.Key)
+	}
+	rel := path.Join(cd.BaseDir, s)
+	if path.IsAbs(s) || !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return fmt.Sprintf("path %q for key %s is outside the repository", s, cd.Key)
+	}
+	fi, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Sprintf("file %q for key %s does not exist", rel, cd.Key)
+	}
+	if fi.IsDir() {
+		return fmt.Sprintf("path %q for key %s is a directory, not a file", rel, cd.Key)
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package constraints
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func fileExistsRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "assets", "icons"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "assets", "icons", "app.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func fileExistsItems(icons ...any) map[string][]Item {
+	var items []Item
+	for i, icon := range icons {
+		items = append(items, Item{
+			TypeName: "app",
+			FilePath: fmt.Sprintf("apps/%d.yaml", i),
+			Data:     map[string]any{"icon": icon},
+			RowIndex: -1,
+		})
+	}
+	return map[string][]Item{"app": items}
+}
+
+func TestFileExists_Exists(t *testing.T) {
+	root := fileExistsRoot(t)
+	defs := []config.TypeDef{{
+		Name:        "app",
+		Constraints: []config.ConstraintDef{{ID: "icon", Type: "file_exists", Key: "$.icon"}},
+	}}
+	if errs := EvaluateIn(root, fileExistsItems("assets/icons/app.png"), defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %v", errs)
+	}
+
+	defs[0].Constraints[0].BaseDir = "assets"
+	if errs := EvaluateIn(root, fileExistsItems("icons/app.png"), defs); len(errs) != 0 {
+		t.Fatalf("expected 0 errors with base_dir, got %v", errs)
+	}
+}
+
+func TestFileExists_Missing(t *testing.T) {
+	root := fileExistsRoot(t)
+	defs := []config.TypeDef{{
+		Name:        "app",
+		Constraints: []config.ConstraintDef{{ID: "icon", Type: "file_exists", Key: "$.icon", BaseDir: "assets"}},
+	}}
+	errs := EvaluateIn(root, fileExistsItems("icons/app.png", "icons/missing.png", "icons", 7), defs)
+	want := []string{
+		`file "assets/icons/missing.png" for key $.icon does not exist`,
+		`path "assets/icons" for key $.icon is a directory, not a file`,
+		`value "7" for key $.icon is not a file path`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Message != w {
+			t.Errorf("error %d = %q, want %q", i, errs[i].Message, w)
+		}
+		if errs[i].ConstraintType != "file_exists" || errs[i].FilePath == "" {
+			t.Errorf("unexpected error fields: %+v", errs[i])
+		}
+	}
+}
+
+func TestFileExists_PathTraversal(t *testing.T) {
+	root := fileExistsRoot(t)
+	// a file next to the repository root must not be reachable
+	if err := os.WriteFile(filepath.Join(filepath.Dir(root), "secret.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defs := []config.TypeDef{{
+		Name:        "app",
+		Constraints: []config.ConstraintDef{{ID: "icon", Type: "file_exists", Key: "$.icon", BaseDir: "assets"}},
+	}}
+	errs := EvaluateIn(root, fileExistsItems("../../secret.txt", "/etc/hostname", "../assets/icons/app.png"), defs)
+	want := []string{
+		`path "../../secret.txt" for key $.icon is outside the repository`,
+		`path "/etc/hostname" for key $.icon is outside the repository`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i, w := range want {
+		if errs[i].Message != w {
+			t.Errorf("error %d = %q, want %q", i, errs[i].Message, w)
+		}
+	}
+}
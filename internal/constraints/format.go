@@ -0,0 +1,116 @@
+package constraints
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+)
+
+var (
+	uuidRe          = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameLabelRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+)
+
+// formatCheckers maps each format name to a function reporting whether a
+// string is valid in that format.
+var formatCheckers = map[string]func(string) bool{
+	"email":    isEmail,
+	"url":      isURL,
+	"uuid":     uuidRe.MatchString,
+	"ipv4":     isIPv4,
+	"hostname": isHostname,
+}
+
+// IsValidFormat reports whether s is valid in the named format. Unknown formats
+// accept nothing.
+func IsValidFormat(format, s string) bool {
+	check, ok := formatCheckers[format]
+	return ok && check(s)
+}
+
+// isEmail accepts a bare address such as user@example.com, without a display
+// name or angle brackets.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Name == "" && addr.Address == s
+}
+
+// isURL accepts an absolute URL with a scheme and host.
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// isIPv4 accepts a dotted-quad IPv4 address.
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+// isHostname accepts an RFC 1123 host name: dot-separated labels of letters,
+// digits, and inner hyphens, each at most 63 characters, 253 in total.
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(s, ".") {
+		if !hostnameLabelRe.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// evalFormat checks the "format" constraint: every value resolved by the key
+// selector must be a string valid in the named format. Items where the key
+// resolves to no values are skipped.
+func evalFormat(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	sel, err := selector.Parse(cd.Key)
+	if err != nil {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "format",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("invalid selector %q: %v", cd.Key, err),
+			RowIndex:       -1,
+		}}
+	}
+	check, ok := formatCheckers[cd.Format]
+	if !ok {
+		return []Error{{
+			ConstraintID:   constraintID,
+			ConstraintType: "format",
+			TypeName:       typeName,
+			FilePath:       "",
+			Message:        fmt.Sprintf("unknown format %q", cd.Format),
+			RowIndex:       -1,
+		}}
+	}
+
+	var errs []Error
+	for _, item := range items {
+		vals, _ := sel.Evaluate(source(sel, item))
+		for _, v := range vals {
+			if s, ok := v.(string); ok && check(s) {
+				continue
+			}
+			errs = append(errs, Error{
+				ConstraintID:   constraintID,
+				ConstraintType: "format",
+				TypeName:       typeName,
+				FilePath:       item.FilePath,
+				Message:        fmt.Sprintf("value %q for key %s is not a valid %s", fmt.Sprint(v), cd.Key, cd.Format),
+				RowIndex:       item.RowIndex,
+			})
+		}
+	}
+
+	return errs
+}
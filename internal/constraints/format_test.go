@@ -0,0 +1,71 @@
+package constraints
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func TestFormatCheckers(t *testing.T) {
+	tests := []struct {
+		format  string
+		valid   string
+		invalid string
+	}{
+		{"email", "dev@example.com", "Dev <dev@example.com>"},
+		{"url", "https://example.com/path?q=1", "example.com/path"},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-42661417400"},
+		{"ipv4", "192.168.0.1", "256.1.1.1"},
+		{"hostname", "api-1.example.com", "-api.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			check := formatCheckers[tt.format]
+			if !check(tt.valid) {
+				t.Errorf("expected %q to be a valid %s", tt.valid, tt.format)
+			}
+			if check(tt.invalid) {
+				t.Errorf("expected %q to be an invalid %s", tt.invalid, tt.format)
+			}
+		})
+	}
+}
+
+func TestFormatCheckers_CoverKnownFormats(t *testing.T) {
+	for _, f := range config.KnownFormats {
+		if formatCheckers[f] == nil {
+			t.Errorf("no checker for known format %q", f)
+		}
+	}
+	if len(formatCheckers) != len(config.KnownFormats) {
+		t.Errorf("formatCheckers has %d entries, config.KnownFormats has %d", len(formatCheckers), len(config.KnownFormats))
+	}
+}
+
+func TestFormat_MultiValueAndNonString(t *testing.T) {
+	items := map[string][]Item{
+		"user": {
+			{TypeName: "user", FilePath: "users/a.json", Data: map[string]any{"emails": []any{"a@example.com", "nope"}}, RowIndex: -1},
+			{TypeName: "user", FilePath: "users/b.json", Data: map[string]any{"emails": []any{float64(7)}}, RowIndex: -1},
+			{TypeName: "user", FilePath: "users/c.json", Data: map[string]any{}, RowIndex: -1},
+		},
+	}
+	defs := []config.TypeDef{{
+		Name: "user",
+		Constraints: []config.ConstraintDef{
+			{Type: "format", Key: "$.emails[*]", Format: "email"},
+		},
+	}}
+
+	errs := Evaluate(items, defs)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].FilePath != "users/a.json" || !strings.Contains(errs[0].Message, `value "nope" for key $.emails[*] is not a valid email`) {
+		t.Errorf("unexpected first error: %+v", errs[0])
+	}
+	if errs[1].FilePath != "users/b.json" || !strings.Contains(errs[1].Message, `value "7"`) {
+		t.Errorf("unexpected second error: %+v", errs[1])
+	}
+}
@@ -0,0 +1,78 @@
+package constraints
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+)
+
+// evalPathTemplateEqualsAttr checks the "path_template_equals_attr"
+// constraint: the template, with each {path.<name>} placeholder replaced by
+// the item's path capture, must equal the value of references.key.
+func evalPathTemplateEqualsAttr(typeName, constraintID string, cd config.ConstraintDef, items []Item) []Error {
+	fail := func(item Item, msg string) Error {
+		return Error{
+			ConstraintID:   constraintID,
+			ConstraintType: "path_template_equals_attr",
+			TypeName:       typeName,
+			FilePath:       item.FilePath,
+			Message:        msg,
+			RowIndex:       item.RowIndex,
+		}
+	}
+	if cd.References == nil {
+		return []Error{fail(Item{RowIndex: -1}, "missing references definition")}
+	}
+	attrSel, err := selector.Parse(cd.References.Key)
+	if err != nil {
+		return []Error{fail(Item{RowIndex: -1}, fmt.Sprintf("invalid references.key selector %q: %v", cd.References.Key, err))}
+	}
+
+	selectors := config.TemplateSelectors(cd.Template)
+	caseSensitive := cd.IsCaseSensitive()
+
+	var errs []Error
+	for _, item := range items {
+		rendered, missing := renderPathTemplate(cd.Template, selectors, item.PathCaptures)
+		if missing != "" {
+			errs = append(errs, fail(item, fmt.Sprintf("template placeholder {%s} not found in path captures", missing)))
+			continue
+		}
+
+		vals, _ := attrSel.Evaluate(source(attrSel, item))
+		if len(vals) == 0 {
+			errs = append(errs, fail(item, fmt.Sprintf("attribute selector %s resolved to no values", cd.References.Key)))
+			continue
+		}
+		if len(vals) > 1 {
+			errs = append(errs, fail(item, fmt.Sprintf("attribute selector %s resolved to multiple values; expected scalar", cd.References.Key)))
+			continue
+		}
+
+		want := rendered
+		if !caseSensitive {
+			want = strings.ToLower(want)
+		}
+		if want != normalizeKey(vals[0], caseSensitive) {
+			errs = append(errs, fail(item, fmt.Sprintf("template %q renders %q, which does not match attribute value %q", cd.Template, rendered, fmt.Sprint(vals[0]))))
+		}
+	}
+	return errs
+}
+
+// renderPathTemplate replaces each {path.<name>} placeholder of template with
+// its value from captures in a single pass. It returns the first selector
+// without a capture, if any, instead of a partial rendering.
+func renderPathTemplate(template string, selectors []string, captures map[string]string) (string, string) {
+	oldnew := make([]string, 0, 2*len(selectors))
+	for _, ps := range selectors {
+		v, ok := resolvePathSelector(ps, captures)
+		if !ok {
+			return "", ps
+		}
+		oldnew = append(oldnew, "{"+ps+"}", v)
+	}
+	return strings.NewReplacer(oldnew...).Replace(template), ""
+}
@@ -0,0 +1,63 @@
+package constraints
+
+import (
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+)
+
+func pathTemplateItems(id string) map[string][]Item {
+	return map[string][]Item{
+		"service": {{
+			TypeName: "service", FilePath: "payments/api.yaml",
+			Data:         map[string]any{"id": id},
+			PathCaptures: map[string]string{"path.file": "api", "path.parent": "payments", "path.ext": "yaml"},
+			RowIndex:     -1,
+		}},
+	}
+}
+
+func pathTemplateDefs(caseSensitive bool) []config.TypeDef {
+	return []config.TypeDef{{
+		Name: "service",
+		Constraints: []config.ConstraintDef{{
+			ID: "composed-id", Type: "path_template_equals_attr", Template: "{path.parent}-{path.file}",
+			References: &config.ReferenceDef{Key: "$.id"}, CaseSensitive: &caseSensitive,
+		}},
+	}}
+}
+
+func TestPathTemplateEqualsAttr_Match(t *testing.T) {
+	if errs := Evaluate(pathTemplateItems("payments-api"), pathTemplateDefs(true)); len(errs) != 0 {
+		t.Fatalf("expected 0 errors, got %v", errs)
+	}
+}
+
+func TestPathTemplateEqualsAttr_Mismatch(t *testing.T) {
+	errs := Evaluate(pathTemplateItems("payments_api"), pathTemplateDefs(true))
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+	}
+	want := `template "{path.parent}-{path.file}" renders "payments-api", which does not match attribute value "payments_api"`
+	if errs[0].Message != want || errs[0].FilePath != "payments/api.yaml" {
+		t.Errorf("unexpected error: %+v", errs[0])
+	}
+}
+
+func TestPathTemplateEqualsAttr_CaseInsensitive(t *testing.T) {
+	if errs := Evaluate(pathTemplateItems("Payments-API"), pathTemplateDefs(true)); len(errs) != 1 {
+		t.Errorf("expected 1 case-sensitive error, got %v", errs)
+	}
+	if errs := Evaluate(pathTemplateItems("Payments-API"), pathTemplateDefs(false)); len(errs) != 0 {
+		t.Errorf("expected 0 case-insensitive errors, got %v", errs)
+	}
+}
+
+func TestPathTemplateEqualsAttr_MissingCapture(t *testing.T) {
+	defs := pathTemplateDefs(true)
+	defs[0].Constraints[0].Template = "{path.team}-{path.file}"
+	errs := Evaluate(pathTemplateItems("payments-api"), defs)
+	if len(errs) != 1 || errs[0].Message != "template placeholder {path.team} not found in path captures" {
+		t.Fatalf("expected missing capture error, got %v", errs)
+	}
+}
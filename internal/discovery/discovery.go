@@ -1,11 +1,16 @@
 package discovery
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
@@ -19,6 +24,61 @@ type DiscoveredFile struct {
 	PathCaptures map[string]string // Named captures from the include regex
 }
 
+// TypeMatch names a type that matches a file and the include pattern that
+// matched it.
+type TypeMatch struct {
+	Type    string // type name
+	Include int    // index of the pattern in the type's match.include
+	Pattern string // the include pattern
+}
+
+// AmbiguousMatchError reports a file matched by more than one type, with the
+// include pattern of each type that matched it.
+type AmbiguousMatchError struct {
+	Path    string      // repo-relative path of the file
+	Matches []TypeMatch // in config order
+}
+
+// Error implements the error interface.
+func (e *AmbiguousMatchError) Error() string {
+	parts := make([]string, len(e.Matches))
+	for i, m := range e.Matches {
+		parts[i] = fmt.Sprintf("%s (match.include[%d] %q)", m.Type, m.Include, m.Pattern)
+	}
+	return fmt.Sprintf("file %q matches multiple types: %s", e.Path, strings.Join(parts, ", "))
+}
+
+// ExcludedFile is a file that a type's include pattern matches but one of
+// its exclude patterns rules out, since exclusion takes precedence.
+type ExcludedFile struct {
+	Path    string // repo-relative path of the file
+	Type    string // type name
+	Include int    // index of the first matching pattern in the type's match.include
+	Exclude int    // index of the first matching pattern in the type's match.exclude
+	Pattern string // the exclude pattern
+}
+
+// UnreadableError reports a file or directory that could not be read during
+// discovery, such as one without read permission. It does not stop discovery:
+// the entry is skipped and the remaining files are still matched.
+type UnreadableError struct {
+	Path string // repo-relative path of the file or directory
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *UnreadableError) Error() string {
+	err := e.Err
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		err = pe.Err // the path is already reported
+	}
+	return fmt.Sprintf("cannot read %q: %v", e.Path, err)
+}
+
+// Unwrap returns the underlying error.
+func (e *UnreadableError) Unwrap() error { return e.Err }
+
 // hiddenOrIgnored returns true for directories that should be skipped during walk.
 var ignoreDirs = map[string]bool{
 	".git":         true,
@@ -26,36 +86,43 @@ var ignoreDirs = map[string]bool{
 	"__pycache__":  true,
 }
 
+// Options controls optional discovery behavior.
+type Options struct {
+	FollowSymlinks bool     // traverse symlinked directories and files, skipping cycles
+	SkipPaths      []string // additional generated files to skip, such as the combined export
+
+	// Files, when non-nil, is the exact candidate set: each path (relative to
+	// rootDir, or absolute inside it) is matched against the types and the
+	// tree is not walked.
+	Files []string
+
+	// OnExcluded, when non-nil, is called for each file and type whose
+	// include pattern is overridden by an exclude pattern, to diagnose
+	// over-broad excludes.
+	OnExcluded func(ExcludedFile)
+}
+
 // Discover walks the rootDir and matches files against the configured types.
+// With opts.Files set, only the listed files are matched.
 // Returns discovered files and any errors (multi-type match, subdirectory .datacur8, etc.)
-func Discover(rootDir string, types []config.TypeDef) ([]DiscoveredFile, []error) {
+func Discover(rootDir string, types []config.TypeDef, opts Options) ([]DiscoveredFile, []error) {
 	var errs []error
 
-	// Pre-compile include and exclude regexes per type.
+	// Compile include and exclude regexes per type (cached by config).
 	type compiledType struct {
 		def      *config.TypeDef
 		includes []*regexp.Regexp
 		excludes []*regexp.Regexp
+		basename bool // match against the file name instead of the relative path
 	}
 
 	compiled := make([]compiledType, len(types))
 	for i := range types {
-		ct := compiledType{def: &types[i]}
-		for _, pat := range types[i].Match.Include {
-			re, err := regexp.Compile(pat)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("type %q: invalid include pattern %q: %w", types[i].Name, pat, err))
-				continue
-			}
-			ct.includes = append(ct.includes, re)
-		}
-		for _, pat := range types[i].Match.Exclude {
-			re, err := regexp.Compile(pat)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("type %q: invalid exclude pattern %q: %w", types[i].Name, pat, err))
-				continue
-			}
-			ct.excludes = append(ct.excludes, re)
+		ct := compiledType{def: &types[i], basename: types[i].Match.Against == "basename"}
+		var patErrs []*config.PatternError
+		ct.includes, ct.excludes, patErrs = types[i].Match.Compile()
+		for _, pe := range patErrs {
+			errs = append(errs, fmt.Errorf("type %q: invalid %s pattern %q: %w", types[i].Name, pe.Field, pe.Pattern, pe.Err))
 		}
 		compiled[i] = ct
 	}
@@ -64,51 +131,53 @@ func Discover(rootDir string, types []config.TypeDef) ([]DiscoveredFile, []error
 		return nil, errs
 	}
 
-	// Collect output paths so we can skip them during matching.
+	// Collect output paths so we can skip them during matching. Paths are
+	// cleaned so that "./out/items.json" still matches "out/items.json".
 	outputPaths := make(map[string]bool)
 	for i := range types {
 		if types[i].Output != nil && types[i].Output.Path != "" {
-			normalized := filepath.ToSlash(types[i].Output.Path)
-			outputPaths[normalized] = true
+			outputPaths[cleanRelPath(types[i].Output.Path)] = true
 		}
 	}
-
-	var discovered []DiscoveredFile
-
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		name := info.Name()
-
-		if info.IsDir() {
-			// Skip hidden directories and common ignore dirs.
-			if name != "." && (strings.HasPrefix(name, ".") || ignoreDirs[name]) {
-				return filepath.SkipDir
-			}
-			return nil
+	for _, p := range opts.SkipPaths {
+		outputPaths[cleanRelPath(p)] = true
+	}
+	var splitOutputs []config.OutputDef
+	for i := range types {
+		if o := types[i].Output; o != nil && o.MaxLines > 0 {
+			cleaned := *o
+			cleaned.Path = cleanRelPath(o.Path)
+			splitOutputs = append(splitOutputs, cleaned)
 		}
+	}
 
-		// Compute repo-relative path with forward slashes.
-		relPath, relErr := filepath.Rel(rootDir, path)
-		if relErr != nil {
-			return relErr
-		}
-		relPath = filepath.ToSlash(relPath)
+	var discovered []DiscoveredFile
 
-		// Check for .datacur8 files in subdirectories.
+	// visit matches a single file, identified by its repo-relative path and name.
+	visit := func(relPath, name string) {
+		// The config file is never data, whatever the include patterns say;
+		// one in a subdirectory is an error.
 		if name == ".datacur8" {
 			dir := filepath.ToSlash(filepath.Dir(relPath))
 			if dir != "." {
 				errs = append(errs, fmt.Errorf("found .datacur8 in subdirectory %q; only root .datacur8 is allowed", dir))
 			}
-			return nil
+			return
 		}
 
-		// Skip output files.
+		// Skip the validation cache maintained by the CLI.
+		if relPath == ".datacur8.cache" {
+			return
+		}
+
+		// Skip output files, including the parts and index of split outputs.
 		if outputPaths[relPath] {
-			return nil
+			return
+		}
+		for i := range splitOutputs {
+			if splitOutputs[i].IsSplitPath(relPath) {
+				return
+			}
 		}
 
 		// Match against each type.
@@ -116,33 +185,57 @@ func Discover(rootDir string, types []config.TypeDef) ([]DiscoveredFile, []error
 			typeName string
 			typeDef  *config.TypeDef
 			captures map[string]string
+			include  int
 		}
 
 		var matches []matchInfo
 
 		for _, ct := range compiled {
-			captures, matched := matchType(relPath, ct.includes, ct.excludes)
+			subject := relPath
+			if ct.basename {
+				subject = name
+			}
+			captures, include, matched := matchType(subject, ct.includes, ct.excludes)
+			if !matched && opts.OnExcluded != nil {
+				if include, exclude, ok := excludedBy(subject, ct.includes, ct.excludes); ok {
+					opts.OnExcluded(ExcludedFile{
+						Path:    relPath,
+						Type:    ct.def.Name,
+						Include: include,
+						Exclude: exclude,
+						Pattern: ct.def.Match.Exclude[exclude],
+					})
+				}
+			}
 			if matched {
 				// Add built-in path captures.
 				captures["path.file"] = fileNameWithoutExt(name)
 				captures["path.ext"] = normalizeExt(filepath.Ext(name))
 				captures["path.parent"] = parentFolder(relPath)
+				captures["path.grandparent"] = grandparentFolder(relPath)
+				captures["path.dir"] = dirPath(relPath)
+				captures["path.depth"] = strconv.Itoa(pathDepth(relPath))
 
 				matches = append(matches, matchInfo{
 					typeName: ct.def.Name,
 					typeDef:  ct.def,
 					captures: captures,
+					include:  include,
 				})
 			}
 		}
 
 		if len(matches) > 1 {
-			names := make([]string, len(matches))
-			for i, m := range matches {
-				names[i] = m.typeName
+			amb := &AmbiguousMatchError{Path: relPath}
+			for _, m := range matches {
+				amb.Matches = append(amb.Matches, TypeMatch{
+					Type:    m.typeName,
+					Include: m.include,
+					Pattern: m.typeDef.Match.Include[m.include],
+				})
 			}
-			errs = append(errs, fmt.Errorf("file %q matches multiple types: %s", relPath, strings.Join(names, ", ")))
-			return nil
+			errs = append(errs, amb)
+			return
 		}
 
 		if len(matches) == 1 {
@@ -154,9 +247,49 @@ func Discover(rootDir string, types []config.TypeDef) ([]DiscoveredFile, []error
 				PathCaptures: m.captures,
 			})
 		}
+	}
 
-		return nil
-	})
+	var err error
+	if opts.Files != nil {
+		errs = append(errs, visitListed(rootDir, opts.Files, visit)...)
+	} else if opts.FollowSymlinks {
+		err = walkFollowingSymlinks(rootDir, visit, func(relPath string, err error) {
+			errs = append(errs, &UnreadableError{Path: relPath, Err: err})
+		})
+	} else {
+		err = filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				// An unreadable entry below the root is skipped, not fatal.
+				relPath, relErr := filepath.Rel(rootDir, path)
+				if relErr != nil || relPath == "." {
+					return err
+				}
+				errs = append(errs, &UnreadableError{Path: filepath.ToSlash(relPath), Err: err})
+				if info != nil && info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			name := info.Name()
+
+			if info.IsDir() {
+				// Skip hidden directories and common ignore dirs.
+				if name != "." && skipDir(name) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// Compute repo-relative path with forward slashes.
+			relPath, relErr := filepath.Rel(rootDir, path)
+			if relErr != nil {
+				return relErr
+			}
+			visit(filepath.ToSlash(relPath), name)
+			return nil
+		})
+	}
 
 	if err != nil {
 		errs = append(errs, fmt.Errorf("walking directory: %w", err))
@@ -174,18 +307,156 @@ func Discover(rootDir string, types []config.TypeDef) ([]DiscoveredFile, []error
 	return discovered, nil
 }
 
+// visitListed visits each listed file instead of walking rootDir. Paths are
+// cleaned and deduplicated; a file that is missing, a directory, or outside
+// rootDir is an error, and files under hidden or ignored directories are
+// skipped as a walk would skip them.
+func visitListed(rootDir string, files []string, visit func(relPath, name string)) []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, p := range files {
+		rel := p
+		if filepath.IsAbs(p) {
+			r, err := filepath.Rel(rootDir, p)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("listed file %q is outside the root directory", p))
+				continue
+			}
+			rel = r
+		}
+		rel = cleanRelPath(rel)
+		if rel == ".." || strings.HasPrefix(rel, "../") {
+			errs = append(errs, fmt.Errorf("listed file %q is outside the root directory", p))
+			continue
+		}
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		dirs := strings.Split(rel, "/")
+		if slices.ContainsFunc(dirs[:len(dirs)-1], skipDir) {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("listed file %q does not exist", p))
+			} else {
+				errs = append(errs, &UnreadableError{Path: rel, Err: err})
+			}
+			continue
+		}
+		if info.IsDir() {
+			errs = append(errs, fmt.Errorf("listed file %q is a directory", p))
+			continue
+		}
+		visit(rel, path.Base(rel))
+	}
+	return errs
+}
+
+// cleanRelPath normalizes a configured repo-relative path to the form visit
+// receives: forward slashes with no "./" or redundant separators.
+func cleanRelPath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+// skipDir reports whether a directory is hidden or commonly ignored.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || ignoreDirs[name]
+}
+
+// walkFollowingSymlinks walks rootDir like filepath.Walk but resolves
+// symlinked directories and files. Each real directory and file is visited at
+// most once, which prevents cycles and duplicate discovery through multiple
+// links. Dangling or looping symlinks are skipped, and entries that cannot be
+// read below rootDir are passed to unreadable and skipped.
+func walkFollowingSymlinks(rootDir string, visit func(relPath, name string), unreadable func(relPath string, err error)) error {
+	visitedDirs := make(map[string]bool)
+	visitedFiles := make(map[string]bool)
+
+	var walk func(dir, relDir string) error
+	walk = func(dir, relDir string) error {
+		// skip reports an unreadable directory below the root; the root itself
+		// is fatal.
+		skip := func(err error) error {
+			if relDir == "" {
+				return err
+			}
+			unreadable(relDir, err)
+			return nil
+		}
+		realDir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return skip(err)
+		}
+		if visitedDirs[realDir] {
+			return nil
+		}
+		visitedDirs[realDir] = true
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return skip(err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			path := filepath.Join(dir, name)
+			relPath := name
+			if relDir != "" {
+				relPath = relDir + "/" + name
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				if entry.Type()&os.ModeSymlink != 0 {
+					continue // dangling or looping link
+				}
+				unreadable(relPath, err)
+				continue
+			}
+
+			if info.IsDir() {
+				if skipDir(name) {
+					continue
+				}
+				if err := walk(path, relPath); err != nil {
+					return err
+				}
+				continue
+			}
+
+			realFile, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				unreadable(relPath, err)
+				continue
+			}
+			if visitedFiles[realFile] {
+				continue
+			}
+			visitedFiles[realFile] = true
+			visit(relPath, name)
+		}
+		return nil
+	}
+
+	return walk(rootDir, "")
+}
+
 // matchType checks if relPath matches any include pattern and no exclude pattern.
-// Returns named captures from the first matching include pattern.
-func matchType(relPath string, includes, excludes []*regexp.Regexp) (map[string]string, bool) {
+// Returns the named captures and index of the first matching include pattern.
+func matchType(relPath string, includes, excludes []*regexp.Regexp) (map[string]string, int, bool) {
 	// Check excludes first.
 	for _, ex := range excludes {
 		if ex.MatchString(relPath) {
-			return nil, false
+			return nil, -1, false
 		}
 	}
 
 	// Check includes.
-	for _, inc := range includes {
+	for idx, inc := range includes {
 		match := inc.FindStringSubmatch(relPath)
 		if match != nil {
 			captures := make(map[string]string)
@@ -194,11 +465,21 @@ func matchType(relPath string, includes, excludes []*regexp.Regexp) (map[string]
 					captures["path."+name] = match[i]
 				}
 			}
-			return captures, true
+			return captures, idx, true
 		}
 	}
 
-	return nil, false
+	return nil, -1, false
+}
+
+// excludedBy returns the indexes of the first include and the first exclude
+// pattern matching relPath, and whether both exist, meaning the exclude is
+// what keeps relPath from matching.
+func excludedBy(relPath string, includes, excludes []*regexp.Regexp) (int, int, bool) {
+	match := func(re *regexp.Regexp) bool { return re.MatchString(relPath) }
+	include := slices.IndexFunc(includes, match)
+	exclude := slices.IndexFunc(excludes, match)
+	return include, exclude, include >= 0 && exclude >= 0
 }
 
 // fileNameWithoutExt returns the file name with its extension removed.
@@ -226,3 +507,29 @@ func parentFolder(relPath string) string {
 	}
 	return filepath.Base(dir)
 }
+
+// grandparentFolder returns the name of the directory above the parent directory
+// from a forward-slash relative path, or "" when the file is fewer than two levels deep.
+func grandparentFolder(relPath string) string {
+	dir := dirPath(relPath)
+	if dir == "" {
+		return ""
+	}
+	return parentFolder(dir)
+}
+
+// dirPath returns the full forward-slash directory of a relative path, or "" for
+// files at the root.
+func dirPath(relPath string) string {
+	dir := filepath.ToSlash(filepath.Dir(relPath))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// pathDepth returns the number of segments in a forward-slash relative path,
+// counting the file name itself.
+func pathDepth(relPath string) int {
+	return strings.Count(filepath.ToSlash(relPath), "/") + 1
+}
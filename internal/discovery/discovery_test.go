@@ -1,8 +1,10 @@
 package discovery
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -37,7 +39,7 @@ func TestDiscoverBasicMatch(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -72,7 +74,7 @@ func TestDiscoverExclude(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -84,6 +86,44 @@ func TestDiscoverExclude(t *testing.T) {
 	}
 }
 
+func TestDiscoverExcludeDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "data/keep.json", "{}")
+	createFile(t, root, "data/skip.json", "{}")
+	createFile(t, root, "data/old/legacy.json", "{}")
+	createFile(t, root, "notes/skip.json", "{}")
+
+	types := []config.TypeDef{
+		{
+			Name:  "data",
+			Input: "json",
+			Match: config.MatchDef{
+				Include: []string{`^data/.*\.json$`},
+				Exclude: []string{`^data/old/`, `skip\.json$`},
+			},
+		},
+	}
+
+	var excluded []ExcludedFile
+	files, errs := Discover(root, types, Options{OnExcluded: func(e ExcludedFile) { excluded = append(excluded, e) }})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 1 || files[0].Path != "data/keep.json" {
+		t.Fatalf("expected only data/keep.json, got %v", files)
+	}
+
+	// notes/skip.json matches an exclude but no include, so the exclude did
+	// not decide anything
+	want := []ExcludedFile{
+		{Path: "data/old/legacy.json", Type: "data", Include: 0, Exclude: 0, Pattern: `^data/old/`},
+		{Path: "data/skip.json", Type: "data", Include: 0, Exclude: 1, Pattern: `skip\.json$`},
+	}
+	if !slices.Equal(excluded, want) {
+		t.Errorf("excluded = %+v, want %+v", excluded, want)
+	}
+}
+
 func TestDiscoverMultiTypeMatch(t *testing.T) {
 	root := t.TempDir()
 	createFile(t, root, "overlap.yaml", "data: true")
@@ -101,7 +141,7 @@ func TestDiscoverMultiTypeMatch(t *testing.T) {
 		},
 	}
 
-	_, errs := Discover(root, types)
+	_, errs := Discover(root, types, Options{})
 	if len(errs) == 0 {
 		t.Fatal("expected error for multi-type match")
 	}
@@ -116,6 +156,43 @@ func TestDiscoverMultiTypeMatch(t *testing.T) {
 	}
 }
 
+func TestDiscoverMultiTypeMatchReportsPatterns(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "data/overlap.yaml", "data: true")
+
+	types := []config.TypeDef{
+		{
+			Name:  "typeA",
+			Input: "yaml",
+			Match: config.MatchDef{Include: []string{`^other/`, `^data/.*\.yaml$`}},
+		},
+		{
+			Name:  "typeB",
+			Input: "yaml",
+			Match: config.MatchDef{Include: []string{`overlap\.yaml$`}},
+		},
+	}
+
+	_, errs := Discover(root, types, Options{})
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got: %v", errs)
+	}
+	var amb *AmbiguousMatchError
+	if !errors.As(errs[0], &amb) {
+		t.Fatalf("expected *AmbiguousMatchError, got %T: %v", errs[0], errs[0])
+	}
+	want := []TypeMatch{
+		{Type: "typeA", Include: 1, Pattern: `^data/.*\.yaml$`},
+		{Type: "typeB", Include: 0, Pattern: `overlap\.yaml$`},
+	}
+	if amb.Path != "data/overlap.yaml" || !slices.Equal(amb.Matches, want) {
+		t.Errorf("got path %q matches %+v, want data/overlap.yaml %+v", amb.Path, amb.Matches, want)
+	}
+	if msg := errs[0].Error(); !strings.Contains(msg, `typeA (match.include[1] "^data/.*\\.yaml$")`) || !strings.Contains(msg, `typeB (match.include[0] "overlap\\.yaml$")`) {
+		t.Errorf("message does not name both patterns: %s", msg)
+	}
+}
+
 func TestDiscoverNamedCaptures(t *testing.T) {
 	root := t.TempDir()
 	createFile(t, root, "configs/alpha/services/web.yaml", "id: web")
@@ -130,7 +207,7 @@ func TestDiscoverNamedCaptures(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -161,7 +238,7 @@ func TestDiscoverBuiltInCaptures(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -181,6 +258,43 @@ func TestDiscoverBuiltInCaptures(t *testing.T) {
 	}
 }
 
+func TestDiscoverNestedPathCaptures(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "a/b/c/x.json", "{}")
+
+	types := []config.TypeDef{
+		{
+			Name:  "nested",
+			Input: "json",
+			Match: config.MatchDef{
+				Include: []string{`^a/.*\.json$`},
+			},
+		},
+	}
+
+	files, errs := Discover(root, types, Options{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+
+	f := files[0]
+	if f.PathCaptures["path.parent"] != "c" {
+		t.Errorf("expected path.parent=c, got %q", f.PathCaptures["path.parent"])
+	}
+	if f.PathCaptures["path.grandparent"] != "b" {
+		t.Errorf("expected path.grandparent=b, got %q", f.PathCaptures["path.grandparent"])
+	}
+	if f.PathCaptures["path.dir"] != "a/b/c" {
+		t.Errorf("expected path.dir=a/b/c, got %q", f.PathCaptures["path.dir"])
+	}
+	if f.PathCaptures["path.depth"] != "4" {
+		t.Errorf("expected path.depth=4, got %q", f.PathCaptures["path.depth"])
+	}
+}
+
 func TestDiscoverYmlNormalizesToYaml(t *testing.T) {
 	root := t.TempDir()
 	createFile(t, root, "data.yml", "a: 1")
@@ -195,7 +309,7 @@ func TestDiscoverYmlNormalizesToYaml(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -223,7 +337,7 @@ func TestDiscoverSkipsHiddenDirs(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -242,7 +356,7 @@ func TestDiscoverSubdirectoryDatacur8Error(t *testing.T) {
 
 	types := []config.TypeDef{}
 
-	_, errs := Discover(root, types)
+	_, errs := Discover(root, types, Options{})
 	if len(errs) == 0 {
 		t.Fatal("expected error for subdirectory .datacur8")
 	}
@@ -257,6 +371,103 @@ func TestDiscoverSubdirectoryDatacur8Error(t *testing.T) {
 	}
 }
 
+func TestDiscoverNeverMatchesConfigFile(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, ".datacur8", "version: '1'")
+	createFile(t, root, "data/item.yaml", "id: a")
+
+	for _, against := range []string{"path", "basename"} {
+		types := []config.TypeDef{
+			{
+				Name:  "any",
+				Input: "yaml",
+				Match: config.MatchDef{
+					Include: []string{`.*`},
+					Against: against,
+				},
+			},
+		}
+
+		walked, errs := Discover(root, types, Options{})
+		if len(errs) > 0 {
+			t.Fatalf("against %s: unexpected errors: %v", against, errs)
+		}
+		listed, errs := Discover(root, types, Options{Files: []string{".datacur8", "./.datacur8", "data/item.yaml"}})
+		if len(errs) > 0 {
+			t.Fatalf("against %s: unexpected errors listing files: %v", against, errs)
+		}
+		for _, files := range [][]DiscoveredFile{walked, listed} {
+			if len(files) != 1 || files[0].Path != "data/item.yaml" {
+				t.Errorf("against %s: expected only data/item.yaml, got %v", against, files)
+			}
+		}
+	}
+}
+
+func TestDiscoverNeverMatchesOwnOutput(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "data/item.yaml", "id: a")
+	createFile(t, root, "out/items.yaml", "items: []")
+	createFile(t, root, "out/combined.yaml", "items: []")
+
+	types := []config.TypeDef{
+		{
+			Name:  "item",
+			Input: "yaml",
+			Match: config.MatchDef{
+				Include: []string{`\.yaml$`},
+			},
+			Output: &config.OutputDef{
+				Path:   "./out//items.yaml",
+				Format: "yaml",
+			},
+		},
+	}
+
+	files, errs := Discover(root, types, Options{SkipPaths: []string{"./out/combined.yaml"}})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 1 || files[0].Path != "data/item.yaml" {
+		t.Errorf("expected only data/item.yaml, got %v", files)
+	}
+}
+
+func TestDiscoverUnreadableDirectoryIsNotFatal(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+	root := t.TempDir()
+	createFile(t, root, "data/a.json", "{}")
+	createFile(t, root, "data/locked/b.json", "{}")
+	locked := filepath.Join(root, "data", "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	types := []config.TypeDef{
+		{
+			Name:  "item",
+			Input: "json",
+			Match: config.MatchDef{
+				Include: []string{`\.json$`},
+			},
+		},
+	}
+
+	for _, follow := range []bool{false, true} {
+		files, errs := Discover(root, types, Options{FollowSymlinks: follow})
+		if len(files) != 1 || files[0].Path != "data/a.json" {
+			t.Errorf("follow_symlinks %v: expected data/a.json, got %v", follow, files)
+		}
+		var unreadable *UnreadableError
+		if len(errs) != 1 || !errors.As(errs[0], &unreadable) || unreadable.Path != "data/locked" {
+			t.Errorf("follow_symlinks %v: expected unreadable data/locked, got %v", follow, errs)
+		}
+	}
+}
+
 func TestDiscoverSkipsOutputPaths(t *testing.T) {
 	root := t.TempDir()
 	createFile(t, root, "data/item.json", "{}")
@@ -276,7 +487,7 @@ func TestDiscoverSkipsOutputPaths(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -288,6 +499,37 @@ func TestDiscoverSkipsOutputPaths(t *testing.T) {
 	}
 }
 
+func TestDiscoverSkipsSplitOutputParts(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "data/item.json", "{}")
+	createFile(t, root, "out/items.1.jsonl", "{}")
+	createFile(t, root, "out/items.2.jsonl", "{}")
+	createFile(t, root, "out/items.index.json", "{}")
+
+	types := []config.TypeDef{
+		{
+			Name:  "item",
+			Input: "auto",
+			Match: config.MatchDef{
+				Include: []string{`\.jsonl?$`},
+			},
+			Output: &config.OutputDef{
+				Path:     "./out/items.jsonl",
+				Format:   "jsonl",
+				MaxLines: 2,
+			},
+		},
+	}
+
+	files, errs := Discover(root, types, Options{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 1 || files[0].Path != "data/item.json" {
+		t.Errorf("expected only data/item.json, got %v", files)
+	}
+}
+
 func TestDiscoverNoMatchIgnored(t *testing.T) {
 	root := t.TempDir()
 	createFile(t, root, "readme.txt", "hello")
@@ -303,7 +545,7 @@ func TestDiscoverNoMatchIgnored(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -328,7 +570,7 @@ func TestDiscoverSortedOutput(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -354,7 +596,7 @@ func TestDiscoverParentFolderRootFile(t *testing.T) {
 		},
 	}
 
-	files, errs := Discover(root, types)
+	files, errs := Discover(root, types, Options{})
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -364,6 +606,172 @@ func TestDiscoverParentFolderRootFile(t *testing.T) {
 	if files[0].PathCaptures["path.parent"] != "" {
 		t.Errorf("expected empty path.parent for root file, got %q", files[0].PathCaptures["path.parent"])
 	}
+	if files[0].PathCaptures["path.grandparent"] != "" {
+		t.Errorf("expected empty path.grandparent for root file, got %q", files[0].PathCaptures["path.grandparent"])
+	}
+	if files[0].PathCaptures["path.dir"] != "" {
+		t.Errorf("expected empty path.dir for root file, got %q", files[0].PathCaptures["path.dir"])
+	}
+	if files[0].PathCaptures["path.depth"] != "1" {
+		t.Errorf("expected path.depth=1 for root file, got %q", files[0].PathCaptures["path.depth"])
+	}
+}
+
+
+
+func TestDiscoverMatchAgainstBasename(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "alpha.team.yaml", "name: alpha")
+	createFile(t, root, "org/east/beta.team.yaml", "name: beta")
+	createFile(t, root, "org/east/notes.yaml", "text: skip")
+	createFile(t, root, "org/west/old.team.yaml", "name: old")
+
+	pattern := `^(?P<team>[a-z]+)\.team\.yaml$`
+	types := []config.TypeDef{
+		{
+			Name:  "team",
+			Input: "yaml",
+			Match: config.MatchDef{
+				Include: []string{pattern},
+				Exclude: []string{`^old\.`},
+				Against: "basename",
+			},
+		},
+	}
+
+	files, errs := Discover(root, types, Options{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d: %v", len(files), files)
+	}
+	if files[0].Path != "alpha.team.yaml" || files[1].Path != "org/east/beta.team.yaml" {
+		t.Errorf("unexpected files: %s, %s", files[0].Path, files[1].Path)
+	}
+	if files[1].PathCaptures["path.team"] != "beta" {
+		t.Errorf("expected path.team=beta, got %q", files[1].PathCaptures["path.team"])
+	}
+	if files[1].PathCaptures["path.parent"] != "east" {
+		t.Errorf("expected path.parent=east from the full path, got %q", files[1].PathCaptures["path.parent"])
+	}
+
+	// The same pattern anchored against the path only matches the root file.
+	types[0].Match.Against = "path"
+	files, errs = Discover(root, types, Options{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 1 || files[0].Path != "alpha.team.yaml" {
+		t.Fatalf("expected only alpha.team.yaml with path matching, got %v", files)
+	}
 }
 
+func TestDiscoverFollowSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	createFile(t, root, "data/real/a.json", "{}")
+	createFile(t, outside, "b.json", "{}")
+
+	symlink := func(target, link string) {
+		t.Helper()
+		if err := os.Symlink(target, filepath.Join(root, filepath.FromSlash(link))); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+	// A linked subtree outside the root, and a cycle back to data/.
+	symlink(outside, "data/linked")
+	symlink(filepath.Join(root, "data"), "data/real/loop")
+
+	types := []config.TypeDef{
+		{
+			Name:  "doc",
+			Input: "json",
+			Match: config.MatchDef{
+				Include: []string{`\.json$`},
+			},
+		},
+	}
+
+	files, errs := Discover(root, types, Options{FollowSymlinks: true})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	want := []string{"data/linked/b.json", "data/real/a.json"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+
+	// By default symlinked directories are not traversed.
+	files, errs = Discover(root, types, Options{})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	for _, f := range files {
+		if strings.HasPrefix(f.Path, "data/linked/") {
+			t.Errorf("did not expect linked file %s without follow_symlinks", f.Path)
+		}
+	}
+}
 
+func TestDiscoverListedFiles(t *testing.T) {
+	root := t.TempDir()
+	createFile(t, root, "teams/alpha.yaml", "name: alpha")
+	createFile(t, root, "teams/beta.yaml", "name: beta")
+	createFile(t, root, "teams/gamma.yaml", "name: gamma")
+	createFile(t, root, ".hidden/teams/delta.yaml", "name: delta")
+	createFile(t, root, "README.md", "# readme")
+
+	types := []config.TypeDef{
+		{
+			Name:  "team",
+			Input: "yaml",
+			Match: config.MatchDef{
+				Include: []string{`^teams/(?P<team>[^/]+)\.yaml$`},
+			},
+		},
+	}
+
+	listed := []string{
+		"teams/gamma.yaml",
+		"./teams/alpha.yaml",
+		filepath.Join(root, "teams", "alpha.yaml"),
+		"README.md",
+		".hidden/teams/delta.yaml",
+	}
+	files, errs := Discover(root, types, Options{Files: listed})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(files) != 2 || files[0].Path != "teams/alpha.yaml" || files[1].Path != "teams/gamma.yaml" {
+		t.Fatalf("expected only the listed team files, got %+v", files)
+	}
+	if files[1].PathCaptures["path.team"] != "gamma" || files[1].PathCaptures["path.parent"] != "teams" {
+		t.Errorf("unexpected captures: %v", files[1].PathCaptures)
+	}
+
+	_, errs = Discover(root, types, Options{Files: []string{"teams/missing.yaml", "teams", "../outside.yaml"}})
+	want := []string{
+		`listed file "teams/missing.yaml" does not exist`,
+		`listed file "teams" is a directory`,
+		`listed file "../outside.yaml" is outside the root directory`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("error %d: expected %q, got %q", i, want[i], err.Error())
+		}
+	}
+
+	// an empty list considers no files rather than walking the tree
+	files, errs = Discover(root, types, Options{Files: []string{}})
+	if len(errs) > 0 || len(files) != 0 {
+		t.Fatalf("expected no files, got %v %v", files, errs)
+	}
+}
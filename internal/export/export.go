@@ -1,10 +1,13 @@
 package export
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
@@ -16,15 +19,23 @@ type ExportResult struct {
 	TypeName string
 	Path     string
 	Format   string
-	Count    int // number of items exported
+	Count    int  // number of items exported
+	Changed  bool // false when the existing output already had identical content
+	Parts    int  // number of part files when a jsonl output is split by max_lines; Path is then the index
+
+	PartPaths []string // the part files written when Parts > 0
 }
 
 // Export writes validated items to their configured output files.
 // items is a map from type name to ordered slice of parsed data items ([]any where each is map[string]any)
 // typeDefs contains the type definitions with output config
+// combinedPath, when not empty, is an additional JSONL file written after the
+// per-type outputs with every item of every type tagged with "_type"
 // rootDir is the base directory for resolving output paths
+// Output files whose existing content is identical are not rewritten, which
+// preserves their modification time; they are reported with Changed false.
 // Returns results and any errors
-func Export(items map[string][]any, typeDefs []config.TypeDef, rootDir string) ([]ExportResult, []error) {
+func Export(items map[string][]any, typeDefs []config.TypeDef, combinedPath string, rootDir string) ([]ExportResult, []error) {
 	var results []ExportResult
 	var errs []error
 
@@ -35,11 +46,7 @@ func Export(items map[string][]any, typeDefs []config.TypeDef, rootDir string) (
 
 		data := items[td.Name]
 
-		outPath := td.Output.Path
-		if !filepath.IsAbs(outPath) {
-			outPath = filepath.Join(rootDir, outPath)
-		}
-
+		outPath := resolveOutputPath(td.Output.Path, rootDir)
 		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
 			errs = append(errs, fmt.Errorf("creating output directory for %s: %w", td.Name, err))
 			continue
@@ -47,14 +54,27 @@ func Export(items map[string][]any, typeDefs []config.TypeDef, rootDir string) (
 
 		format := strings.ToLower(td.Output.Format)
 
+		if format == "jsonl" && td.Output.MaxLines > 0 {
+			result, err := exportSplitJSONL(td, data, rootDir)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			results = append(results, result)
+			continue
+		}
+
 		var content []byte
 		var err error
 
 		switch format {
 		case "json":
-			content, err = marshalJSON(td.Name, data)
+			content, err = marshalJSON(td.Name, data, td.Output.Banner, td.Output.IsPretty())
 		case "yaml":
-			content, err = marshalYAML(td.Name, data)
+			content, err = marshalYAML(td.Name, data, td.Output.YAMLStyle)
+			if err == nil && td.Output.Banner != "" {
+				content = append(yamlBanner(td.Output.Banner), content...)
+			}
 		case "jsonl":
 			content, err = marshalJSONL(data)
 		default:
@@ -67,7 +87,8 @@ func Export(items map[string][]any, typeDefs []config.TypeDef, rootDir string) (
 			continue
 		}
 
-		if err := os.WriteFile(outPath, content, 0o644); err != nil {
+		changed, err := writeIfChanged(outPath, content)
+		if err != nil {
 			errs = append(errs, fmt.Errorf("writing output file for %s: %w", td.Name, err))
 			continue
 		}
@@ -77,35 +98,240 @@ func Export(items map[string][]any, typeDefs []config.TypeDef, rootDir string) (
 			Path:     outPath,
 			Format:   format,
 			Count:    len(data),
+			Changed:  changed,
 		})
 	}
 
+	if combinedPath != "" {
+		result, err := exportCombined(items, typeDefs, resolveOutputPath(combinedPath, rootDir))
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			results = append(results, result)
+		}
+	}
+
 	return results, errs
 }
 
-func marshalJSON(typeName string, data []any) ([]byte, error) {
+// exportSplitJSONL writes a jsonl output limited by max_lines. When the items
+// fit, it writes output.path as usual; otherwise it writes numbered part files
+// of at most max_lines lines each and a JSON index listing them. Files left
+// over from a previous export with a different layout are removed.
+func exportSplitJSONL(td config.TypeDef, data []any, rootDir string) (ExportResult, error) {
+	out := td.Output
+	outPath := resolveOutputPath(out.Path, rootDir)
+	result := ExportResult{TypeName: td.Name, Path: outPath, Format: "jsonl", Count: len(data)}
+
+	var parts [][]any
+	if len(data) > out.MaxLines {
+		parts = slices.Collect(slices.Chunk(data, out.MaxLines))
+	}
+
+	changed := false
+	write := func(p string, content []byte) error {
+		c, err := writeIfChanged(p, content)
+		changed = changed || c
+		return err
+	}
+	remove := func(p string) error {
+		err := os.Remove(p)
+		if err == nil {
+			changed = true
+			return nil
+		}
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if len(parts) == 0 {
+		content, err := marshalJSONL(data)
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("marshaling jsonl output for type %s: %w", td.Name, err)
+		}
+		if err := write(outPath, content); err != nil {
+			return ExportResult{}, fmt.Errorf("writing output file for %s: %w", td.Name, err)
+		}
+		if err := remove(resolveOutputPath(out.IndexPath(), rootDir)); err != nil {
+			return ExportResult{}, fmt.Errorf("removing stale index for %s: %w", td.Name, err)
+		}
+	} else {
+		names := make([]string, len(parts))
+		for i, part := range parts {
+			content, err := marshalJSONL(part)
+			if err != nil {
+				return ExportResult{}, fmt.Errorf("marshaling jsonl output for type %s: %w", td.Name, err)
+			}
+			partPath := resolveOutputPath(out.PartPath(i), rootDir)
+			if err := write(partPath, content); err != nil {
+				return ExportResult{}, fmt.Errorf("writing output file for %s: %w", td.Name, err)
+			}
+			names[i] = filepath.Base(partPath)
+			result.PartPaths = append(result.PartPaths, partPath)
+		}
+		index, err := json.MarshalIndent(map[string]any{"parts": names}, "", "  ")
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("marshaling index for type %s: %w", td.Name, err)
+		}
+		result.Path = resolveOutputPath(out.IndexPath(), rootDir)
+		result.Parts = len(parts)
+		if err := write(result.Path, append(index, '\n')); err != nil {
+			return ExportResult{}, fmt.Errorf("writing index file for %s: %w", td.Name, err)
+		}
+		if err := remove(outPath); err != nil {
+			return ExportResult{}, fmt.Errorf("removing unsplit output for %s: %w", td.Name, err)
+		}
+	}
+
+	// Parts beyond the current count are stale.
+	for i := len(parts); ; i++ {
+		p := resolveOutputPath(out.PartPath(i), rootDir)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		if err := remove(p); err != nil {
+			return ExportResult{}, fmt.Errorf("removing stale part for %s: %w", td.Name, err)
+		}
+	}
+
+	result.Changed = changed
+	return result, nil
+}
+
+// exportCombined writes the items of every type, in config order, to a single
+// JSONL file at outPath.
+func exportCombined(items map[string][]any, typeDefs []config.TypeDef, outPath string) (ExportResult, error) {
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return ExportResult{}, fmt.Errorf("creating output directory for combined export: %w", err)
+	}
+
+	var content []byte
+	count := 0
+	for _, td := range typeDefs {
+		lines, err := marshalTaggedJSONL(td.Name, items[td.Name])
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("marshaling combined output for type %s: %w", td.Name, err)
+		}
+		content = append(content, lines...)
+		count += len(items[td.Name])
+	}
+
+	changed, err := writeIfChanged(outPath, content)
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("writing combined output file: %w", err)
+	}
+	return ExportResult{Path: outPath, Format: "jsonl", Count: count, Changed: changed}, nil
+}
+
+// resolveOutputPath resolves a configured output path against rootDir.
+func resolveOutputPath(p, rootDir string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(rootDir, p)
+}
+
+// writeIfChanged writes content to outPath unless the file already holds
+// identical bytes, and reports whether it wrote.
+func writeIfChanged(outPath string, content []byte) (bool, error) {
+	if existing, err := os.ReadFile(outPath); err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if err := os.WriteFile(outPath, content, 0o644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// marshalJSON renders the items wrapped under typeName, indented with two
+// spaces when pretty and minified otherwise. A non-empty banner is written
+// first as a "_generated" field, since JSON has no comments.
+func marshalJSON(typeName string, data []any, banner string, pretty bool) ([]byte, error) {
 	if data == nil {
 		data = []any{}
 	}
 	wrapper := map[string]any{typeName: data}
-	out, err := json.MarshalIndent(wrapper, "", "  ")
+	var out []byte
+	var err error
+	if pretty {
+		out, err = json.MarshalIndent(wrapper, "", "  ")
+	} else {
+		out, err = json.Marshal(wrapper)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if banner != "" {
+		marker, err := json.Marshal(banner)
+		if err != nil {
+			return nil, err
+		}
+		prefix := []byte(`{"_generated":`)
+		if pretty {
+			prefix = []byte("{\n  \"_generated\": ")
+		}
+		out = slices.Concat(prefix, marker, []byte(","), out[1:])
+	}
 	out = append(out, '\n')
 	return out, nil
 }
 
-func marshalYAML(typeName string, data []any) ([]byte, error) {
+// yamlBanner renders banner as YAML comment lines, one per line of text.
+func yamlBanner(banner string) []byte {
+	var buf []byte
+	for line := range strings.Lines(banner) {
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			buf = append(buf, "#\n"...)
+			continue
+		}
+		buf = append(buf, "# "+line+"\n"...)
+	}
+	return buf
+}
+
+// marshalYAML renders the wrapped items in block style, or entirely in flow
+// style ({...} and [...]) when style is "flow". Object keys are written in
+// byte order, as encoding/json writes them, so the YAML and JSON exports of a
+// type list fields in the same order.
+func marshalYAML(typeName string, data []any, style string) ([]byte, error) {
 	if data == nil {
 		data = []any{}
 	}
 	wrapper := map[string]any{typeName: data}
-	out, err := yaml.Marshal(wrapper)
-	if err != nil {
+
+	var node yaml.Node
+	if err := node.Encode(wrapper); err != nil {
 		return nil, err
 	}
-	return out, nil
+	sortMappingKeys(&node)
+	if style == "flow" {
+		node.Style = yaml.FlowStyle
+	}
+	return yaml.Marshal(&node)
+}
+
+// sortMappingKeys reorders the key/value pairs of every mapping under n by the
+// byte order of their keys. yaml.v3 sorts map keys itself, but in a "natural"
+// order that puts a2 before a10, where encoding/json puts a10 first.
+func sortMappingKeys(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+		}
+		slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+			return strings.Compare(a[0].Value, b[0].Value)
+		})
+		for i, p := range pairs {
+			n.Content[2*i], n.Content[2*i+1] = p[0], p[1]
+		}
+	}
+	for _, c := range n.Content {
+		sortMappingKeys(c)
+	}
 }
 
 func marshalJSONL(data []any) ([]byte, error) {
@@ -120,3 +346,40 @@ func marshalJSONL(data []any) ([]byte, error) {
 	}
 	return buf, nil
 }
+
+// marshalTaggedJSONL renders one JSON object per item with "_type" set to
+// typeName as the first key. An existing "_type" field of the item is
+// replaced.
+func marshalTaggedJSONL(typeName string, data []any) ([]byte, error) {
+	tag, err := json.Marshal(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf []byte
+	for _, item := range data {
+		if m, ok := item.(map[string]any); ok {
+			if _, exists := m["_type"]; exists {
+				m = maps.Clone(m)
+				delete(m, "_type")
+			}
+			item = m
+		}
+		body, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) < 2 || body[0] != '{' {
+			return nil, fmt.Errorf("item is not a JSON object")
+		}
+
+		buf = append(buf, `{"_type":`...)
+		buf = append(buf, tag...)
+		if len(body) > 2 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, body[1:]...)
+		buf = append(buf, '\n')
+	}
+	return buf, nil
+}
@@ -1,11 +1,14 @@
 package export
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/config"
 	"gopkg.in/yaml.v3"
@@ -32,7 +35,7 @@ func TestExportJSON(t *testing.T) {
 		},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -94,7 +97,7 @@ func TestExportYAML(t *testing.T) {
 		},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -143,7 +146,7 @@ func TestExportJSONL(t *testing.T) {
 		},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -186,7 +189,7 @@ func TestExportNoOutput(t *testing.T) {
 		"things": {map[string]any{"a": 1}},
 	}
 
-	results, errs := Export(items, typeDefs, t.TempDir())
+	results, errs := Export(items, typeDefs, "", t.TempDir())
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -213,7 +216,7 @@ func TestExportCreatesParentDirs(t *testing.T) {
 		"items": {map[string]any{"k": "v"}},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -241,7 +244,7 @@ func TestExportEmptyItems(t *testing.T) {
 
 	items := map[string][]any{}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -283,7 +286,7 @@ func TestExportRelativePath(t *testing.T) {
 		"rel": {map[string]any{"x": 1}},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) > 0 {
 		t.Fatalf("unexpected errors: %v", errs)
 	}
@@ -317,7 +320,7 @@ func TestExportUnsupportedFormat(t *testing.T) {
 		"bad": {map[string]any{"a": 1}},
 	}
 
-	results, errs := Export(items, typeDefs, dir)
+	results, errs := Export(items, typeDefs, "", dir)
 	if len(errs) != 1 {
 		t.Fatalf("expected 1 error, got %d", len(errs))
 	}
@@ -328,3 +331,540 @@ func TestExportUnsupportedFormat(t *testing.T) {
 		t.Errorf("expected unsupported format error, got: %v", errs[0])
 	}
 }
+
+func TestExportUnchangedPreservesMtime(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.json")
+
+	typeDefs := []config.TypeDef{
+		{
+			Name:   "widgets",
+			Output: &config.OutputDef{Path: outPath, Format: "json"},
+		},
+	}
+	items := map[string][]any{
+		"widgets": {map[string]any{"name": "alpha"}},
+	}
+
+	results, errs := Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !results[0].Changed {
+		t.Error("expected first export to report Changed")
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(outPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	results, errs = Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(results) != 1 || results[0].Changed {
+		t.Fatalf("expected unchanged result, got %+v", results)
+	}
+	info, err := os.Stat(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("expected mtime %v to be preserved, got %v", past, info.ModTime())
+	}
+
+	// Different content is rewritten.
+	items["widgets"] = append(items["widgets"], map[string]any{"name": "beta"})
+	results, errs = Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !results[0].Changed {
+		t.Error("expected changed content to report Changed")
+	}
+	info, err = os.Stat(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ModTime().Equal(past) {
+		t.Error("expected mtime to change after rewriting")
+	}
+}
+
+func TestExportYAMLFlowStyle(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.yaml")
+
+	typeDefs := []config.TypeDef{
+		{
+			Name: "gadgets",
+			Output: &config.OutputDef{
+				Path:      outPath,
+				Format:    "yaml",
+				YAMLStyle: "flow",
+			},
+		},
+	}
+
+	items := map[string][]any{
+		"gadgets": {
+			map[string]any{"id": "g1", "tags": []any{"a", "b"}},
+			map[string]any{"id": "g2", "tags": []any{}},
+		},
+	}
+
+	if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	want := "{gadgets: [{id: g1, tags: [a, b]}, {id: g2, tags: []}]}\n"
+	if string(data) != want {
+		t.Errorf("expected flow style output:\n%q\ngot:\n%q", want, string(data))
+	}
+
+	var parsed map[string][]map[string]any
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("parsing output YAML: %v", err)
+	}
+	if len(parsed["gadgets"]) != 2 || parsed["gadgets"][1]["id"] != "g2" {
+		t.Errorf("unexpected round-trip: %v", parsed)
+	}
+}
+
+func TestExportYAMLBlockStyleDefault(t *testing.T) {
+	for _, style := range []string{"", "block"} {
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "out.yaml")
+		typeDefs := []config.TypeDef{
+			{
+				Name:   "gadgets",
+				Output: &config.OutputDef{Path: outPath, Format: "yaml", YAMLStyle: style},
+			},
+		}
+		items := map[string][]any{"gadgets": {map[string]any{"id": "g1"}}}
+
+		if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		data, _ := os.ReadFile(outPath)
+		if want := "gadgets:\n    - id: g1\n"; string(data) != want {
+			t.Errorf("style %q: expected block output %q, got %q", style, want, string(data))
+		}
+	}
+}
+
+func TestExportYAMLSortsKeys(t *testing.T) {
+	items := map[string][]any{
+		"gadgets": {
+			map[string]any{"name": "g1", "a2": 1, "a10": 2, "Zone": "x", "meta": map[string]any{"b": true, "a": false}},
+		},
+	}
+
+	cases := map[string]string{
+		"block": "gadgets:\n    - Zone: x\n      a10: 2\n      a2: 1\n      meta:\n        a: false\n        b: true\n      name: g1\n",
+		"flow":  "{gadgets: [{Zone: x, a10: 2, a2: 1, meta: {a: false, b: true}, name: g1}]}\n",
+	}
+	for style, want := range cases {
+		dir := t.TempDir()
+		outPath := filepath.Join(dir, "out.yaml")
+		typeDefs := []config.TypeDef{
+			{
+				Name:   "gadgets",
+				Output: &config.OutputDef{Path: outPath, Format: "yaml", YAMLStyle: style},
+			},
+		}
+
+		// Render several times: map iteration order differs between runs,
+		// the output must not.
+		for range 5 {
+			if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			data, _ := os.ReadFile(outPath)
+			if string(data) != want {
+				t.Fatalf("style %s: expected keys in byte order:\n%q\ngot:\n%q", style, want, string(data))
+			}
+		}
+	}
+}
+
+func TestExportYAMLBanner(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.yaml")
+
+	typeDefs := []config.TypeDef{
+		{
+			Name: "gadgets",
+			Output: &config.OutputDef{
+				Path:   outPath,
+				Format: "yaml",
+				Banner: "GENERATED BY datacur8 - DO NOT EDIT\n\nSource: gadgets/",
+			},
+		},
+	}
+	items := map[string][]any{"gadgets": {map[string]any{"id": "g1"}}}
+
+	if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	want := "# GENERATED BY datacur8 - DO NOT EDIT\n#\n# Source: gadgets/\ngadgets:\n    - id: g1\n"
+	if string(data) != want {
+		t.Errorf("expected banner comment:\n%q\ngot:\n%q", want, string(data))
+	}
+}
+
+func TestExportJSONBannerMarker(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.json")
+
+	typeDefs := []config.TypeDef{
+		{
+			Name: "Gadgets",
+			Output: &config.OutputDef{
+				Path:   outPath,
+				Format: "json",
+				Banner: "GENERATED BY datacur8 - DO NOT EDIT",
+			},
+		},
+	}
+	items := map[string][]any{"Gadgets": {map[string]any{"id": "g1"}}}
+
+	if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("reading output: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"_generated\": \"GENERATED BY datacur8 - DO NOT EDIT\",\n  \"Gadgets\": [") {
+		t.Errorf("expected _generated marker as the first field, got:\n%s", data)
+	}
+
+	var parsed map[string]any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("parsing output JSON: %v", err)
+	}
+	if parsed["_generated"] != "GENERATED BY datacur8 - DO NOT EDIT" || len(parsed["Gadgets"].([]any)) != 1 {
+		t.Errorf("unexpected round-trip: %v", parsed)
+	}
+}
+
+func TestExportJSONPretty(t *testing.T) {
+	items := map[string][]any{"widgets": {map[string]any{"name": "alpha", "tags": []any{"a b", "c"}}}}
+	tests := []struct {
+		name   string
+		pretty *bool
+		banner string
+		want   string
+	}{
+		{"default", nil, "", "{\n  \"widgets\": [\n    {\n      \"name\": \"alpha\",\n      \"tags\": [\n        \"a b\",\n        \"c\"\n      ]\n    }\n  ]\n}\n"},
+		{"explicit true", new(true), "", "{\n  \"widgets\": [\n    {\n      \"name\": \"alpha\",\n      \"tags\": [\n        \"a b\",\n        \"c\"\n      ]\n    }\n  ]\n}\n"},
+		{"minified", new(false), "", `{"widgets":[{"name":"alpha","tags":["a b","c"]}]}` + "\n"},
+		{"minified with banner", new(false), "generated", `{"_generated":"generated","widgets":[{"name":"alpha","tags":["a b","c"]}]}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outPath := filepath.Join(dir, "out.json")
+			typeDefs := []config.TypeDef{{
+				Name:   "widgets",
+				Output: &config.OutputDef{Path: outPath, Format: "json", Pretty: tt.pretty, Banner: tt.banner},
+			}}
+			if _, errs := Export(items, typeDefs, "", dir); len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			data, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatalf("reading output: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("output = %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportJSONLMaxLinesSplitsParts(t *testing.T) {
+	dir := t.TempDir()
+	typeDefs := []config.TypeDef{
+		{
+			Name:   "events",
+			Output: &config.OutputDef{Path: "out/events.jsonl", Format: "jsonl", MaxLines: 2},
+		},
+	}
+	items := map[string][]any{
+		"events": {
+			map[string]any{"id": "e1"},
+			map[string]any{"id": "e2"},
+			map[string]any{"id": "e3"},
+		},
+	}
+
+	results, errs := Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	indexPath := filepath.Join(dir, "out", "events.index.json")
+	if len(results) != 1 || results[0].Path != indexPath || results[0].Parts != 2 || results[0].Count != 3 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	for name, want := range map[string]string{
+		"events.0.jsonl":    "{\"id\":\"e1\"}\n{\"id\":\"e2\"}\n",
+		"events.1.jsonl":    "{\"id\":\"e3\"}\n",
+		"events.index.json": "{\n  \"parts\": [\n    \"events.0.jsonl\",\n    \"events.1.jsonl\"\n  ]\n}\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, "out", name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", name, want, string(got))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "events.jsonl")); !os.IsNotExist(err) {
+		t.Error("expected no unsplit output when parts are written")
+	}
+
+	// Once the items fit, the single file returns and the parts and index are removed.
+	items["events"] = items["events"][:2]
+	results, errs = Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(results) != 1 || results[0].Parts != 0 || results[0].Path != filepath.Join(dir, "out", "events.jsonl") {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, name := range []string{"events.0.jsonl", "events.1.jsonl", "events.index.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", name)); !os.IsNotExist(err) {
+			t.Errorf("expected stale %s to be removed", name)
+		}
+	}
+}
+
+func TestExportCombinedJSONL(t *testing.T) {
+	dir := t.TempDir()
+
+	typeDefs := []config.TypeDef{
+		{Name: "teams", Output: &config.OutputDef{Path: "teams.json", Format: "json"}},
+		{Name: "services"},
+	}
+	items := map[string][]any{
+		"services": {
+			map[string]any{"id": "s1", "Team": "a", "_type": "spoofed"},
+		},
+		"teams": {
+			map[string]any{"id": "a"},
+			map[string]any{},
+		},
+	}
+
+	results, errs := Export(items, typeDefs, "out/all.jsonl", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	combined := results[1]
+	if combined.Format != "jsonl" || combined.Count != 3 || !combined.Changed {
+		t.Errorf("unexpected combined result: %+v", combined)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "all.jsonl"))
+	if err != nil {
+		t.Fatalf("reading combined output: %v", err)
+	}
+	want := `{"_type":"teams","id":"a"}
+{"_type":"teams"}
+{"_type":"services","Team":"a","id":"s1"}
+`
+	if string(data) != want {
+		t.Errorf("combined output:\n%s\nwant:\n%s", data, want)
+	}
+	if _, exists := items["services"][0].(map[string]any)["_type"]; !exists {
+		t.Error("export must not modify the source item")
+	}
+
+	results, _ = Export(items, typeDefs, "out/all.jsonl", dir)
+	if results[1].Changed {
+		t.Error("expected unchanged combined output on second export")
+	}
+}
+
+func TestWriteManifestHashesWrittenFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	typeDefs := []config.TypeDef{
+		{Name: "teams", Output: &config.OutputDef{Path: "out/teams.json", Format: "json"}},
+		{Name: "events", Output: &config.OutputDef{Path: "out/events.jsonl", Format: "jsonl", MaxLines: 2}},
+	}
+	items := map[string][]any{
+		"teams": {map[string]any{"id": "a"}},
+		"events": {
+			map[string]any{"id": "e1"},
+			map[string]any{"id": "e2"},
+			map[string]any{"id": "e3"},
+		},
+	}
+
+	results, errs := Export(items, typeDefs, "out/all.jsonl", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	result, err := WriteManifest(results, "out/manifest.json", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Changed || result.Count != 5 {
+		t.Errorf("unexpected manifest result: %+v", result)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out", "manifest.json"))
+	if err != nil {
+		t.Fatalf("reading manifest: %v", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("parsing manifest: %v", err)
+	}
+
+	wantItems := map[string]int{
+		"out/teams.json":        1,
+		"out/events.0.jsonl":    2,
+		"out/events.1.jsonl":    1,
+		"out/events.index.json": 3,
+		"out/all.jsonl":         4,
+	}
+	if len(m.Files) != len(wantItems) {
+		t.Fatalf("expected %d manifest entries, got %+v", len(wantItems), m.Files)
+	}
+	for _, f := range m.Files {
+		want, ok := wantItems[f.Path]
+		if !ok {
+			t.Errorf("unexpected manifest entry %q", f.Path)
+			continue
+		}
+		if f.Items != want {
+			t.Errorf("%s: expected %d items, got %d", f.Path, want, f.Items)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Path, err)
+		}
+		sum := sha256.Sum256(content)
+		if f.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: manifest hash %s does not match the written file", f.Path, f.SHA256)
+		}
+	}
+
+	result, err = WriteManifest(results, "out/manifest.json", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Changed {
+		t.Error("expected unchanged manifest on second write")
+	}
+}
+
+func TestWriteGitAttributesMarksOutputs(t *testing.T) {
+	dir := t.TempDir()
+
+	typeDefs := []config.TypeDef{
+		{Name: "teams", Output: &config.OutputDef{Path: "out/teams.json", Format: "json"}},
+		{Name: "users", Output: &config.OutputDef{Path: "out/users.yaml", Format: "yaml"}},
+	}
+	items := map[string][]any{
+		"teams": {map[string]any{"id": "a"}},
+		"users": {map[string]any{"id": "u"}},
+	}
+	results, errs := Export(items, typeDefs, "", dir)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// lines around an earlier block are kept, the block itself is replaced
+	attrsPath := filepath.Join(dir, ".gitattributes")
+	existing := "*.csv text eol=lf\n" + gitAttributesBegin + "\n/out/old.json linguist-generated=true -diff\n" + gitAttributesEnd + "\n*.png binary\n"
+	if err := os.WriteFile(attrsPath, []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := WriteGitAttributes(results, ".gitattributes", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Changed || result.Count != 2 {
+		t.Errorf("unexpected gitattributes result: %+v", result)
+	}
+	data, err := os.ReadFile(attrsPath)
+	if err != nil {
+		t.Fatalf("reading gitattributes: %v", err)
+	}
+	want := "*.csv text eol=lf\n" +
+		"# BEGIN datacur8 generated files\n" +
+		"/out/teams.json linguist-generated=true -diff\n" +
+		"/out/users.yaml linguist-generated=true -diff\n" +
+		"# END datacur8 generated files\n" +
+		"*.png binary\n"
+	if string(data) != want {
+		t.Errorf("unexpected gitattributes:\n%s\nwant:\n%s", data, want)
+	}
+
+	result, err = WriteGitAttributes(results, ".gitattributes", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Changed {
+		t.Error("expected unchanged gitattributes on second write")
+	}
+
+	// patterns are relative to the file's directory, which must hold the outputs
+	if _, err := WriteGitAttributes(results, "docs/.gitattributes", dir); err == nil || !strings.Contains(err.Error(), "outside its directory") {
+		t.Errorf("expected outside directory error, got %v", err)
+	}
+}
+
+func TestWriteGitAttributesAppendsBlock(t *testing.T) {
+	got := replaceGitAttributesBlock("*.csv text\n", []string{gitAttributesBegin, gitAttributesEnd})
+	want := "*.csv text\n\n" + gitAttributesBegin + "\n" + gitAttributesEnd + "\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := replaceGitAttributesBlock("", []string{gitAttributesBegin, gitAttributesEnd}); got != gitAttributesBegin+"\n"+gitAttributesEnd+"\n" {
+		t.Errorf("unexpected block for a new file: %q", got)
+	}
+}
+
+func TestGitAttributesPattern(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want string
+	}{
+		{"out/teams.json", "/out/teams.json"},
+		{"out/#1.json", "/out/#1.json"},
+		{"out/st*r?.json", `/out/st\*r\?.json`},
+		{"out/br[1].json", `/out/br\[1].json`},
+		{`out/back\slash.json`, `/out/back\\slash.json`},
+		{"out/my teams.json", `"/out/my teams.json"`},
+		{"out/a b*.json", `"/out/a b\\*.json"`},
+		{`out/q"t.json`, `"/out/q\"t.json"`},
+		{"out/tab\tx.json", `"/out/tab\tx.json"`},
+	}
+	for _, tt := range tests {
+		if got := gitAttributesPattern(tt.rel); got != tt.want {
+			t.Errorf("gitAttributesPattern(%q) = %s, want %s", tt.rel, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The lines delimiting the block WriteGitAttributes maintains.
+const (
+	gitAttributesBegin = "# BEGIN datacur8 generated files"
+	gitAttributesEnd   = "# END datacur8 generated files"
+)
+
+// WriteGitAttributes writes a block to the .gitattributes file at attrPath
+// (resolved against rootDir) marking every file in results, including the
+// parts of a split output, as linguist-generated=true -diff. Patterns are
+// anchored to the directory of attrPath, so every file must be inside it.
+// The block replaces an earlier one, keeping the lines around it, or is
+// appended to the file. Like the outputs, the file is not rewritten when
+// unchanged.
+func WriteGitAttributes(results []ExportResult, attrPath string, rootDir string) (ExportResult, error) {
+	outPath := resolveOutputPath(attrPath, rootDir)
+	dir := filepath.Dir(outPath)
+
+	block := []string{gitAttributesBegin}
+	for _, r := range results {
+		for _, p := range append(slices.Clone(r.PartPaths), r.Path) {
+			rel, err := filepath.Rel(dir, p)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return ExportResult{}, fmt.Errorf("export gitattributes %s cannot mark %s, which is outside its directory", attrPath, p)
+			}
+			block = append(block, gitAttributesPattern(filepath.ToSlash(rel))+" linguist-generated=true -diff")
+		}
+	}
+	block = append(block, gitAttributesEnd)
+
+	existing, err := os.ReadFile(outPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ExportResult{}, fmt.Errorf("reading export gitattributes: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return ExportResult{}, fmt.Errorf("creating output directory for export gitattributes: %w", err)
+	}
+	changed, err := writeIfChanged(outPath, []byte(replaceGitAttributesBlock(string(existing), block)))
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("writing export gitattributes: %w", err)
+	}
+	return ExportResult{Path: outPath, Count: len(block) - 2, Changed: changed}, nil
+}
+
+// gitAttributesPattern returns the .gitattributes pattern matching only the
+// file at rel, a slash-separated path below the directory of the file. Glob
+// characters and backslashes are escaped, and a pattern with whitespace or a
+// double quote is written C-style quoted, since git would otherwise split it.
+func gitAttributesPattern(rel string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for _, r := range rel {
+		if strings.ContainsRune(`\*?[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	pattern := b.String()
+	if !strings.ContainsAny(pattern, " \t\n\r\v\f\"") {
+		return pattern
+	}
+	return `"` + gitAttributesQuoter.Replace(pattern) + `"`
+}
+
+// gitAttributesQuoter escapes a pattern for a C-style quoted string.
+var gitAttributesQuoter = strings.NewReplacer(
+	`\`, `\\`, `"`, `\"`,
+	"\t", `\t`, "\n", `\n`, "\r", `\r`, "\v", `\v`, "\f", `\f`,
+)
+
+// replaceGitAttributesBlock returns existing with the lines from
+// gitAttributesBegin to gitAttributesEnd replaced by block, or with block
+// appended after a blank line when there is no such block.
+func replaceGitAttributesBlock(existing string, block []string) string {
+	var lines []string
+	if existing != "" {
+		lines = strings.Split(strings.TrimSuffix(existing, "\n"), "\n")
+	}
+	if begin := slices.Index(lines, gitAttributesBegin); begin >= 0 {
+		if n := slices.Index(lines[begin:], gitAttributesEnd); n >= 0 {
+			lines = slices.Replace(lines, begin, begin+n+1, block...)
+			return strings.Join(lines, "\n") + "\n"
+		}
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, block...)
+	return strings.Join(lines, "\n") + "\n"
+}
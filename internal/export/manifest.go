@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestEntry describes one exported file in the manifest.
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format"`
+	SHA256 string `json:"sha256"`
+	Items  int    `json:"items"`
+}
+
+// Manifest is the content of the export.manifest file.
+type Manifest struct {
+	Files []ManifestEntry `json:"files"`
+}
+
+// WriteManifest hashes every file in results and writes a JSON manifest to
+// manifestPath (resolved against rootDir). Entry paths are relative to rootDir
+// with forward slashes. A split jsonl output lists each part, with its line
+// count as items, followed by its index, which carries the type's total.
+// Like the outputs, the manifest is not rewritten when unchanged.
+func WriteManifest(results []ExportResult, manifestPath string, rootDir string) (ExportResult, error) {
+	m := Manifest{Files: []ManifestEntry{}}
+	for _, r := range results {
+		for _, p := range r.PartPaths {
+			entry, content, err := manifestEntry(p, rootDir)
+			if err != nil {
+				return ExportResult{}, err
+			}
+			entry.Type = r.TypeName
+			entry.Format = r.Format
+			entry.Items = bytes.Count(content, []byte("\n"))
+			m.Files = append(m.Files, entry)
+		}
+		entry, _, err := manifestEntry(r.Path, rootDir)
+		if err != nil {
+			return ExportResult{}, err
+		}
+		entry.Type = r.TypeName
+		entry.Format = r.Format
+		if r.Parts > 0 {
+			entry.Format = "json"
+		}
+		entry.Items = r.Count
+		m.Files = append(m.Files, entry)
+	}
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("marshaling export manifest: %w", err)
+	}
+	outPath := resolveOutputPath(manifestPath, rootDir)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		return ExportResult{}, fmt.Errorf("creating output directory for export manifest: %w", err)
+	}
+	changed, err := writeIfChanged(outPath, append(content, '\n'))
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("writing export manifest: %w", err)
+	}
+	return ExportResult{Path: outPath, Format: "json", Count: len(m.Files), Changed: changed}, nil
+}
+
+// manifestEntry reads the file at p and returns an entry with its path and
+// hash filled in, along with the content.
+func manifestEntry(p, rootDir string) (ManifestEntry, []byte, error) {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return ManifestEntry{}, nil, fmt.Errorf("reading %s for export manifest: %w", p, err)
+	}
+	rel := p
+	if r, err := filepath.Rel(rootDir, p); err == nil {
+		rel = r
+	}
+	sum := sha256.Sum256(content)
+	return ManifestEntry{Path: filepath.ToSlash(rel), SHA256: hex.EncodeToString(sum[:])}, content, nil
+}
@@ -0,0 +1,371 @@
+// Package fix repairs the violations that have exactly one mechanical fix by
+// rewriting the value in the source file, leaving the rest of the file as it is.
+package fix
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/constraints"
+	"github.com/UnitVectorY-Labs/datacur8/internal/selector"
+	"gopkg.in/yaml.v3"
+)
+
+// Fix is a string value to replace in one item.
+type Fix struct {
+	TypeName string
+	FilePath string
+	RowIndex int    // for CSV, the row index; -1 for JSON/YAML
+	Path     string // location of the value, such as $.contacts[1].email
+	Old      string
+	New      string
+	Reason   string
+}
+
+// Find returns the fixes for the violations among items that have a single
+// unambiguous repair:
+//   - a format constraint value that becomes valid once surrounding whitespace is trimmed
+//   - a top-level property failing its schema pattern that matches once trimmed
+//   - a top-level property outside its schema enum that equals exactly one
+//     enum value ignoring case and surrounding whitespace
+//
+// When one value in a file would be fixed in two different ways, neither fix
+// is returned. Fixes are sorted by file, row, and path.
+func Find(items map[string][]constraints.Item, typeDefs []config.TypeDef) []Fix {
+	var fixes []Fix
+	for _, td := range typeDefs {
+		for _, item := range items[td.Name] {
+			fixes = append(fixes, formatFixes(td, item)...)
+			fixes = append(fixes, schemaFixes(td, item)...)
+		}
+	}
+	return dedupe(fixes)
+}
+
+// formatFixes trims the values of item that fail a format constraint of td but
+// pass it once trimmed.
+func formatFixes(td config.TypeDef, item constraints.Item) []Fix {
+	var fixes []Fix
+	for _, cd := range td.Constraints {
+		if cd.Type != "format" {
+			continue
+		}
+		sel, err := selector.Parse(cd.Key)
+		if err != nil {
+			continue
+		}
+		if _, ok := sel.PathCapture(); ok {
+			continue // path captures are not file content
+		}
+		for _, m := range sel.EvaluateMatches(item.Data) {
+			s, ok := m.Value.(string)
+			if !ok || constraints.IsValidFormat(cd.Format, s) {
+				continue
+			}
+			if trimmed := strings.TrimSpace(s); trimmed != s && constraints.IsValidFormat(cd.Format, trimmed) {
+				fixes = append(fixes, newFix(item, m.Path, s, trimmed, "trimmed whitespace"))
+			}
+		}
+	}
+	return fixes
+}
+
+// schemaFixes repairs top-level string properties of item that fail the
+// pattern or enum of their schema property.
+func schemaFixes(td config.TypeDef, item constraints.Item) []Fix {
+	props, _ := td.Schema["properties"].(map[string]any)
+	data, _ := item.Data.(map[string]any)
+	var fixes []Fix
+	for name, v := range data {
+		s, ok := v.(string)
+		prop, _ := props[name].(map[string]any)
+		if !ok || prop == nil {
+			continue
+		}
+		path := "$." + name
+
+		if pattern, ok := prop["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err == nil && !re.MatchString(s) {
+				if trimmed := strings.TrimSpace(s); trimmed != s && re.MatchString(trimmed) {
+					fixes = append(fixes, newFix(item, path, s, trimmed, "trimmed whitespace"))
+				}
+			}
+		}
+
+		if enum, ok := prop["enum"].([]any); ok {
+			if value, ok := enumMatch(enum, s); ok {
+				fixes = append(fixes, newFix(item, path, s, value, "matched enum value"))
+			}
+		}
+	}
+	return fixes
+}
+
+// enumMatch returns the single enum value equal to s ignoring case and
+// surrounding whitespace. It fails when s is already in enum or when no or
+// several values match.
+func enumMatch(enum []any, s string) (string, bool) {
+	match := ""
+	found := 0
+	for _, e := range enum {
+		es, ok := e.(string)
+		if !ok {
+			continue
+		}
+		if es == s {
+			return "", false
+		}
+		if strings.EqualFold(es, strings.TrimSpace(s)) {
+			match = es
+			found++
+		}
+	}
+	return match, found == 1
+}
+
+func newFix(item constraints.Item, path, from, to, reason string) Fix {
+	return Fix{
+		TypeName: item.TypeName,
+		FilePath: item.FilePath,
+		RowIndex: item.RowIndex,
+		Path:     path,
+		Old:      from,
+		New:      to,
+		Reason:   reason,
+	}
+}
+
+// dedupe sorts fixes, drops repeats of the same replacement, and drops every
+// fix of a value that would be replaced in more than one way in its file.
+func dedupe(fixes []Fix) []Fix {
+	type valueKey struct{ file, old string }
+	replacements := make(map[valueKey]map[string]bool)
+	for _, f := range fixes {
+		k := valueKey{f.FilePath, f.Old}
+		if replacements[k] == nil {
+			replacements[k] = make(map[string]bool)
+		}
+		replacements[k][f.New] = true
+	}
+
+	sort.SliceStable(fixes, func(i, j int) bool {
+		if fixes[i].FilePath != fixes[j].FilePath {
+			return fixes[i].FilePath < fixes[j].FilePath
+		}
+		if fixes[i].RowIndex != fixes[j].RowIndex {
+			return fixes[i].RowIndex < fixes[j].RowIndex
+		}
+		return fixes[i].Path < fixes[j].Path
+	})
+
+	var out []Fix
+	seen := make(map[valueKey]bool)
+	for _, f := range fixes {
+		k := valueKey{f.FilePath, f.Old}
+		if len(replacements[k]) > 1 || seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, f)
+	}
+	return out
+}
+
+// Apply returns raw, the content of a file in the given input format, with the
+// string value from replaced by to. The value must occur exactly once in the
+// file, counting keys and CSV headers, so the replacement is unambiguous; the
+// rest of the file is left byte for byte as it was.
+func Apply(raw []byte, input, from, to string) ([]byte, error) {
+	switch input {
+	case "json", "json5":
+		return applyJSON(raw, from, to)
+	case "yaml":
+		return applyYAML(raw, from, to)
+	case "csv":
+		return applyCSV(raw, from, to)
+	default:
+		return nil, fmt.Errorf("%s files are not fixed", input)
+	}
+}
+
+// applyJSON replaces the JSON string literal of from.
+func applyJSON(raw []byte, from, to string) ([]byte, error) {
+	token := jsonString(from)
+	var at []int
+	for i := 0; ; {
+		j := bytes.Index(raw[i:], token)
+		if j < 0 {
+			break
+		}
+		// a quote preceded by a backslash is inside another string
+		if i+j == 0 || raw[i+j-1] != '\\' {
+			at = append(at, i+j)
+		}
+		i += j + 1
+	}
+	if err := occurrences(len(at)); err != nil {
+		return nil, err
+	}
+	return splice(raw, at[0], len(token), jsonString(to)), nil
+}
+
+// applyYAML replaces the single scalar node whose value is from, written in the
+// node's quoting style. Block scalars are not rewritten.
+func applyYAML(raw []byte, from, to string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	var found []*yaml.Node
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && n.Value == from {
+			found = append(found, n)
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(&doc)
+	if err := occurrences(len(found)); err != nil {
+		return nil, err
+	}
+
+	n := found[0]
+	var token, replacement []byte
+	switch n.Style {
+	case yaml.DoubleQuotedStyle:
+		token, replacement = jsonString(from), jsonString(to)
+	case yaml.SingleQuotedStyle:
+		token, replacement = yamlSingleQuoted(from), yamlSingleQuoted(to)
+	case 0:
+		token, replacement = []byte(from), []byte(to)
+		var v any
+		if yaml.Unmarshal(replacement, &v) != nil || v != to {
+			// to would not read back as the same plain string
+			replacement = jsonString(to)
+		}
+	default:
+		return nil, errors.New("block scalars are not fixed")
+	}
+
+	offset, ok := lineColumnOffset(raw, n.Line, n.Column, true)
+	if !ok || !bytes.HasPrefix(raw[offset:], token) {
+		return nil, errors.New("value could not be located in the file")
+	}
+	return splice(raw, offset, len(token), replacement), nil
+}
+
+// applyCSV replaces the single field whose value is from, quoting the to
+// value only when it needs it.
+func applyCSV(raw []byte, from, to string) ([]byte, error) {
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.FieldsPerRecord = -1
+	var line, column, count int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing CSV: %w", err)
+		}
+		for i, field := range record {
+			if field == from {
+				count++
+				line, column = reader.FieldPos(i)
+			}
+		}
+	}
+	if err := occurrences(count); err != nil {
+		return nil, err
+	}
+
+	offset, ok := lineColumnOffset(raw, line, column, false)
+	if !ok {
+		return nil, errors.New("value could not be located in the file")
+	}
+	token := []byte(from)
+	if offset < len(raw) && raw[offset] == '"' {
+		token = csvQuoted(from)
+	}
+	if !bytes.HasPrefix(raw[offset:], token) {
+		return nil, errors.New("value could not be located in the file")
+	}
+	replacement := []byte(to)
+	if to == "" || strings.ContainsAny(to, ",\"\r\n") || strings.TrimSpace(to) != to {
+		replacement = csvQuoted(to)
+	}
+	return splice(raw, offset, len(token), replacement), nil
+}
+
+// occurrences reports an error unless a value was found exactly once.
+func occurrences(n int) error {
+	switch n {
+	case 1:
+		return nil
+	case 0:
+		return errors.New("value not found in the file")
+	default:
+		return fmt.Errorf("value occurs %d times in the file", n)
+	}
+}
+
+// lineColumnOffset returns the byte offset of a 1-based line and column.
+// Columns count characters when runes is true and bytes otherwise.
+func lineColumnOffset(raw []byte, line, column int, runes bool) (int, bool) {
+	offset := 0
+	for range line - 1 {
+		i := bytes.IndexByte(raw[offset:], '\n')
+		if i < 0 {
+			return 0, false
+		}
+		offset += i + 1
+	}
+	if !runes {
+		offset += column - 1
+		return offset, offset <= len(raw)
+	}
+	for range column - 1 {
+		if offset >= len(raw) {
+			return 0, false
+		}
+		_, size := utf8.DecodeRune(raw[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+func splice(raw []byte, offset, length int, replacement []byte) []byte {
+	out := make([]byte, 0, len(raw)-length+len(replacement))
+	out = append(out, raw[:offset]...)
+	out = append(out, replacement...)
+	return append(out, raw[offset+length:]...)
+}
+
+// jsonString encodes s as a JSON string literal without HTML escaping.
+func jsonString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func yamlSingleQuoted(s string) []byte {
+	return []byte("'" + strings.ReplaceAll(s, "'", "''") + "'")
+}
+
+func csvQuoted(s string) []byte {
+	return []byte(`"` + strings.ReplaceAll(s, `"`, `""`) + `"`)
+}
@@ -0,0 +1,128 @@
+package fix
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/constraints"
+)
+
+func TestFind(t *testing.T) {
+	td := config.TypeDef{
+		Name: "user",
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"status": map[string]any{"type": "string", "enum": []any{"active", "retired", "Retired"}},
+				"code":   map[string]any{"type": "string", "pattern": "^[A-Z]{3}$"},
+			},
+		},
+		Constraints: []config.ConstraintDef{
+			{Type: "format", Key: "$.contacts[*].email", Format: "email"},
+		},
+	}
+	items := map[string][]constraints.Item{"user": {
+		{TypeName: "user", FilePath: "users/a.yaml", RowIndex: -1, Data: map[string]any{
+			"status":   "ACTIVE ",
+			"code":     " ABC",
+			"contacts": []any{map[string]any{"email": "a@example.com"}, map[string]any{"email": " b@example.com"}},
+		}},
+		{TypeName: "user", FilePath: "users/b.yaml", RowIndex: -1, Data: map[string]any{
+			"status":   "RETIRED", // matches two enum values
+			"code":     "abc",     // trimming does not help
+			"contacts": []any{map[string]any{"email": " not an email "}},
+		}},
+	}}
+
+	got := Find(items, []config.TypeDef{td})
+	want := []Fix{
+		{TypeName: "user", FilePath: "users/a.yaml", RowIndex: -1, Path: "$.code", Old: " ABC", New: "ABC", Reason: "trimmed whitespace"},
+		{TypeName: "user", FilePath: "users/a.yaml", RowIndex: -1, Path: "$.contacts[1].email", Old: " b@example.com", New: "b@example.com", Reason: "trimmed whitespace"},
+		{TypeName: "user", FilePath: "users/a.yaml", RowIndex: -1, Path: "$.status", Old: "ACTIVE ", New: "active", Reason: "matched enum value"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Find = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("fix %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFind_ConflictingFixesDropped(t *testing.T) {
+	td := config.TypeDef{
+		Name: "user",
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"a": map[string]any{"type": "string", "enum": []any{"x"}},
+				"b": map[string]any{"type": "string", "enum": []any{"X"}},
+			},
+		},
+	}
+	items := map[string][]constraints.Item{"user": {
+		{TypeName: "user", FilePath: "u.json", RowIndex: -1, Data: map[string]any{"a": " x ", "b": " x "}},
+	}}
+	if got := Find(items, []config.TypeDef{td}); len(got) != 0 {
+		t.Errorf("expected no fixes for a value with two repairs, got %+v", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		raw      string
+		from, to string
+		want     string
+	}{
+		{"json", "json", "{\n  \"email\": \"  a@example.com \",\n  \"n\": 1\n}\n", "  a@example.com ", "a@example.com",
+			"{\n  \"email\": \"a@example.com\",\n  \"n\": 1\n}\n"},
+		{"yaml double quoted", "yaml", "# c\nemail: \" a@example.com\" # keep\n", " a@example.com", "a@example.com",
+			"# c\nemail: \"a@example.com\" # keep\n"},
+		{"yaml single quoted", "yaml", "name: 'Zoë'\nemail: ' it''s@example.com'\n", " it's@example.com", "it's@example.com",
+			"name: 'Zoë'\nemail: 'it''s@example.com'\n"},
+		{"yaml plain after multibyte", "yaml", "{ü: Active}\n", "Active", "active",
+			"{ü: active}\n"},
+		{"yaml plain needing quotes", "yaml", "value: nULL\n", "nULL", "null",
+			"value: \"null\"\n"},
+		{"csv quoted", "csv", "id,email\r\n1,\" a@example.com\"\r\n", " a@example.com", "a@example.com",
+			"id,email\r\n1,a@example.com\r\n"},
+		{"csv bare", "csv", "id,status\n1,Active\n", "Active", "active",
+			"id,status\n1,active\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.raw), tt.input, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Apply = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply_Ambiguous(t *testing.T) {
+	tests := []struct {
+		name, input, raw, from, want string
+	}{
+		{"json value twice", "json", `{"a": " x", "b": " x"}`, " x", "value occurs 2 times in the file"},
+		{"json key and value", "json", `{"Active": "Active"}`, "Active", "value occurs 2 times in the file"},
+		{"yaml missing", "yaml", "a: b\n", "c", "value not found in the file"},
+		{"yaml block scalar", "yaml", "a: |\n  text\n", "text\n", "block scalars are not fixed"},
+		{"csv two rows", "csv", "id,s\n1,x \n2,x \n", "x ", "value occurs 2 times in the file"},
+		{"text", "text", "x", "x", "text files are not fixed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(tt.raw), tt.input, tt.from, strings.TrimSpace(tt.from))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Apply error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package infer derives a candidate JSON Schema from sample data items, as a
+// starting point for a type's schema.
+package infer
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// Schema returns a JSON Schema describing every sample: each value's type
+// (a list of types when the samples disagree), the properties of objects
+// with the keys present in every sample listed as required, and the items of
+// arrays. Samples are decoded JSON or YAML values, so numbers are int or
+// float64; a float64 without a fractional part counts as an integer.
+func Schema(samples []any) map[string]any {
+	s := map[string]any{}
+	kinds := make(map[string]bool)
+	var objects []map[string]any
+	var elems []any
+	for _, v := range samples {
+		k := kind(v)
+		kinds[k] = true
+		switch val := v.(type) {
+		case map[string]any:
+			objects = append(objects, val)
+		case []any:
+			elems = append(elems, val...)
+		}
+	}
+	if kinds["integer"] && kinds["number"] {
+		delete(kinds, "integer")
+	}
+
+	switch types := slices.Sorted(maps.Keys(kinds)); len(types) {
+	case 0:
+		return s
+	case 1:
+		s["type"] = types[0]
+	default:
+		s["type"] = types
+	}
+
+	if len(objects) > 0 {
+		props, required := properties(objects)
+		s["properties"] = props
+		if len(required) > 0 {
+			s["required"] = required
+		}
+	}
+	if len(elems) > 0 {
+		s["items"] = Schema(elems)
+	}
+	return s
+}
+
+// properties infers the schema of each key found in objects from the values
+// it has, and returns the sorted keys present in all of them.
+func properties(objects []map[string]any) (map[string]any, []string) {
+	values := make(map[string][]any)
+	for _, obj := range objects {
+		for k, v := range obj {
+			values[k] = append(values[k], v)
+		}
+	}
+	props := make(map[string]any, len(values))
+	var required []string
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		props[k] = Schema(values[k])
+		if len(values[k]) == len(objects) {
+			required = append(required, k)
+		}
+	}
+	return props, required
+}
+
+// kind returns the JSON Schema type name of a decoded value.
+func kind(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		if val == math.Trunc(val) && !math.IsInf(val, 0) {
+			return "integer"
+		}
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return "string"
+	}
+}
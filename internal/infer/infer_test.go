@@ -0,0 +1,56 @@
+package infer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchema_TwoObjects(t *testing.T) {
+	samples := []any{
+		map[string]any{"id": "a", "name": "Alpha", "count": 1, "tags": []any{"x"}, "owner": map[string]any{"team": "core"}},
+		map[string]any{"id": "b", "count": 2.5, "tags": []any{}, "owner": map[string]any{"team": "web", "lead": "kim"}, "note": nil},
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "string"},
+			"name":  map[string]any{"type": "string"},
+			"count": map[string]any{"type": "number"},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"owner": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"team": map[string]any{"type": "string"},
+					"lead": map[string]any{"type": "string"},
+				},
+				"required": []string{"team"},
+			},
+			"note": map[string]any{"type": "null"},
+		},
+		"required": []string{"count", "id", "owner", "tags"},
+	}
+	if got := Schema(samples); !reflect.DeepEqual(got, want) {
+		t.Errorf("Schema() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestSchema_MixedTypes(t *testing.T) {
+	got := Schema([]any{
+		map[string]any{"v": 1, "w": 2.0},
+		map[string]any{"v": "one", "w": nil},
+	})
+	props := got["properties"].(map[string]any)
+	if typ := props["v"].(map[string]any)["type"]; !reflect.DeepEqual(typ, []string{"integer", "string"}) {
+		t.Errorf("v type = %v, want [integer string]", typ)
+	}
+	// 2.0 has no fractional part, so it reads as an integer
+	if typ := props["w"].(map[string]any)["type"]; !reflect.DeepEqual(typ, []string{"integer", "null"}) {
+		t.Errorf("w type = %v, want [integer null]", typ)
+	}
+}
+
+func TestSchema_NoSamples(t *testing.T) {
+	if got := Schema(nil); len(got) != 0 {
+		t.Errorf("expected an empty schema, got %v", got)
+	}
+}
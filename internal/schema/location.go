@@ -0,0 +1,252 @@
+package schema
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// ValidationError is a schema validation failure of one item.
+type ValidationError struct {
+	Instance string // JSON Pointer (RFC 6901) of the failing value in the item; "" for the item itself
+	Message  string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// instanceLocation returns the JSON Pointer of the value in data that failed
+// validation. jsonschema-go only reports where in the schema a failure
+// happened, wrapping its error once per schema visited ("validating root:
+// validating /properties/meta: required: ..."), so the instance path is
+// rebuilt by walking data along those schema locations. Where the failing
+// array element or object member cannot be told from the schema location
+// alone (items, patternProperties, additionalProperties), each candidate is
+// validated against the subschema and the first failing one is followed. The
+// walk stops at the deepest value it can determine.
+func instanceLocation(root map[string]any, data any, msg string) string {
+	line, _, _ := strings.Cut(msg, "\n")
+	var ptr strings.Builder
+	cur := data
+	prev := ""
+	for _, loc := range schemaLocations(root, line) {
+		if !strings.HasPrefix(loc, prev+"/") {
+			prev = loc // a $ref to elsewhere in the schema applies to the same value
+			continue
+		}
+		tokens := strings.Split(loc[len(prev)+1:], "/")
+		for i := 0; i < len(tokens); i++ {
+			at := prev + "/" + strings.Join(tokens[:i+1], "/")
+			switch tokens[i] {
+			case "properties", "prefixItems":
+				if i+1 == len(tokens) {
+					return ptr.String()
+				}
+				i++
+				next, ok := child(cur, unescapeToken(tokens[i]))
+				if !ok {
+					return ptr.String()
+				}
+				ptr.WriteString("/" + escapeToken(unescapeToken(tokens[i])))
+				cur = next
+			case "items", "additionalProperties":
+				parent := strings.TrimSuffix(at, "/"+tokens[i])
+				name, next, ok := firstFailing(root, at, cur, func(name string) bool {
+					return tokens[i] == "items" || !declaredMember(root, parent, name)
+				})
+				if !ok {
+					return ptr.String()
+				}
+				ptr.WriteString("/" + escapeToken(name))
+				cur = next
+			case "patternProperties":
+				if i+1 == len(tokens) {
+					return ptr.String()
+				}
+				i++
+				re, err := regexp.Compile(unescapeToken(tokens[i]))
+				if err != nil {
+					return ptr.String()
+				}
+				name, next, ok := firstFailing(root, at+"/"+tokens[i], cur, re.MatchString)
+				if !ok {
+					return ptr.String()
+				}
+				ptr.WriteString("/" + escapeToken(name))
+				cur = next
+			case "allOf", "anyOf", "oneOf", "$defs", "definitions", "dependentSchemas":
+				i++ // the subschema applies to the same value
+			case "not", "if", "then", "else":
+			default:
+				return ptr.String()
+			}
+		}
+		prev = loc
+	}
+	return ptr.String()
+}
+
+// schemaLocations splits the leading "validating <location>: " wrappers off
+// line and returns the locations, with "root" as "". A location may itself
+// contain ": ", so each candidate is accepted only if it exists in root.
+func schemaLocations(root map[string]any, line string) []string {
+	var locs []string
+	for {
+		rest, ok := strings.CutPrefix(line, "validating ")
+		if !ok {
+			return locs
+		}
+		found := false
+		for i := 0; i+2 <= len(rest); i++ {
+			if rest[i:i+2] != ": " {
+				continue
+			}
+			loc := rest[:i]
+			if loc == "root" {
+				loc = ""
+			} else if _, ok := lookup(root, loc); !ok {
+				continue
+			}
+			locs = append(locs, loc)
+			line = rest[i+2:]
+			found = true
+			break
+		}
+		if !found {
+			return locs
+		}
+	}
+}
+
+// lookup resolves the JSON Pointer ptr within v.
+func lookup(v any, ptr string) (any, bool) {
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, false
+	}
+	for tok := range strings.SplitSeq(ptr[1:], "/") {
+		next, ok := child(v, unescapeToken(tok))
+		if !ok {
+			return nil, false
+		}
+		v = next
+	}
+	return v, true
+}
+
+// child returns the member name of an object or the element at index name of
+// an array.
+func child(v any, name string) (any, bool) {
+	switch t := v.(type) {
+	case map[string]any:
+		c, ok := t[name]
+		return c, ok
+	case []any:
+		i, err := strconv.Atoi(name)
+		if err != nil || i < 0 || i >= len(t) {
+			return nil, false
+		}
+		return t[i], true
+	}
+	return nil, false
+}
+
+// firstFailing returns the first member or element of v, in key or index
+// order, that is selected by include and does not validate against the
+// subschema at ptr in root.
+func firstFailing(root map[string]any, ptr string, v any, include func(name string) bool) (string, any, bool) {
+	sub, ok := lookup(root, ptr)
+	if !ok {
+		return "", nil, false
+	}
+	resolved, ok := resolveSubschema(root, sub)
+	if !ok {
+		return "", nil, false
+	}
+	var names []string
+	switch t := v.(type) {
+	case map[string]any:
+		names = slices.Sorted(maps.Keys(t))
+	case []any:
+		for i := range t {
+			names = append(names, strconv.Itoa(i))
+		}
+	}
+	for _, name := range names {
+		if !include(name) {
+			continue
+		}
+		c, _ := child(v, name)
+		if resolved.Validate(c) != nil {
+			return name, c, true
+		}
+	}
+	return "", nil, false
+}
+
+// resolveSubschema resolves sub on its own, carrying over the $defs and
+// definitions of root so that references to them still resolve.
+func resolveSubschema(root map[string]any, sub any) (*jsonschema.Resolved, bool) {
+	m, ok := sub.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	standalone := make(map[string]any, len(m)+2)
+	for _, k := range []string{"$defs", "definitions"} {
+		if defs, ok := root[k]; ok {
+			standalone[k] = defs
+		}
+	}
+	for k, v := range m {
+		standalone[k] = v
+	}
+	raw, err := json.Marshal(standalone)
+	if err != nil {
+		return nil, false
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, false
+	}
+	resolved, err := s.Resolve(nil)
+	return resolved, err == nil
+}
+
+// declaredMember reports whether the object schema at ptr in root covers name
+// with properties or patternProperties, so additionalProperties does not apply.
+func declaredMember(root map[string]any, ptr, name string) bool {
+	obj := any(root)
+	if ptr != "" {
+		var ok bool
+		if obj, ok = lookup(root, ptr); !ok {
+			return false
+		}
+	}
+	m, _ := obj.(map[string]any)
+	if props, ok := m["properties"].(map[string]any); ok {
+		if _, ok := props[name]; ok {
+			return true
+		}
+	}
+	patterns, _ := m["patternProperties"].(map[string]any)
+	for pat := range patterns {
+		if re, err := regexp.Compile(pat); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func unescapeToken(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
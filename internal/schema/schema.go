@@ -2,7 +2,6 @@ package schema
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -12,11 +11,28 @@ import (
 	"github.com/google/jsonschema-go/jsonschema"
 )
 
+// dialectURIs maps each schema_dialect name to the $schema URI that selects
+// it when the schema is resolved.
+var dialectURIs = map[string]string{
+	"draft-07": "http://json-schema.org/draft-07/schema#",
+	"2020-12":  "https://json-schema.org/draft/2020-12/schema",
+}
+
 // ValidateItem validates a single data item against the type's schema.
 // strictMode is "DISABLED", "ENABLED", or "FORCE".
-// Returns validation errors.
-func ValidateItem(schemaMap map[string]any, data any, strictMode string) []error {
+// dialect is "draft-07" or "2020-12" to set the schema's $schema, replacing
+// any it declares, or "" to keep the schema's own (2020-12 when absent).
+// Returns validation errors; a failure of the data is a *ValidationError
+// carrying the location of the failing value.
+func ValidateItem(schemaMap map[string]any, data any, strictMode, dialect string) []error {
 	adjusted := ApplyStrictMode(schemaMap, strictMode)
+	if dialect != "" {
+		uri, ok := dialectURIs[dialect]
+		if !ok {
+			return []error{fmt.Errorf("unknown schema dialect %q", dialect)}
+		}
+		adjusted["$schema"] = uri
+	}
 
 	schemaJSON, err := json.Marshal(adjusted)
 	if err != nil {
@@ -34,7 +50,10 @@ func ValidateItem(schemaMap map[string]any, data any, strictMode string) []error
 	}
 
 	if err := resolved.Validate(data); err != nil {
-		return []error{errors.New(normalizeValidationMessage(err.Error()))}
+		return []error{&ValidationError{
+			Instance: instanceLocation(adjusted, data, err.Error()),
+			Message:  normalizeValidationMessage(err.Error()),
+		}}
 	}
 
 	return nil
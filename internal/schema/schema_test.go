@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -20,7 +21,7 @@ func TestValidateItem_BasicValid(t *testing.T) {
 		"age":  float64(30),
 	}
 
-	errs := ValidateItem(s, data, "DISABLED")
+	errs := ValidateItem(s, data, "DISABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("expected no errors, got %v", errs)
 	}
@@ -39,7 +40,7 @@ func TestValidateItem_BasicInvalid(t *testing.T) {
 		"age": float64(30),
 	}
 
-	errs := ValidateItem(s, data, "DISABLED")
+	errs := ValidateItem(s, data, "DISABLED", "")
 	if len(errs) == 0 {
 		t.Error("expected validation errors for missing required field")
 	}
@@ -57,7 +58,7 @@ func TestValidateItem_TypeMismatch(t *testing.T) {
 		"age": "not a number",
 	}
 
-	errs := ValidateItem(s, data, "DISABLED")
+	errs := ValidateItem(s, data, "DISABLED", "")
 	if len(errs) == 0 {
 		t.Error("expected validation errors for type mismatch")
 	}
@@ -76,7 +77,7 @@ func TestStrictMode_Disabled_AllowsExtraProperties(t *testing.T) {
 		"extra": "value",
 	}
 
-	errs := ValidateItem(s, data, "DISABLED")
+	errs := ValidateItem(s, data, "DISABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("DISABLED mode should allow extra properties, got %v", errs)
 	}
@@ -95,7 +96,7 @@ func TestStrictMode_Enabled_ForbidsExtraProperties(t *testing.T) {
 		"extra": "value",
 	}
 
-	errs := ValidateItem(s, data, "ENABLED")
+	errs := ValidateItem(s, data, "ENABLED", "")
 	if len(errs) == 0 {
 		t.Error("ENABLED mode should forbid extra properties when not explicitly set")
 	}
@@ -115,7 +116,7 @@ func TestStrictMode_Enabled_RespectsExplicitTrue(t *testing.T) {
 		"extra": "value",
 	}
 
-	errs := ValidateItem(s, data, "ENABLED")
+	errs := ValidateItem(s, data, "ENABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("ENABLED mode should respect explicit additionalProperties:true, got %v", errs)
 	}
@@ -135,12 +136,44 @@ func TestStrictMode_Force_OverridesExplicitTrue(t *testing.T) {
 		"extra": "value",
 	}
 
-	errs := ValidateItem(s, data, "FORCE")
+	errs := ValidateItem(s, data, "FORCE", "")
 	if len(errs) == 0 {
 		t.Error("FORCE mode should override explicit additionalProperties:true")
 	}
 }
 
+func TestStrictMode_PatternPropertiesAllowMatchingKeys(t *testing.T) {
+	schemaMap := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+		},
+		"patternProperties": map[string]any{
+			"^x-": map[string]any{"type": "string"},
+		},
+	}
+
+	for _, mode := range []string{"ENABLED", "FORCE"} {
+		// extra keys matching a pattern are allowed
+		data := map[string]any{"name": "alice", "x-team": "core", "x-owner": "bob"}
+		if errs := ValidateItem(schemaMap, data, mode, ""); len(errs) != 0 {
+			t.Errorf("%s: expected pattern-matched keys to be allowed, got: %v", mode, errs)
+		}
+
+		// pattern-matched keys are still validated against their subschema
+		data = map[string]any{"name": "alice", "x-team": 1}
+		if errs := ValidateItem(schemaMap, data, mode, ""); len(errs) == 0 {
+			t.Errorf("%s: expected error for pattern-matched key with wrong type", mode)
+		}
+
+		// keys matched by neither properties nor patternProperties are rejected
+		data = map[string]any{"name": "alice", "team": "core"}
+		if errs := ValidateItem(schemaMap, data, mode, ""); len(errs) == 0 {
+			t.Errorf("%s: expected error for unknown key", mode)
+		}
+	}
+}
+
 func TestStrictMode_Enabled_NestedObjects(t *testing.T) {
 	s := map[string]any{
 		"type": "object",
@@ -161,7 +194,7 @@ func TestStrictMode_Enabled_NestedObjects(t *testing.T) {
 		},
 	}
 
-	errs := ValidateItem(s, data, "ENABLED")
+	errs := ValidateItem(s, data, "ENABLED", "")
 	if len(errs) == 0 {
 		t.Error("ENABLED mode should forbid extra properties in nested objects")
 	}
@@ -188,7 +221,7 @@ func TestStrictMode_Force_NestedObjects(t *testing.T) {
 		},
 	}
 
-	errs := ValidateItem(s, data, "FORCE")
+	errs := ValidateItem(s, data, "FORCE", "")
 	if len(errs) == 0 {
 		t.Error("FORCE mode should override additionalProperties:true in nested objects")
 	}
@@ -203,13 +236,13 @@ func TestValidateItem_ArraySchema(t *testing.T) {
 	}
 
 	valid := []any{"a", "b", "c"}
-	errs := ValidateItem(s, valid, "DISABLED")
+	errs := ValidateItem(s, valid, "DISABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("expected no errors for valid array, got %v", errs)
 	}
 
 	invalid := []any{"a", float64(1)}
-	errs = ValidateItem(s, invalid, "DISABLED")
+	errs = ValidateItem(s, invalid, "DISABLED", "")
 	if len(errs) == 0 {
 		t.Error("expected validation errors for array with wrong item type")
 	}
@@ -221,12 +254,12 @@ func TestValidateItem_StringSchema(t *testing.T) {
 		"minLength": float64(3),
 	}
 
-	errs := ValidateItem(s, "hello", "DISABLED")
+	errs := ValidateItem(s, "hello", "DISABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("expected no errors, got %v", errs)
 	}
 
-	errs = ValidateItem(s, "hi", "DISABLED")
+	errs = ValidateItem(s, "hi", "DISABLED", "")
 	if len(errs) == 0 {
 		t.Error("expected validation errors for string shorter than minLength")
 	}
@@ -239,12 +272,12 @@ func TestValidateItem_NumberSchema(t *testing.T) {
 		"maximum": float64(100),
 	}
 
-	errs := ValidateItem(s, float64(50), "DISABLED")
+	errs := ValidateItem(s, float64(50), "DISABLED", "")
 	if len(errs) != 0 {
 		t.Errorf("expected no errors, got %v", errs)
 	}
 
-	errs = ValidateItem(s, float64(200), "DISABLED")
+	errs = ValidateItem(s, float64(200), "DISABLED", "")
 	if len(errs) == 0 {
 		t.Error("expected validation errors for number exceeding maximum")
 	}
@@ -262,7 +295,7 @@ func TestValidateItem_NumberSchema_RationalErrorIsNormalized(t *testing.T) {
 		"maximum": float64(6),
 	}
 
-	errs := ValidateItem(s, float64(95.5), "DISABLED")
+	errs := ValidateItem(s, float64(95.5), "DISABLED", "")
 	if len(errs) == 0 {
 		t.Fatal("expected validation errors for number exceeding maximum")
 	}
@@ -355,8 +388,99 @@ func TestStrictMode_ArrayOfObjects(t *testing.T) {
 		map[string]any{"id": float64(1), "extra": "field"},
 	}
 
-	errs := ValidateItem(s, data, "ENABLED")
+	errs := ValidateItem(s, data, "ENABLED", "")
 	if len(errs) == 0 {
 		t.Error("ENABLED mode should forbid extra properties in array item objects")
 	}
 }
+
+func TestValidateItem_InstanceLocation(t *testing.T) {
+	s := map[string]any{
+		"type":     "object",
+		"required": []any{"meta"},
+		"properties": map[string]any{
+			"meta": map[string]any{
+				"type":     "object",
+				"required": []any{"owner"},
+				"properties": map[string]any{
+					"owner": map[string]any{
+						"type":       "object",
+						"required":   []any{"email"},
+						"properties": map[string]any{"email": map[string]any{"type": "string"}},
+					},
+				},
+			},
+			"users": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/user"}},
+			"a/b":   map[string]any{"type": "string"},
+			"tuple": map[string]any{"type": "array", "prefixItems": []any{map[string]any{"type": "string"}}},
+		},
+		"patternProperties": map[string]any{"^x-": map[string]any{"type": "string"}},
+		"$defs": map[string]any{
+			"user": map[string]any{"type": "object", "required": []any{"id"}},
+		},
+	}
+	meta := map[string]any{"owner": map[string]any{"email": "a@example.com"}}
+	tests := []struct {
+		name string
+		data map[string]any
+		want string
+	}{
+		{"nested required", map[string]any{"meta": map[string]any{"owner": map[string]any{}}}, "/meta/owner"},
+		{"nested type", map[string]any{"meta": map[string]any{"owner": map[string]any{"email": 1}}}, "/meta/owner/email"},
+		{"root required", map[string]any{}, ""},
+		{"array element via ref", map[string]any{"meta": meta, "users": []any{map[string]any{"id": 1}, map[string]any{}}}, "/users/1"},
+		{"escaped name", map[string]any{"meta": meta, "a/b": 1}, "/a~1b"},
+		{"prefix item", map[string]any{"meta": meta, "tuple": []any{1}}, "/tuple/0"},
+		{"pattern property", map[string]any{"meta": meta, "x-a": "ok", "x-b": 2}, "/x-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateItem(s, tt.data, "DISABLED", "")
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %v", errs)
+			}
+			var verr *ValidationError
+			if !errors.As(errs[0], &verr) {
+				t.Fatalf("expected a *ValidationError, got %T", errs[0])
+			}
+			if verr.Instance != tt.want {
+				t.Errorf("Instance = %q, want %q (%s)", verr.Instance, tt.want, verr.Message)
+			}
+		})
+	}
+}
+
+func TestValidateItem_Draft07Dialect(t *testing.T) {
+	// A tuple written the draft-07 way: items as an array, with
+	// additionalItems forbidding anything after the second element.
+	s := map[string]any{
+		"type": "object",
+		"definitions": map[string]any{
+			"code": map[string]any{"type": "string", "pattern": "^[A-Z]{3}$"},
+		},
+		"properties": map[string]any{
+			"pair": map[string]any{
+				"type":            "array",
+				"items":           []any{map[string]any{"$ref": "#/definitions/code"}, map[string]any{"type": "integer"}},
+				"additionalItems": false,
+			},
+		},
+	}
+
+	if errs := ValidateItem(s, map[string]any{"pair": []any{"USD", float64(2)}}, "DISABLED", "draft-07"); len(errs) != 0 {
+		t.Fatalf("expected valid tuple under draft-07, got %v", errs)
+	}
+	for _, pair := range [][]any{{"usd", float64(2)}, {"USD", "2"}, {"USD", float64(2), float64(3)}} {
+		if errs := ValidateItem(s, map[string]any{"pair": pair}, "DISABLED", "draft-07"); len(errs) == 0 {
+			t.Errorf("expected %v to fail under draft-07", pair)
+		}
+	}
+
+	// 2020-12, the default, has no array form of items, so the tuple is not
+	// enforced at all.
+	for _, dialect := range []string{"", "2020-12"} {
+		if errs := ValidateItem(s, map[string]any{"pair": []any{"usd", float64(2), float64(3)}}, "DISABLED", dialect); len(errs) != 0 {
+			t.Errorf("dialect %q: expected the draft-07 tuple to be ignored, got %v", dialect, errs)
+		}
+	}
+}
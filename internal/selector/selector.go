@@ -2,23 +2,39 @@ package selector
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// captureNameRe matches the name of a $path.<name> selector.
+var captureNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 // segment represents one step in a selector path.
 type segment struct {
 	field    string // field name to access on an object
 	wildcard bool   // true when the segment is [*] (iterate array elements)
+	length   bool   // true when the segment is a terminal .length (count array elements)
+	indexed  bool   // true when the segment is [N] (select one array element)
+	index    int    // element index for [N]; negative counts from the end
 }
 
 // Selector is a parsed JSONPath-like selector.
 type Selector struct {
 	raw      string
 	segments []segment
+	capture  bool // a $path.<name> selector, read from path captures instead of data
 }
 
 // Parse parses a selector string into a Selector.
-// Valid forms: "$", "$.field", "$.a.b.c", "$.items[*].id", "$.a[*].b[*].c".
+// Valid forms: "$", "$.field", "$.a.b.c", "$.items[*].id", "$.a[*].b[*].c",
+// "$.items[0].id", "$.versions[-1].tag".
+// Field names containing dots or brackets may be quoted: `$["app.version"]`,
+// `$.meta['a[0]']`, or `$.['app.version']`.
+// A terminal unquoted ".length" counts array elements: "$.items.length". Use
+// `$["length"]` to select a field literally named length.
+// "$path.<name>" selects the path capture path.<name> of an item rather than
+// a data field; see PathCapture.
 func Parse(sel string) (*Selector, error) {
 	if sel == "" {
 		return nil, fmt.Errorf("selector: empty selector")
@@ -26,6 +42,12 @@ func Parse(sel string) (*Selector, error) {
 	if sel[0] != '$' {
 		return nil, fmt.Errorf("selector: must start with '$': %s", sel)
 	}
+	if name, ok := strings.CutPrefix(sel, "$path."); ok {
+		if !captureNameRe.MatchString(name) {
+			return nil, fmt.Errorf("selector: invalid path capture name %q in: %s", name, sel)
+		}
+		return &Selector{raw: sel, segments: []segment{{field: "path." + name}}, capture: true}, nil
+	}
 
 	s := &Selector{raw: sel}
 
@@ -40,6 +62,15 @@ func Parse(sel string) (*Selector, error) {
 			if rest == "" {
 				return nil, fmt.Errorf("selector: trailing dot: %s", sel)
 			}
+			if isQuotedField(rest) {
+				name, n, err := parseQuotedField(rest, sel)
+				if err != nil {
+					return nil, err
+				}
+				s.segments = append(s.segments, segment{field: name})
+				rest = rest[n:]
+				continue
+			}
 			// read field name (up to next '.', '[', or end)
 			end := strings.IndexAny(rest, ".[")
 			if end == -1 {
@@ -49,11 +80,33 @@ func Parse(sel string) (*Selector, error) {
 			if name == "" {
 				return nil, fmt.Errorf("selector: empty field name: %s", sel)
 			}
-			s.segments = append(s.segments, segment{field: name})
+			if name == "length" && end == len(rest) {
+				s.segments = append(s.segments, segment{length: true})
+			} else {
+				s.segments = append(s.segments, segment{field: name})
+			}
 			rest = rest[end:]
 		} else if strings.HasPrefix(rest, "[*]") {
 			s.segments = append(s.segments, segment{wildcard: true})
 			rest = rest[3:]
+		} else if isQuotedField(rest) {
+			name, n, err := parseQuotedField(rest, sel)
+			if err != nil {
+				return nil, err
+			}
+			s.segments = append(s.segments, segment{field: name})
+			rest = rest[n:]
+		} else if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("selector: unterminated index: %s", sel)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || strings.HasPrefix(rest[1:end], "+") {
+				return nil, fmt.Errorf("selector: invalid index %q in: %s", rest[1:end], sel)
+			}
+			s.segments = append(s.segments, segment{indexed: true, index: i})
+			rest = rest[end+1:]
 		} else {
 			return nil, fmt.Errorf("selector: unexpected character %q in: %s", rest[0], sel)
 		}
@@ -62,14 +115,64 @@ func Parse(sel string) (*Selector, error) {
 	return s, nil
 }
 
+// isQuotedField reports whether rest begins a bracketed, quoted field name.
+func isQuotedField(rest string) bool {
+	return strings.HasPrefix(rest, `["`) || strings.HasPrefix(rest, `['`)
+}
+
+// parseQuotedField reads a bracketed field name such as ["a.b"] or ['a.b'] at
+// the start of rest. A backslash escapes the following character, so \" and
+// \\ produce a literal quote and backslash. It returns the unescaped name and
+// the number of bytes consumed.
+func parseQuotedField(rest, sel string) (string, int, error) {
+	quote := rest[1]
+	var name strings.Builder
+	for i := 2; i < len(rest); i++ {
+		c := rest[i]
+		switch {
+		case c == '\\':
+			i++
+			if i == len(rest) {
+				return "", 0, fmt.Errorf("selector: unterminated quoted field: %s", sel)
+			}
+			name.WriteByte(rest[i])
+		case c == quote:
+			if i+1 == len(rest) || rest[i+1] != ']' {
+				return "", 0, fmt.Errorf("selector: expected ']' after quoted field: %s", sel)
+			}
+			if name.Len() == 0 {
+				return "", 0, fmt.Errorf("selector: empty field name: %s", sel)
+			}
+			return name.String(), i + 2, nil
+		default:
+			name.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("selector: unterminated quoted field: %s", sel)
+}
+
 // String returns the original selector string.
 func (s *Selector) String() string {
 	return s.raw
 }
 
-// IsScalar returns true if the selector will always yield exactly one value
-// (no [*] wildcard in the path).
+// PathCapture returns the capture key, such as "path.region", read by a
+// "$path.region" selector. ok is false for a selector over data. A path
+// selector is evaluated against a map of an item's captures keyed the same way.
+func (s *Selector) PathCapture() (key string, ok bool) {
+	if !s.capture {
+		return "", false
+	}
+	return s.segments[0].field, true
+}
+
+// IsScalar returns true if the selector yields at most one value (no [*]
+// wildcard in the path, or a terminal .length). Index segments such as [0]
+// and [-1] keep a selector scalar.
 func (s *Selector) IsScalar() bool {
+	if s.IsLength() {
+		return true
+	}
 	for _, seg := range s.segments {
 		if seg.wildcard {
 			return false
@@ -78,6 +181,21 @@ func (s *Selector) IsScalar() bool {
 	return true
 }
 
+// IsLength returns true if the selector ends with the .length operator.
+func (s *Selector) IsLength() bool {
+	return len(s.segments) > 0 && s.segments[len(s.segments)-1].length
+}
+
+// RootField returns the top-level field the selector reads, such as "team"
+// for "$.team.id". ok is false for "$", a $path. selector, or a selector that
+// does not start with a field.
+func (s *Selector) RootField() (name string, ok bool) {
+	if s.capture || len(s.segments) == 0 || s.segments[0].field == "" {
+		return "", false
+	}
+	return s.segments[0].field, true
+}
+
 // Evaluate applies the selector to data and returns all matched values.
 // Missing fields yield an empty slice, not an error.
 func (s *Selector) Evaluate(data any) ([]any, error) {
@@ -85,6 +203,141 @@ func (s *Selector) Evaluate(data any) ([]any, error) {
 	return results, nil
 }
 
+// Match is a value resolved by EvaluateMatches together with the concrete
+// path it was found at.
+type Match struct {
+	Path  string // the selector with [*] and negative indexes resolved, such as $.members[2].id
+	Value any
+}
+
+// EvaluateMatches applies the selector like Evaluate but also returns where
+// each value was found, so callers can point at a specific array element.
+// A terminal .length yields a single match whose path is the selector itself.
+func (s *Selector) EvaluateMatches(data any) []Match {
+	current := []Match{{Path: "$", Value: data}}
+	for _, seg := range s.segments {
+		if seg.length {
+			values := make([]any, len(current))
+			for i, m := range current {
+				values[i] = m.Value
+			}
+			return []Match{{Path: s.raw, Value: countElements(values)}}
+		}
+		var next []Match
+		for _, m := range current {
+			switch {
+			case seg.wildcard:
+				arr, ok := m.Value.([]any)
+				if !ok {
+					continue
+				}
+				for i, v := range arr {
+					next = append(next, Match{Path: fmt.Sprintf("%s[%d]", m.Path, i), Value: v})
+				}
+			case seg.indexed:
+				arr, ok := m.Value.([]any)
+				if !ok {
+					continue
+				}
+				i := seg.index
+				if i < 0 {
+					i += len(arr)
+				}
+				if i < 0 || i >= len(arr) {
+					continue
+				}
+				next = append(next, Match{Path: fmt.Sprintf("%s[%d]", m.Path, i), Value: arr[i]})
+			default:
+				obj, ok := m.Value.(map[string]any)
+				if !ok {
+					continue
+				}
+				v, exists := obj[seg.field]
+				if !exists {
+					continue
+				}
+				path := m.Path + fieldPath(seg.field)
+				if s.capture {
+					path = s.raw
+				}
+				next = append(next, Match{Path: path, Value: v})
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// fieldPath renders a field segment as .name, or as a quoted ["name"] when
+// the name would not parse back unquoted.
+func fieldPath(name string) string {
+	if name != "length" && !strings.ContainsAny(name, `.[]'"\`) {
+		return "." + name
+	}
+	return `["` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"]`
+}
+
+// MissingPathError reports the first field that could not be found during
+// strict evaluation.
+type MissingPathError struct {
+	Selector string // the selector being evaluated
+	Field    string // name of the missing field
+	Leaf     bool   // true when the missing field is the final segment
+}
+
+// Error implements the error interface.
+func (e *MissingPathError) Error() string {
+	if e.Leaf {
+		return fmt.Sprintf("selector %s: field %q is missing", e.Selector, e.Field)
+	}
+	return fmt.Sprintf("selector %s: intermediate field %q is missing", e.Selector, e.Field)
+}
+
+// EvaluateStrict applies the selector like Evaluate, but returns a
+// *MissingPathError when a field along the path is absent from an object,
+// distinguishing a missing intermediate object from a missing leaf.
+// Values that are not objects or arrays where one is expected are skipped.
+func (s *Selector) EvaluateStrict(data any) ([]any, error) {
+	current := []any{data}
+	last := len(s.segments) - 1
+	if s.IsLength() {
+		last-- // the field before .length is the leaf
+	}
+	for i, seg := range s.segments {
+		if seg.length {
+			return []any{countElements(current)}, nil
+		}
+		var next []any
+		for _, val := range current {
+			if seg.wildcard {
+				arr, ok := val.([]any)
+				if !ok {
+					continue
+				}
+				next = append(next, arr...)
+				continue
+			}
+			if seg.indexed {
+				if v, ok := arrayElement(val, seg.index); ok {
+					next = append(next, v)
+				}
+				continue
+			}
+			m, ok := val.(map[string]any)
+			if !ok {
+				continue
+			}
+			v, exists := m[seg.field]
+			if !exists {
+				return nil, &MissingPathError{Selector: s.raw, Field: seg.field, Leaf: i == last}
+			}
+			next = append(next, v)
+		}
+		current = next
+	}
+	return current, nil
+}
+
 // resolve recursively applies the remaining segments to a set of current values.
 func resolve(current []any, segments []segment) []any {
 	if len(segments) == 0 {
@@ -94,6 +347,10 @@ func resolve(current []any, segments []segment) []any {
 	seg := segments[0]
 	rest := segments[1:]
 
+	if seg.length {
+		return []any{countElements(current)}
+	}
+
 	var next []any
 	for _, val := range current {
 		if seg.wildcard {
@@ -102,6 +359,10 @@ func resolve(current []any, segments []segment) []any {
 				continue // not an array — skip
 			}
 			next = append(next, arr...)
+		} else if seg.indexed {
+			if v, ok := arrayElement(val, seg.index); ok {
+				next = append(next, v)
+			}
 		} else {
 			m, ok := val.(map[string]any)
 			if !ok {
@@ -117,3 +378,35 @@ func resolve(current []any, segments []segment) []any {
 
 	return resolve(next, rest)
 }
+
+// arrayElement returns element i of val when val is an array. A negative i
+// counts from the end, so -1 is the last element. Non-arrays and indexes out
+// of range yield nothing.
+func arrayElement(val any, i int) (any, bool) {
+	arr, ok := val.([]any)
+	if !ok {
+		return nil, false
+	}
+	if i < 0 {
+		i += len(arr)
+	}
+	if i < 0 || i >= len(arr) {
+		return nil, false
+	}
+	return arr[i], true
+}
+
+// countElements returns the total number of elements across the arrays in
+// values as an int, matching how integers in JSON and YAML data are decoded.
+// CSV numbers decode as float64 instead; numeric comparisons convert both
+// sides to float64, so a length still equals a CSV count. Non-array values
+// (including missing fields) contribute nothing, so the count is 0.
+func countElements(values []any) int {
+	n := 0
+	for _, v := range values {
+		if arr, ok := v.([]any); ok {
+			n += len(arr)
+		}
+	}
+	return n
+}
@@ -17,6 +17,10 @@ func TestParseValid(t *testing.T) {
 		{"$.items[*].id", false, 3},
 		{"$.a[*].b[*].c", false, 5},
 		{"$[*]", false, 1},
+		{"$[0]", true, 1},
+		{"$.a[0]", true, 2},
+		{"$.versions[-1].tag", true, 3},
+		{"$.a[*].b[-2]", false, 4},
 	}
 	for _, tc := range cases {
 		s, err := Parse(tc.input)
@@ -42,8 +46,16 @@ func TestParseInvalid(t *testing.T) {
 		"$.",
 		"$.a.",
 		"$..a",
-		"$[0]",
-		"$.a[0]",
+		"$[]",
+		"$[x]",
+		"$[1.5]",
+		"$[-]",
+		"$[+1]",
+		"$.a[0",
+		"$path.",
+		"$path.a.b",
+		"$path.1x",
+		"$path.a[*]",
 	}
 	for _, input := range cases {
 		_, err := Parse(input)
@@ -53,6 +65,95 @@ func TestParseInvalid(t *testing.T) {
 	}
 }
 
+func TestParsePathCapture(t *testing.T) {
+	s, err := Parse("$path.region")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if key, ok := s.PathCapture(); key != "path.region" || !ok {
+		t.Errorf("PathCapture() = %q, %v; want path.region, true", key, ok)
+	}
+	if !s.IsScalar() {
+		t.Error("expected a path capture selector to be scalar")
+	}
+	captures := map[string]any{"path.region": "us", "path.file": "a"}
+	if got := s.EvaluateMatches(captures); !reflect.DeepEqual(got, []Match{{"$path.region", "us"}}) {
+		t.Errorf("EvaluateMatches = %v", got)
+	}
+
+	data, _ := Parse("$.region")
+	if _, ok := data.PathCapture(); ok {
+		t.Error("expected a data selector not to be a path capture")
+	}
+}
+
+func TestParseQuotedFields(t *testing.T) {
+	cases := []struct {
+		input  string
+		scalar bool
+		fields []string
+	}{
+		{`$["app.version"]`, true, []string{"app.version"}},
+		{`$['app.version']`, true, []string{"app.version"}},
+		{`$.['app.version']`, true, []string{"app.version"}},
+		{`$.meta["a[0]"].id`, true, []string{"meta", "a[0]", "id"}},
+		{`$.items[*]["k.v"]`, false, []string{"items", "", "k.v"}},
+		{`$["say \"hi\""]`, true, []string{`say "hi"`}},
+		{`$['it\'s']`, true, []string{"it's"}},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) unexpected error: %v", tc.input, err)
+		}
+		if s.String() != tc.input {
+			t.Errorf("String() = %q, want %q", s.String(), tc.input)
+		}
+		if s.IsScalar() != tc.scalar {
+			t.Errorf("IsScalar() = %v, want %v for %q", s.IsScalar(), tc.scalar, tc.input)
+		}
+		var fields []string
+		for _, seg := range s.segments {
+			fields = append(fields, seg.field)
+		}
+		if !reflect.DeepEqual(fields, tc.fields) {
+			t.Errorf("fields = %q, want %q for %q", fields, tc.fields, tc.input)
+		}
+	}
+}
+
+func TestParseQuotedFieldsInvalid(t *testing.T) {
+	cases := []string{
+		`$["a`,
+		`$["a"`,
+		`$["a"x`,
+		`$[""]`,
+		`$['a"]`,
+		`$["a\`,
+	}
+	for _, input := range cases {
+		_, err := Parse(input)
+		if err == nil {
+			t.Errorf("Parse(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestEvaluateQuotedField(t *testing.T) {
+	s := mustParse(t, `$.meta["app.version"]`)
+	data := map[string]any{
+		"meta": map[string]any{
+			"app.version": "1.2.3",
+			"app":         map[string]any{"version": "wrong"},
+		},
+	}
+	got, err := s.Evaluate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertResults(t, got, []any{"1.2.3"})
+}
+
 func TestEvaluateRoot(t *testing.T) {
 	s := mustParse(t, "$")
 	data := map[string]any{"id": "abc"}
@@ -240,6 +341,49 @@ func TestEvaluateEmptyArray(t *testing.T) {
 	}
 }
 
+func TestEvaluateIndex(t *testing.T) {
+	cases := []struct {
+		sel   string
+		items []any
+		want  []any
+	}{
+		{"$.items[-1]", []any{"a", "b", "c"}, []any{"c"}},
+		{"$.items[-3]", []any{"a", "b", "c"}, []any{"a"}},
+		{"$.items[-4]", []any{"a", "b", "c"}, []any{}},
+		{"$.items[-1]", []any{"only"}, []any{"only"}},
+		{"$.items[-2]", []any{"only"}, []any{}},
+		{"$.items[-1]", []any{}, []any{}},
+		{"$.items[0]", []any{"a", "b"}, []any{"a"}},
+		{"$.items[2]", []any{"a", "b"}, []any{}},
+	}
+	for _, tc := range cases {
+		s := mustParse(t, tc.sel)
+		data := map[string]any{"items": tc.items}
+		got, _ := s.Evaluate(data)
+		assertResults(t, got, tc.want)
+		strict, err := s.EvaluateStrict(data)
+		if err != nil {
+			t.Errorf("EvaluateStrict(%s) unexpected error: %v", tc.sel, err)
+		}
+		assertResults(t, strict, tc.want)
+	}
+}
+
+func TestEvaluateNegativeIndexField(t *testing.T) {
+	s := mustParse(t, "$.versions[-1].tag")
+	data := map[string]any{
+		"versions": []any{
+			map[string]any{"tag": "v1"},
+			map[string]any{"tag": "v2"},
+		},
+	}
+	got, _ := s.Evaluate(data)
+	assertResults(t, got, []any{"v2"})
+
+	got, _ = s.Evaluate(map[string]any{"versions": "not an array"})
+	assertResults(t, got, []any{})
+}
+
 func TestEvaluateNestedObject(t *testing.T) {
 	s := mustParse(t, "$.config")
 	data := map[string]any{
@@ -277,6 +421,124 @@ func TestEvaluateNumericAndBoolValues(t *testing.T) {
 	assertResults(t, got, []any{1.5, true, nil})
 }
 
+func TestEvaluateStrictFound(t *testing.T) {
+	s := mustParse(t, "$.meta.id")
+	got, err := s.EvaluateStrict(map[string]any{"meta": map[string]any{"id": "x"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertResults(t, got, []any{"x"})
+}
+
+func TestEvaluateStrictIntermediateMissing(t *testing.T) {
+	s := mustParse(t, "$.meta.id")
+	_, err := s.EvaluateStrict(map[string]any{"name": "x"})
+	missing, ok := err.(*MissingPathError)
+	if !ok {
+		t.Fatalf("expected *MissingPathError, got %v", err)
+	}
+	if missing.Leaf {
+		t.Error("expected intermediate (non-leaf) missing field")
+	}
+	if missing.Field != "meta" {
+		t.Errorf("missing field = %q, want meta", missing.Field)
+	}
+}
+
+func TestEvaluateStrictLeafMissing(t *testing.T) {
+	s := mustParse(t, "$.meta.id")
+	_, err := s.EvaluateStrict(map[string]any{"meta": map[string]any{}})
+	missing, ok := err.(*MissingPathError)
+	if !ok {
+		t.Fatalf("expected *MissingPathError, got %v", err)
+	}
+	if !missing.Leaf {
+		t.Error("expected leaf missing field")
+	}
+	if missing.Field != "id" {
+		t.Errorf("missing field = %q, want id", missing.Field)
+	}
+}
+
+func TestEvaluateStrictWildcardElementMissing(t *testing.T) {
+	s := mustParse(t, "$.items[*].meta.id")
+	data := map[string]any{
+		"items": []any{
+			map[string]any{"meta": map[string]any{"id": "a"}},
+			map[string]any{"name": "b"},
+		},
+	}
+	_, err := s.EvaluateStrict(data)
+	missing, ok := err.(*MissingPathError)
+	if !ok || missing.Leaf || missing.Field != "meta" {
+		t.Fatalf("expected intermediate missing meta, got %v", err)
+	}
+
+	// Evaluate is unchanged and silently skips the missing element.
+	got, _ := s.Evaluate(data)
+	assertResults(t, got, []any{"a"})
+}
+
+func TestParseLength(t *testing.T) {
+	s := mustParse(t, "$.items.length")
+	if !s.IsLength() || !s.IsScalar() {
+		t.Fatalf("expected scalar length selector, got IsLength=%v IsScalar=%v", s.IsLength(), s.IsScalar())
+	}
+
+	// A non-terminal or quoted length is an ordinary field.
+	for _, sel := range []string{"$.length.count", `$.items["length"]`} {
+		s := mustParse(t, sel)
+		if s.IsLength() {
+			t.Errorf("Parse(%q) should not be a length selector", sel)
+		}
+	}
+}
+
+func TestEvaluateLength(t *testing.T) {
+	s := mustParse(t, "$.items.length")
+	tests := []struct {
+		name string
+		data any
+		want int
+	}{
+		{"empty", map[string]any{"items": []any{}}, 0},
+		{"one", map[string]any{"items": []any{"a"}}, 1},
+		{"three", map[string]any{"items": []any{"a", "b", "c"}}, 3},
+		{"missing", map[string]any{"other": true}, 0},
+		{"not array", map[string]any{"items": "abc"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Evaluate(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertResults(t, got, []any{tt.want})
+		})
+	}
+}
+
+func TestEvaluateLengthQuotedField(t *testing.T) {
+	s := mustParse(t, `$["length"]`)
+	got, _ := s.Evaluate(map[string]any{"length": "long"})
+	assertResults(t, got, []any{"long"})
+}
+
+func TestEvaluateStrictLengthMissing(t *testing.T) {
+	s := mustParse(t, "$.items.length")
+	_, err := s.EvaluateStrict(map[string]any{})
+	missing, ok := err.(*MissingPathError)
+	if !ok || !missing.Leaf || missing.Field != "items" {
+		t.Fatalf("expected leaf missing items, got %v", err)
+	}
+
+	got, err := s.EvaluateStrict(map[string]any{"items": []any{1.0, 2.0}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertResults(t, got, []any{2})
+}
+
 func mustParse(t *testing.T, sel string) *Selector {
 	t.Helper()
 	s, err := Parse(sel)
@@ -297,3 +559,70 @@ func assertResults(t *testing.T, got, want []any) {
 		}
 	}
 }
+
+func TestRootField(t *testing.T) {
+	tests := []struct {
+		sel   string
+		field string
+		ok    bool
+	}{
+		{"$", "", false},
+		{"$.team", "team", true},
+		{"$.team.id", "team", true},
+		{`$["app.version"]`, "app.version", true},
+		{"$.tags[*].name", "tags", true},
+		{"$[0].id", "", false},
+		{"$path.region", "", false},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.sel)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.sel, err)
+		}
+		field, ok := s.RootField()
+		if field != tt.field || ok != tt.ok {
+			t.Errorf("RootField(%q) = %q, %v; want %q, %v", tt.sel, field, ok, tt.field, tt.ok)
+		}
+	}
+}
+
+func TestEvaluateMatches(t *testing.T) {
+	data := map[string]any{
+		"members": []any{
+			map[string]any{"id": "a"},
+			map[string]any{"name": "no id"},
+			map[string]any{"id": "c"},
+		},
+		"app.meta": map[string]any{"length": 2.0},
+		"tags":     []any{"x", "y"},
+	}
+	tests := []struct {
+		sel  string
+		want []Match
+	}{
+		{"$.members[*].id", []Match{{"$.members[0].id", "a"}, {"$.members[2].id", "c"}}},
+		{"$.members[-1].id", []Match{{"$.members[2].id", "c"}}},
+		{`$["app.meta"]["length"]`, []Match{{`$["app.meta"]["length"]`, 2.0}}},
+		{"$.tags.length", []Match{{"$.tags.length", 2}}},
+		{"$.missing[*]", nil},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.sel)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.sel, err)
+		}
+		if got := s.EvaluateMatches(data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("EvaluateMatches(%q) = %v, want %v", tt.sel, got, tt.want)
+		}
+		// every path selects exactly its value
+		for _, m := range tt.want {
+			ps, err := Parse(m.Path)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", m.Path, err)
+			}
+			if vals, _ := ps.Evaluate(data); len(vals) != 1 || vals[0] != m.Value {
+				t.Errorf("path %q selects %v, want %v", m.Path, vals, m.Value)
+			}
+		}
+	}
+}
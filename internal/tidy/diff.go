@@ -22,17 +22,24 @@ type diffLine struct {
 	newLine int
 }
 
-// RenderUnifiedDiff renders a git-like unified diff (without color).
-func RenderUnifiedDiff(path string, original, tidied []byte) string {
-	return renderUnifiedDiff(path, original, tidied, false)
+// DefaultDiffContext is the number of unchanged lines shown around each
+// change, as with diff -U3.
+const DefaultDiffContext = 3
+
+// RenderUnifiedDiff renders a git-like unified diff (without color), showing
+// context unchanged lines around each change; a negative context shows the
+// whole file as a single hunk.
+func RenderUnifiedDiff(path string, original, tidied []byte, context int) string {
+	return renderUnifiedDiff(path, original, tidied, context, false)
 }
 
-// RenderColorUnifiedDiff renders a git-like unified diff using ANSI colors.
-func RenderColorUnifiedDiff(path string, original, tidied []byte) string {
-	return renderUnifiedDiff(path, original, tidied, true)
+// RenderColorUnifiedDiff renders a git-like unified diff using ANSI colors,
+// with context as for RenderUnifiedDiff.
+func RenderColorUnifiedDiff(path string, original, tidied []byte, context int) string {
+	return renderUnifiedDiff(path, original, tidied, context, true)
 }
 
-func renderUnifiedDiff(path string, original, tidied []byte, color bool) string {
+func renderUnifiedDiff(path string, original, tidied []byte, context int, color bool) string {
 	if bytes.Equal(original, tidied) {
 		return ""
 	}
@@ -45,46 +52,87 @@ func renderUnifiedDiff(path string, original, tidied []byte, color bool) string
 	}
 	numberDiffLines(ops)
 
-	oldCount := 0
-	newCount := 0
-	for _, op := range ops {
-		if op.kind != diffInsert {
-			oldCount++
-		}
-		if op.kind != diffDelete {
-			newCount++
-		}
-	}
-
-	oldStart := 1
-	newStart := 1
-	if oldCount == 0 {
-		oldStart = 0
-	}
-	if newCount == 0 {
-		newStart = 0
-	}
-
 	width := len(strconv.Itoa(maxInt(1, maxInt(len(oldLines), len(newLines)))))
 
 	var b strings.Builder
-	writeDiffHeader(&b, path, oldStart, oldCount, newStart, newCount, color)
-	for _, op := range ops {
-		writeDiffLine(&b, op, width, color)
+	writeDiffHeader(&b, path, color)
+	for _, h := range diffHunks(ops, context) {
+		writeHunk(&b, ops, h, width, color)
 	}
 	return b.String()
 }
 
-func writeDiffHeader(b *strings.Builder, path string, oldStart, oldCount, newStart, newCount int, color bool) {
+// diffHunk is the range ops[start:end] rendered under one @@ header.
+type diffHunk struct {
+	start, end int
+}
+
+// diffHunks groups the changed lines of ops into hunks, each extended by up
+// to context unchanged lines on both sides. Hunks whose context would touch or
+// overlap are merged. A negative context yields one hunk spanning all of ops.
+func diffHunks(ops []diffLine, context int) []diffHunk {
+	if context < 0 {
+		return []diffHunk{{0, len(ops)}}
+	}
+	var hunks []diffHunk
+	for i, op := range ops {
+		if op.kind == diffEqual {
+			continue
+		}
+		start, end := max(0, i-context), min(len(ops), i+context+1)
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, diffHunk{start, end})
+	}
+	return hunks
+}
+
+func writeDiffHeader(b *strings.Builder, path string, color bool) {
 	writeColoredLine(b, fmt.Sprintf("diff --git a/%s b/%s", path, path), ansiBold, color)
 	writeColoredLine(b, fmt.Sprintf("--- a/%s", path), ansiRed, color)
 	writeColoredLine(b, fmt.Sprintf("+++ b/%s", path), ansiGreen, color)
+}
+
+// writeHunk writes the @@ header and lines of h. As in diff -U, a side with
+// no lines in the hunk is numbered by the line before it.
+func writeHunk(b *strings.Builder, ops []diffLine, h diffHunk, width int, color bool) {
+	oldBefore, newBefore := 0, 0
+	for _, op := range ops[:h.start] {
+		if op.kind != diffInsert {
+			oldBefore++
+		}
+		if op.kind != diffDelete {
+			newBefore++
+		}
+	}
+	oldCount, newCount := 0, 0
+	for _, op := range ops[h.start:h.end] {
+		if op.kind != diffInsert {
+			oldCount++
+		}
+		if op.kind != diffDelete {
+			newCount++
+		}
+	}
+	oldStart, newStart := oldBefore, newBefore
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+
 	writeColoredLine(
 		b,
 		fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount),
 		ansiCyan,
 		color,
 	)
+	for _, op := range ops[h.start:h.end] {
+		writeDiffLine(b, op, width, color)
+	}
 }
 
 func writeDiffLine(b *strings.Builder, op diffLine, width int, color bool) {
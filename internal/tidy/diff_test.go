@@ -1,20 +1,21 @@
 package tidy
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"testing"
 )
 
 func TestRenderUnifiedDiff_NoChange(t *testing.T) {
-	got := RenderUnifiedDiff("data/test.yaml", []byte("a: 1\n"), []byte("a: 1\n"))
+	got := RenderUnifiedDiff("data/test.yaml", []byte("a: 1\n"), []byte("a: 1\n"), DefaultDiffContext)
 	if got != "" {
 		t.Fatalf("expected empty diff, got:\n%s", got)
 	}
 }
 
 func TestRenderUnifiedDiff_HeadersAndLineNumbers(t *testing.T) {
-	got := RenderUnifiedDiff("data/test.yaml", []byte("b: 2\na: 1\n"), []byte("a: 1\nb: 2\n"))
+	got := RenderUnifiedDiff("data/test.yaml", []byte("b: 2\na: 1\n"), []byte("a: 1\nb: 2\n"), DefaultDiffContext)
 
 	if !strings.Contains(got, "diff --git a/data/test.yaml b/data/test.yaml") {
 		t.Fatalf("missing diff header:\n%s", got)
@@ -44,8 +45,62 @@ func TestRenderUnifiedDiff_HeadersAndLineNumbers(t *testing.T) {
 }
 
 func TestRenderColorUnifiedDiff_UsesANSI(t *testing.T) {
-	got := RenderColorUnifiedDiff("data/test.yaml", []byte("a: 1\n"), []byte("b: 1\n"))
+	got := RenderColorUnifiedDiff("data/test.yaml", []byte("a: 1\n"), []byte("b: 1\n"), DefaultDiffContext)
 	if !strings.Contains(got, "\x1b[") {
 		t.Fatalf("expected ANSI escape codes in colored diff:\n%s", got)
 	}
 }
+
+func TestRenderUnifiedDiff_ContextLimitsHunk(t *testing.T) {
+	var orig, tidied strings.Builder
+	for i := 1; i <= 200; i++ {
+		fmt.Fprintf(&orig, "line%d: %d\n", i, i)
+		if i == 100 {
+			fmt.Fprintf(&tidied, "line%d: changed\n", i)
+			continue
+		}
+		fmt.Fprintf(&tidied, "line%d: %d\n", i, i)
+	}
+
+	got := RenderUnifiedDiff("data/big.yaml", []byte(orig.String()), []byte(tidied.String()), 3)
+	if !strings.Contains(got, "@@ -97,7 +97,7 @@") {
+		t.Fatalf("expected a single hunk around line 100:\n%s", got)
+	}
+	if strings.Count(got, "@@ -") != 1 {
+		t.Fatalf("expected exactly one hunk:\n%s", got)
+	}
+	for _, want := range []string{"|  line97: 97", "| -line100: 100", "| +line100: changed", "|  line103: 103"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in diff:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"line96: 96", "line104: 104", "line1: 1\n"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("unexpected %q outside the context:\n%s", unwanted, got)
+		}
+	}
+
+	full := RenderUnifiedDiff("data/big.yaml", []byte(orig.String()), []byte(tidied.String()), -1)
+	if !strings.Contains(full, "@@ -1,200 +1,200 @@") || !strings.Contains(full, "line1: 1\n") {
+		t.Fatalf("expected the whole file with a negative context:\n%s", full)
+	}
+}
+
+func TestRenderUnifiedDiff_SeparateHunks(t *testing.T) {
+	orig := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	tidied := "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n"
+
+	got := RenderUnifiedDiff("data/test.txt", []byte(orig), []byte(tidied), 2)
+	if !strings.Contains(got, "@@ -1,3 +1,3 @@") || !strings.Contains(got, "@@ -8,3 +8,3 @@") {
+		t.Fatalf("expected two hunks:\n%s", got)
+	}
+	if strings.Contains(got, "| e") {
+		t.Fatalf("unchanged middle lines should be omitted:\n%s", got)
+	}
+
+	// context reaching across the gap merges the hunks
+	merged := RenderUnifiedDiff("data/test.txt", []byte(orig), []byte(tidied), 4)
+	if strings.Count(merged, "@@ -") != 1 || !strings.Contains(merged, "@@ -1,10 +1,10 @@") {
+		t.Fatalf("expected one merged hunk:\n%s", merged)
+	}
+}
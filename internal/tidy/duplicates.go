@@ -0,0 +1,65 @@
+package tidy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// CheckJSONDuplicateKeys reports the first object key that appears twice in
+// the same JSON object. encoding/json silently keeps the last value, so a
+// duplicate would otherwise vanish from validation and from tidied output.
+// The error mirrors yaml.v3's "mapping key already defined" wording. data is
+// assumed to be valid JSON; syntax errors are left to the caller's decoder.
+func CheckJSONDuplicateKeys(data []byte) error {
+	// one frame per open object or array; keys is nil for arrays
+	type frame struct {
+		keys      map[string]int
+		expectKey bool
+	}
+	var stack []*frame
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// valueDone marks the value of the enclosing object's current key as read.
+	valueDone := func() {
+		if len(stack) > 0 && stack[len(stack)-1].keys != nil {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{keys: map[string]int{}, expectKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &frame{})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.keys != nil && top.expectKey {
+			key := tok.(string)
+			line := 1 + bytes.Count(data[:dec.InputOffset()], []byte("\n"))
+			if first, seen := top.keys[key]; seen {
+				return fmt.Errorf("line %d: key %q already defined at line %d", line, key, first)
+			}
+			top.keys[key] = line
+			top.expectKey = false
+			continue
+		}
+		valueDone()
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,38 +24,48 @@ type TidyResult struct {
 	Tidied   []byte // Tidied file content
 }
 
+// CSVOptions controls how CSV files are tidied.
+type CSVOptions struct {
+	SortColumns bool // sort columns alphabetically; if false, keep header order
+
+	// NumberColumns maps the columns whose cells are rewritten in canonical
+	// number form to their schema type, "number" or "integer".
+	NumberColumns map[string]string
+}
+
 // TidyFile tidies a single file.
-// input is the file format: "json", "yaml", "csv"
+// input is the file format: "json", "json5", "yaml", "csv", "text"; json5 files
+// keep their comments and layout, so they are never changed
 // dryRun: if true, don't write changes, just report if they would change
-func TidyFile(path string, input string, dryRun bool) (TidyResult, error) {
+// csvOpts: how CSV files are tidied; ignored for other formats
+func TidyFile(path string, input string, dryRun bool, csvOpts CSVOptions) (TidyResult, error) {
 	switch input {
 	case "json":
 		return tidyJSON(path, dryRun)
 	case "yaml":
 		return tidyYAML(path, dryRun)
 	case "csv":
-		return tidyCSV(path, dryRun)
+		return tidyCSV(path, dryRun, csvOpts)
+	case "text":
+		return tidyPath(path, dryRun, tidyTextBytes)
+	case "json5":
+		return TidyResult{Path: path}, nil
 	default:
 		return TidyResult{Path: path}, fmt.Errorf("unsupported input format: %s", input)
 	}
 }
 
-func tidyJSON(path string, dryRun bool) (TidyResult, error) {
+// tidyPath reads path, applies transform, and writes the result back when it
+// differs from the original and dryRun is false.
+func tidyPath(path string, dryRun bool, transform func([]byte) ([]byte, error)) (TidyResult, error) {
 	original, err := os.ReadFile(path)
 	if err != nil {
 		return TidyResult{Path: path}, fmt.Errorf("reading file: %w", err)
 	}
 
-	var data any
-	if err := json.Unmarshal(original, &data); err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("parsing JSON: %w", err)
-	}
-
-	data = sortKeys(data)
-
-	tidied, err := marshalJSONIndent(data)
+	tidied, err := transform(bytes.TrimPrefix(original, utf8BOM))
 	if err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("marshaling JSON: %w", err)
+		return TidyResult{Path: path}, err
 	}
 
 	changed := !bytes.Equal(original, tidied)
@@ -63,6 +78,74 @@ func tidyJSON(path string, dryRun bool) (TidyResult, error) {
 	return TidyResult{Path: path, Changed: changed, Original: original, Tidied: tidied}, nil
 }
 
+// VerifyIdempotent re-applies the tidy transform for input to already tidied
+// content and returns an error identifying the first differing line when the
+// output is not a fixed point.
+func VerifyIdempotent(input string, tidied []byte, csvOpts CSVOptions) error {
+	var transform func([]byte) ([]byte, error)
+	switch input {
+	case "json":
+		transform = tidyJSONBytes
+	case "yaml":
+		transform = tidyYAMLBytes
+	case "csv":
+		transform = func(b []byte) ([]byte, error) { return tidyCSVBytes(b, csvOpts) }
+	case "text":
+		transform = tidyTextBytes
+	default:
+		return fmt.Errorf("unsupported input format: %s", input)
+	}
+
+	again, err := transform(tidied)
+	if err != nil {
+		return fmt.Errorf("re-tidying output: %w", err)
+	}
+	if bytes.Equal(tidied, again) {
+		return nil
+	}
+
+	first := bytes.Split(tidied, []byte("\n"))
+	second := bytes.Split(again, []byte("\n"))
+	line := 1
+	for line <= len(first) && line <= len(second) && bytes.Equal(first[line-1], second[line-1]) {
+		line++
+	}
+	return fmt.Errorf("tidy output is not stable: re-tidying changes line %d", line)
+}
+
+// utf8BOM is the UTF-8 byte order mark stripped from the start of every file
+// before it is tidied.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+func tidyJSON(path string, dryRun bool) (TidyResult, error) {
+	return tidyPath(path, dryRun, tidyJSONBytes)
+}
+
+func tidyJSONBytes(original []byte) ([]byte, error) {
+	// Numbers are decoded as json.Number so sortKeys can keep integers beyond
+	// float64 precision exact.
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(original))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", json.Unmarshal(original, &data))
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("parsing JSON: %w", json.Unmarshal(original, &data))
+	}
+	if err := CheckJSONDuplicateKeys(original); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+
+	data = sortKeys(data)
+
+	tidied, err := marshalJSONIndent(data)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling JSON: %w", err)
+	}
+	return tidied, nil
+}
+
 func marshalJSONIndent(data any) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	enc := json.NewEncoder(buf)
@@ -75,38 +158,159 @@ func marshalJSONIndent(data any) ([]byte, error) {
 }
 
 func tidyYAML(path string, dryRun bool) (TidyResult, error) {
-	original, err := os.ReadFile(path)
-	if err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("reading file: %w", err)
-	}
+	return tidyPath(path, dryRun, tidyYAMLBytes)
+}
 
+func tidyYAMLBytes(original []byte) ([]byte, error) {
 	var data any
 	if err := yaml.Unmarshal(original, &data); err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("parsing YAML: %w", err)
+		return nil, fmt.Errorf("parsing YAML: %w", err)
 	}
 
 	data = normalizeYAML(data)
 	data = sortKeys(data)
 
+	var out any = data
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	styles := make(map[string]yaml.Style)
+	blockScalarStyles(&doc, "", styles)
+	if len(styles) > 0 {
+		var node yaml.Node
+		if err := node.Encode(data); err != nil {
+			return nil, fmt.Errorf("marshaling YAML: %w", err)
+		}
+		applyBlockScalarStyles(&node, "", styles)
+		out = &node
+	}
+
 	buf := &bytes.Buffer{}
 	enc := yaml.NewEncoder(buf)
 	enc.SetIndent(2)
-	if err := enc.Encode(data); err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("marshaling YAML: %w", err)
+	if err := enc.Encode(out); err != nil {
+		return nil, fmt.Errorf("marshaling YAML: %w", err)
 	}
 	if err := enc.Close(); err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("closing YAML encoder: %w", err)
+		return nil, fmt.Errorf("closing YAML encoder: %w", err)
 	}
-	tidied := buf.Bytes()
+	if len(styles) > 0 {
+		return trimFoldedTrailingBlankLines(buf.Bytes())
+	}
+	return buf.Bytes(), nil
+}
 
-	changed := !bytes.Equal(original, tidied)
-	if changed && !dryRun {
-		if err := os.WriteFile(path, tidied, 0o644); err != nil {
-			return TidyResult{Path: path}, fmt.Errorf("writing file: %w", err)
+// blockScalarStyles records the style of every literal (|) or folded (>)
+// string scalar under n, keyed by its path of mapping keys and sequence
+// indexes, so the re-encoded document can keep block scalars as written.
+func blockScalarStyles(n *yaml.Node, path string, styles map[string]yaml.Style) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			blockScalarStyles(c, path, styles)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			blockScalarStyles(n.Content[i+1], path+"\x00"+n.Content[i].Value, styles)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			blockScalarStyles(c, path+"\x00"+strconv.Itoa(i), styles)
+		}
+	case yaml.ScalarNode:
+		if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			styles[path] = n.Style & (yaml.LiteralStyle | yaml.FoldedStyle)
 		}
 	}
+}
 
-	return TidyResult{Path: path, Changed: changed, Original: original, Tidied: tidied}, nil
+// applyBlockScalarStyles sets the recorded block style on the string scalars
+// under n at the paths in styles.
+func applyBlockScalarStyles(n *yaml.Node, path string, styles map[string]yaml.Style) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			applyBlockScalarStyles(c, path, styles)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			applyBlockScalarStyles(n.Content[i+1], path+"\x00"+n.Content[i].Value, styles)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			applyBlockScalarStyles(c, path+"\x00"+strconv.Itoa(i), styles)
+		}
+	case yaml.ScalarNode:
+		style, ok := styles[path]
+		if !ok || n.Tag != "!!str" {
+			return
+		}
+		// yaml.v3 emits one line break too many after a folded scalar, which
+		// changes the value under keep chomping (>+); write those as literal.
+		if style == yaml.FoldedStyle && strings.HasSuffix(n.Value, "\n\n") {
+			style = yaml.LiteralStyle
+		}
+		n.Style = style
+	}
+}
+
+// trimFoldedTrailingBlankLines removes the blank line yaml.v3 writes after
+// the body of a folded scalar that ends in a single line break. Under clip
+// chomping (>) trailing blank lines are not part of the value, so this only
+// undoes the noise.
+func trimFoldedTrailingBlankLines(out []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		return nil, fmt.Errorf("parsing YAML: %w", err)
+	}
+	var starts []int // 0-based line index of each folded scalar indicator
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode && n.Style&yaml.FoldedStyle != 0 &&
+			strings.HasSuffix(n.Value, "\n") && !strings.HasSuffix(n.Value, "\n\n") {
+			starts = append(starts, n.Line-1)
+		}
+		for _, c := range n.Content {
+			walk(c)
+		}
+	}
+	walk(&doc)
+	if len(starts) == 0 {
+		return out, nil
+	}
+
+	lines := strings.SplitAfter(string(out), "\n")
+	drop := make(map[int]bool)
+	for _, start := range starts {
+		indent := -1 // indentation of the scalar body, from its first non-blank line
+		lastText := start
+		for i := start + 1; i < len(lines); i++ {
+			text := strings.TrimRight(lines[i], "\n")
+			if text == "" {
+				continue
+			}
+			lineIndent := len(text) - len(strings.TrimLeft(text, " "))
+			if indent < 0 {
+				indent = lineIndent
+			}
+			if lineIndent < indent {
+				break
+			}
+			lastText = i
+		}
+		for i := lastText + 1; i < len(lines) && lines[i] == "\n"; i++ {
+			drop[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if !drop[i] {
+			b.WriteString(line)
+		}
+	}
+	return []byte(b.String()), nil
 }
 
 // normalizeYAML converts YAML-decoded data to JSON-like structures (map[string]any).
@@ -131,20 +335,19 @@ func normalizeYAML(v any) any {
 	}
 }
 
-func tidyCSV(path string, dryRun bool) (TidyResult, error) {
-	original, err := os.ReadFile(path)
-	if err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("reading file: %w", err)
-	}
+func tidyCSV(path string, dryRun bool, opts CSVOptions) (TidyResult, error) {
+	return tidyPath(path, dryRun, func(b []byte) ([]byte, error) { return tidyCSVBytes(b, opts) })
+}
 
-	reader := csv.NewReader(bytes.NewReader(original))
+func tidyCSVBytes(original []byte, opts CSVOptions) ([]byte, error) {
+	reader := csv.NewReader(bytes.NewReader(trimCSVTrailingSpace(original)))
 	records, err := reader.ReadAll()
 	if err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("parsing CSV: %w", err)
+		return nil, fmt.Errorf("parsing CSV: %w", err)
 	}
 
 	if len(records) == 0 {
-		return TidyResult{Path: path, Changed: false}, nil
+		return original, nil
 	}
 
 	headers := records[0]
@@ -158,11 +361,13 @@ func tidyCSV(path string, dryRun bool) (TidyResult, error) {
 	for i, h := range headers {
 		cols[i] = colInfo{name: h, origIdx: i}
 	}
-	sort.SliceStable(cols, func(i, j int) bool {
-		return cols[i].name < cols[j].name
-	})
+	if opts.SortColumns {
+		sort.SliceStable(cols, func(i, j int) bool {
+			return cols[i].name < cols[j].name
+		})
+	}
 
-	// Reorder all rows according to sorted columns
+	// Reorder all rows according to the column order
 	sorted := make([][]string, len(records))
 	for i, row := range records {
 		newRow := make([]string, len(cols))
@@ -170,26 +375,118 @@ func tidyCSV(path string, dryRun bool) (TidyResult, error) {
 			if c.origIdx < len(row) {
 				newRow[j] = row[c.origIdx]
 			}
+			if t, ok := opts.NumberColumns[c.name]; ok && i > 0 {
+				newRow[j] = canonicalNumber(newRow[j], t)
+			}
 		}
 		sorted[i] = newRow
 	}
 
-	buf := &bytes.Buffer{}
-	writer := csv.NewWriter(buf)
-	if err := writer.WriteAll(sorted); err != nil {
-		return TidyResult{Path: path}, fmt.Errorf("writing CSV: %w", err)
+	return writeCSV(sorted), nil
+}
+
+// canonicalNumber rewrites a cell of a column with schema type "number" or
+// "integer" in canonical form: no leading "+" or leading zeros and, for
+// numbers, no trailing fractional zeros, so "+01.50" becomes "1.5" and "2.0"
+// becomes "2". Only plain decimal notation is rewritten, digit for digit, so
+// the value never changes; exponents, integer cells with a fraction, and cells
+// that are not numbers are returned as they are.
+func canonicalNumber(cell, schemaType string) string {
+	s := cell
+	neg := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+	if (intPart == "" && frac == "") || !isDigits(intPart) || (hasFrac && (schemaType != "number" || !isDigits(frac))) {
+		return cell
 	}
-	writer.Flush()
-	tidied := buf.Bytes()
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	frac = strings.TrimRight(frac, "0")
+	out := intPart
+	if frac != "" {
+		out += "." + frac
+	}
+	if neg && out != "0" {
+		out = "-" + out
+	}
+	return out
+}
 
-	changed := !bytes.Equal(original, tidied)
-	if changed && !dryRun {
-		if err := os.WriteFile(path, tidied, 0o644); err != nil {
-			return TidyResult{Path: path}, fmt.Errorf("writing file: %w", err)
+// isDigits reports whether s consists only of ASCII digits.
+func isDigits(s string) bool {
+	for i := range len(s) {
+		if s[i] < '0' || s[i] > '9' {
+			return false
 		}
 	}
+	return true
+}
 
-	return TidyResult{Path: path, Changed: changed, Original: original, Tidied: tidied}, nil
+// writeCSV encodes records like csv.Writer, but also quotes fields that end in
+// a space or tab so intentional trailing whitespace survives re-tidying.
+func writeCSV(records [][]string) []byte {
+	buf := &bytes.Buffer{}
+	for _, row := range records {
+		for i, field := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if !csvFieldNeedsQuotes(field) {
+				buf.WriteString(field)
+				continue
+			}
+			buf.WriteByte('"')
+			buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// csvFieldNeedsQuotes mirrors csv.Writer's quoting rules and additionally
+// quotes fields with trailing spaces or tabs.
+func csvFieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsAny(field, ",\"\r\n") {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(field)
+	if unicode.IsSpace(first) {
+		return true
+	}
+	last := field[len(field)-1]
+	return last == ' ' || last == '\t'
+}
+
+// trimCSVTrailingSpace removes spaces and tabs before each line break that is
+// outside a quoted field, so only unquoted trailing whitespace is dropped.
+// JSON and YAML re-serialization already discards trailing whitespace.
+func trimCSVTrailingSpace(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inQuotes := false
+	for _, b := range data {
+		switch b {
+		case '"':
+			inQuotes = !inQuotes
+		case '\r', '\n':
+			if !inQuotes {
+				out = bytes.TrimRight(out, " \t")
+			}
+		}
+		out = append(out, b)
+	}
+	if !inQuotes {
+		out = bytes.TrimRight(out, " \t")
+	}
+	return out
 }
 
 // sortKeys recursively sorts all object keys in the data structure.
@@ -207,7 +504,31 @@ func sortKeys(data any) any {
 			out[i] = sortKeys(val)
 		}
 		return out
+	case json.Number:
+		// integers that fit keep every digit; anything else is written as a
+		// float64, as before numbers were decoded exactly
+		if i, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
 	default:
 		return data
 	}
 }
+
+// tidyTextBytes applies minimal normalization to a text file without parsing
+// it: CRLF line endings become LF, trailing whitespace is stripped from every
+// line, and the file ends with exactly one newline. Empty files stay empty.
+func tidyTextBytes(original []byte) ([]byte, error) {
+	text := strings.ReplaceAll(string(original), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	text = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if text == "" {
+		return []byte{}, nil
+	}
+	return []byte(text + "\n"), nil
+}
@@ -3,6 +3,7 @@ package tidy
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -21,7 +22,7 @@ func TestTidyJSON_SortsKeys(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.json", `{"z":1,"a":2,"m":3}`)
 
-	res, err := TidyFile(p, "json", false)
+	res, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -36,11 +37,44 @@ func TestTidyJSON_SortsKeys(t *testing.T) {
 	}
 }
 
+func TestTidyJSON_KeepsLargeIntegers(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "test.json", `{"id":1234567890123456789,"ratio":1.50,"n":1e3}`)
+
+	if _, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(p)
+	expected := "{\n  \"id\": 1234567890123456789,\n  \"n\": 1000,\n  \"ratio\": 1.5\n}\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(got))
+	}
+}
+
+func TestTidyJSON_RejectsDuplicateKeys(t *testing.T) {
+	input := "{\n  \"id\": \"a\",\n  \"meta\": {\"x\": 1, \"y\": [{\"x\": 2}]},\n  \"id\": \"b\"\n}\n"
+	_, err := tidyJSONBytes([]byte(input))
+	if err == nil || err.Error() != `parsing JSON: line 4: key "id" already defined at line 2` {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+
+	// the same key in sibling objects is not a duplicate
+	if _, err := tidyJSONBytes([]byte(`{"a": {"x": 1}, "b": {"x": 2}, "c": [{"x": 3}, {"x": 4}]}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = tidyJSONBytes([]byte(`{"a": {"x": 1, "x": 2}}`))
+	if err == nil || !strings.Contains(err.Error(), `key "x" already defined`) {
+		t.Fatalf("expected nested duplicate key error, got %v", err)
+	}
+}
+
 func TestTidyJSON_NestedKeys(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.json", `{"b":{"z":1,"a":2},"a":3}`)
 
-	res, err := TidyFile(p, "json", false)
+	res, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -60,7 +94,7 @@ func TestTidyJSON_AlreadyTidy(t *testing.T) {
 	content := "{\n  \"a\": 1,\n  \"b\": 2\n}\n"
 	p := writeTempFile(t, dir, "test.json", content)
 
-	res, err := TidyFile(p, "json", false)
+	res, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -74,7 +108,7 @@ func TestTidyJSON_DryRun(t *testing.T) {
 	original := `{"z":1,"a":2}`
 	p := writeTempFile(t, dir, "test.json", original)
 
-	res, err := TidyFile(p, "json", true)
+	res, err := TidyFile(p, "json", true, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -93,7 +127,7 @@ func TestTidyJSON_ArrayOrderPreserved(t *testing.T) {
 	input := "[\n  {\n    \"id\": 2,\n    \"name\": \"banana\"\n  },\n  {\n    \"id\": 1,\n    \"name\": \"apple\"\n  }\n]\n"
 	p := writeTempFile(t, dir, "test.json", input)
 
-	res, err := TidyFile(p, "json", false)
+	res, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -108,7 +142,7 @@ func TestTidyYAML_SortsKeys(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.yaml", "z: 1\na: 2\nm: 3\n")
 
-	res, err := TidyFile(p, "yaml", false)
+	res, err := TidyFile(p, "yaml", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -127,7 +161,7 @@ func TestTidyYAML_StripsComments(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.yaml", "# This is a comment\na: 1\nb: 2 # inline comment\n")
 
-	res, err := TidyFile(p, "yaml", false)
+	res, err := TidyFile(p, "yaml", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -142,11 +176,18 @@ func TestTidyYAML_StripsComments(t *testing.T) {
 	}
 }
 
+func TestTidyYAML_RejectsDuplicateKeys(t *testing.T) {
+	_, err := tidyYAMLBytes([]byte("id: a\nmeta:\n  x: 1\nid: b\n"))
+	if err == nil || !strings.Contains(err.Error(), `mapping key "id" already defined at line 1`) {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
 func TestTidyYAML_NestedKeys(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.yaml", "b:\n  z: 1\n  a: 2\na: 3\n")
 
-	res, err := TidyFile(p, "yaml", false)
+	res, err := TidyFile(p, "yaml", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -166,7 +207,7 @@ func TestTidyYAML_DryRun(t *testing.T) {
 	original := "z: 1\na: 2\n"
 	p := writeTempFile(t, dir, "test.yaml", original)
 
-	res, err := TidyFile(p, "yaml", true)
+	res, err := TidyFile(p, "yaml", true, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -180,13 +221,47 @@ func TestTidyYAML_DryRun(t *testing.T) {
 	}
 }
 
+func TestTidyYAML_PreservesLiteralBlockScalar(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "test.yaml", "z: 1\nscript: |-\n  echo one\n  echo two\nnote: |-\n  single line\n")
+
+	if _, err := TidyFile(p, "yaml", false, CSVOptions{SortColumns: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(p)
+	expected := "note: |-\n  single line\nscript: |-\n  echo one\n  echo two\nz: 1\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(got))
+	}
+}
+
+func TestTidyYAML_PreservesFoldedBlockScalar(t *testing.T) {
+	dir := t.TempDir()
+	original := "b: >\n  first paragraph\n\n  second paragraph\na:\n  - >-\n    item text\n"
+	p := writeTempFile(t, dir, "test.yaml", original)
+
+	if _, err := TidyFile(p, "yaml", false, CSVOptions{SortColumns: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, _ := os.ReadFile(p)
+	expected := "a:\n  - >-\n    item text\nb: >\n  first paragraph\n\n  second paragraph\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(got))
+	}
+	if err := VerifyIdempotent("yaml", got, CSVOptions{SortColumns: true}); err != nil {
+		t.Errorf("tidied output is not stable: %v", err)
+	}
+}
+
 // --- CSV tests ---
 
 func TestTidyCSV_SortsColumns(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.csv", "z,a,m\n1,2,3\n")
 
-	res, err := TidyFile(p, "csv", false)
+	res, err := TidyFile(p, "csv", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -206,7 +281,7 @@ func TestTidyCSV_AlreadyTidy(t *testing.T) {
 	content := "a,b\n1,2\n"
 	p := writeTempFile(t, dir, "test.csv", content)
 
-	res, err := TidyFile(p, "csv", false)
+	res, err := TidyFile(p, "csv", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -220,7 +295,7 @@ func TestTidyCSV_DryRun(t *testing.T) {
 	original := "z,a\n1,2\n"
 	p := writeTempFile(t, dir, "test.csv", original)
 
-	res, err := TidyFile(p, "csv", true)
+	res, err := TidyFile(p, "csv", true, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -234,6 +309,143 @@ func TestTidyCSV_DryRun(t *testing.T) {
 	}
 }
 
+func TestTidyCSV_PreservesColumnOrderWhenUnsorted(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "test.csv", "name,id,price\r\n\"Apple\",p1,1.5 \r\n")
+
+	res, err := TidyFile(p, "csv", false, CSVOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Changed {
+		t.Error("expected line endings and quoting to be normalized")
+	}
+
+	got, _ := os.ReadFile(p)
+	expected := "name,id,price\nApple,p1,1.5\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, string(got))
+	}
+	if err := VerifyIdempotent("csv", got, CSVOptions{}); err != nil {
+		t.Errorf("unsorted output is not idempotent: %v", err)
+	}
+}
+
+func TestTidyCSV_CanonicalNumbers(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "test.csv", "id,price,qty,code\n"+
+		"a,1.50,+3,007\n"+
+		"b,+2.0,007,+1.50\n"+
+		"c,-0.0,-0,x\n"+
+		"d,.5,1.0,\n"+
+		"e,1e3,,abc\n")
+
+	opts := CSVOptions{SortColumns: false, NumberColumns: map[string]string{"price": "number", "qty": "integer"}}
+	res, err := TidyFile(p, "csv", false, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Changed {
+		t.Error("expected numeric cells to be rewritten")
+	}
+
+	got, _ := os.ReadFile(p)
+	// string columns, exponents, and integer cells with a fraction are left as they are
+	expected := "id,price,qty,code\n" +
+		"a,1.5,3,007\n" +
+		"b,2,7,+1.50\n" +
+		"c,0,0,x\n" +
+		"d,0.5,1.0,\n" +
+		"e,1e3,,abc\n"
+	if string(got) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(got))
+	}
+	if err := VerifyIdempotent("csv", got, opts); err != nil {
+		t.Errorf("canonical output is not idempotent: %v", err)
+	}
+
+	// without number columns the cells are kept verbatim
+	p = writeTempFile(t, dir, "plain.csv", "price\n1.50\n")
+	if res, err := TidyFile(p, "csv", false, CSVOptions{SortColumns: true}); err != nil || res.Changed {
+		t.Errorf("expected no change without canonical numbers, got changed=%v err=%v", res.Changed, err)
+	}
+}
+
+// --- BOM and trailing whitespace tests ---
+
+func TestTidyFile_StripsBOM(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		content  string
+		expected string
+	}{
+		{"json", "test.json", "\ufeff{\"a\": 1}\n", "{\n  \"a\": 1\n}\n"},
+		{"yaml", "test.yaml", "\ufeffa: 1\n", "a: 1\n"},
+		{"csv", "test.csv", "\ufeffb,a\n1,2\n", "a,b\n2,1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := writeTempFile(t, t.TempDir(), tt.name, tt.content)
+
+			res, err := TidyFile(p, tt.input, false, CSVOptions{SortColumns: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !res.Changed {
+				t.Error("expected file to be changed")
+			}
+
+			got, _ := os.ReadFile(p)
+			if string(got) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, string(got))
+			}
+		})
+	}
+}
+
+func TestTidyFile_StripsTrailingWhitespace(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		content  string
+		expected string
+	}{
+		{"json", "test.json", "{  \n  \"a\": \"x  \"\t\n}  \n", "{\n  \"a\": \"x  \"\n}\n"},
+		{"yaml", "test.yaml", "a: 1   \nb: 'y  '  \n", "a: 1\nb: 'y  '\n"},
+		{"csv", "test.csv", "a,b  \n1,x  \n", "a,b\n1,x\n"},
+		{"text", "test.md", "# Title  \n\nbody\t\n", "# Title\n\nbody\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p := writeTempFile(t, t.TempDir(), tt.name, tt.content)
+
+			if _, err := TidyFile(p, tt.input, false, CSVOptions{SortColumns: true}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, _ := os.ReadFile(p)
+			if string(got) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, string(got))
+			}
+		})
+	}
+}
+
+func TestTidyCSV_PreservesQuotedTrailingSpaces(t *testing.T) {
+	dir := t.TempDir()
+	content := "a,b\n1,\"x  \"\n2,\"multi  \nline\"\n"
+	p := writeTempFile(t, dir, "test.csv", content)
+
+	res, err := TidyFile(p, "csv", false, CSVOptions{SortColumns: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Changed {
+		t.Errorf("expected file to not be changed, got:\n%q", res.Tidied)
+	}
+}
+
 // --- sortKeys tests ---
 
 func TestSortKeys_Map(t *testing.T) {
@@ -280,10 +492,74 @@ func TestSortKeys_Scalar(t *testing.T) {
 	}
 }
 
+// --- Text tests ---
+
+func TestTidyText_AddsMissingTrailingNewline(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "README.md", "# Title\n\nbody")
+
+	res, err := TidyFile(p, "text", false, CSVOptions{SortColumns: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !res.Changed {
+		t.Error("expected file to be changed")
+	}
+	got, _ := os.ReadFile(p)
+	if string(got) != "# Title\n\nbody\n" {
+		t.Errorf("unexpected output: %q", string(got))
+	}
+}
+
+func TestTidyText_ConvertsCRLF(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "notes.txt", "a\r\nb \r\n\r\n\r\n")
+
+	if _, err := TidyFile(p, "text", false, CSVOptions{SortColumns: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := os.ReadFile(p)
+	if string(got) != "a\nb\n" {
+		t.Errorf("unexpected output: %q", string(got))
+	}
+}
+
+func TestTidyText_AlreadyTidy(t *testing.T) {
+	dir := t.TempDir()
+	p := writeTempFile(t, dir, "notes.txt", "a\n\nb\n")
+
+	res, err := TidyFile(p, "text", false, CSVOptions{SortColumns: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Changed {
+		t.Error("already tidy file should not be changed")
+	}
+}
+
+// --- JSON5 ---
+
+func TestTidyFile_JSON5Unchanged(t *testing.T) {
+	dir := t.TempDir()
+	content := "// comment\n{id: 'a',}  \r\n"
+	p := writeTempFile(t, dir, "a.json5", content)
+
+	res, err := TidyFile(p, "json5", false, CSVOptions{SortColumns: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Changed {
+		t.Error("json5 file should never be changed")
+	}
+	if got, _ := os.ReadFile(p); string(got) != content {
+		t.Errorf("file content = %q, want %q", got, content)
+	}
+}
+
 // --- Unsupported format ---
 
 func TestTidyFile_UnsupportedFormat(t *testing.T) {
-	_, err := TidyFile("dummy.txt", "xml", false)
+	_, err := TidyFile("dummy.txt", "xml", false, CSVOptions{SortColumns: true})
 	if err == nil {
 		t.Error("expected error for unsupported format")
 	}
@@ -295,7 +571,7 @@ func TestTidyCSV_EmptyFile(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.csv", "")
 
-	res, err := TidyFile(p, "csv", false)
+	res, err := TidyFile(p, "csv", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -310,7 +586,7 @@ func TestTidyResult_Path(t *testing.T) {
 	dir := t.TempDir()
 	p := writeTempFile(t, dir, "test.json", `{"a":1}`)
 
-	res, err := TidyFile(p, "json", false)
+	res, err := TidyFile(p, "json", false, CSVOptions{SortColumns: true})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -318,3 +594,51 @@ func TestTidyResult_Path(t *testing.T) {
 		t.Errorf("expected path %s, got %s", p, res.Path)
 	}
 }
+
+// --- Idempotency ---
+
+func TestVerifyIdempotent_CraftedInputs(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		content string
+	}{
+		{"json", "json", `{"z":1.0,"big":12345678901234567890,"exp":1e2,"tiny":0.000001,"html":"<a href=\"x\">&</a>","uni":"é","nested":{"b":[3,1,2],"a":null}}`},
+		{"yaml", "yaml", "# comment\nz: 1.0\nbase: &base\n  b: yes\n  a: \"0123\"\nref: *base\ntext: |\n  line one\n  line two\nwhen: 2024-01-01\nempty: ~\n"},
+		{"csv", "csv", "name,id,note\nalpha,1,\"has, comma\"\nbeta,2,\"multi\nline\"\ngamma,3,\"say \"\"hi\"\"\"\n"},
+		{"text", "text", "line one \r\n\r\nline two\r\n\r\n\r\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := writeTempFile(t, dir, "test."+tc.input, tc.content)
+
+			res, err := TidyFile(p, tc.input, false, CSVOptions{SortColumns: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !res.Changed {
+				t.Fatal("expected crafted input to change")
+			}
+			if err := VerifyIdempotent(tc.input, res.Tidied, CSVOptions{SortColumns: true}); err != nil {
+				t.Fatalf("tidy output is not idempotent: %v\n%s", err, res.Tidied)
+			}
+		})
+	}
+}
+
+func TestVerifyIdempotent_ReportsUnstableLine(t *testing.T) {
+	err := VerifyIdempotent("json", []byte("{\n  \"a\": 1,\n  \"b\":2\n}\n"), CSVOptions{SortColumns: true})
+	if err == nil {
+		t.Fatal("expected error for content that changes when re-tidied")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error to identify line 3, got: %v", err)
+	}
+}
+
+func TestVerifyIdempotent_UnsupportedFormat(t *testing.T) {
+	if err := VerifyIdempotent("xml", []byte("<a/>"), CSVOptions{SortColumns: true}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
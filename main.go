@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -10,9 +11,13 @@ import (
 	"strings"
 
 	"github.com/UnitVectorY-Labs/datacur8/internal/cli"
+	"github.com/UnitVectorY-Labs/datacur8/internal/config"
+	"github.com/UnitVectorY-Labs/datacur8/internal/tidy"
 )
 
 var Version = "dev" // This will be set by the build systems to the release version
+var Commit = ""     // Optionally set by the build system; falls back to the VCS revision
+var BuildDate = ""  // Optionally set by the build system; falls back to the VCS commit time
 var versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.-]+)?$`)
 
 func buildVersionOutput(projectName, version string) string {
@@ -24,6 +29,70 @@ func buildVersionOutput(projectName, version string) string {
 	return fmt.Sprintf("%s version %s (%s, %s/%s)", projectName, normalized, runtime.Version(), runtime.GOOS, runtime.GOARCH)
 }
 
+// versionInfo is the build metadata printed by `version --json`.
+type versionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// buildVersionInfo collects build metadata, preferring values set via ldflags
+// and falling back to the VCS settings recorded in the build info.
+func buildVersionInfo(version, commit, buildDate string, bi *debug.BuildInfo) versionInfo {
+	info := versionInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Commit:    commit,
+		BuildDate: buildDate,
+	}
+	if bi == nil {
+		return info
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// addReportFlags registers the reporting, --root, and --config flags shared by validate, export, tidy, and check.
+func addReportFlags(fs *flag.FlagSet) *cli.Options {
+	opts := &cli.Options{Version: Version}
+	fs.StringVar(&opts.Format, "format", "", "Output format: text, json, ndjson, yaml, or csv (default: text)")
+	fs.BoolVar(&opts.FormatByType, "format-by-type", false, "Group json/yaml output entries under their type name")
+	fs.StringVar(&opts.Color, "color", "auto", "Colorize text output: always, never, or auto (terminal and NO_COLOR unset)")
+	fs.StringVar(&opts.Root, "root", "", "Repository root holding .datacur8 (default: current directory)")
+	fs.StringVar(&opts.Config, "config", "", "Fetch the config from this http or https URL instead of reading .datacur8")
+	fs.StringVar(&opts.PathStyle, "path-style", "relative", "Report file paths relative to the repository root or as absolute paths: relative or absolute")
+	fs.BoolVar(&opts.SkipVersionCheck, "skip-version-check", false, "Do not fail when the config version is incompatible with this CLI version")
+	fs.BoolVar(&opts.LenientConfig, "lenient-config", false, "Ignore unknown top-level config keys with a warning instead of failing")
+	fs.StringVar(&opts.ReportFile, "report-file", "", "Also write the report to this file (json unless --format is ndjson, yaml, or csv), creating parent directories")
+	fs.BoolVar(&opts.ReportStdout, "report-stdout", true, "With --report-file, still print the report to stdout (stderr for text); false writes it only to the file")
+	return opts
+}
+
+// addFilesFromFlag registers the --files-from flag used by commands that can
+// work on an explicit list of files instead of walking the tree.
+func addFilesFromFlag(fs *flag.FlagSet, opts *cli.Options) {
+	fs.StringVar(&opts.FilesFrom, "files-from", "", "Only consider the newline-delimited paths listed in this file instead of walking the tree")
+}
+
+// addPipelineFlags registers the --jobs, --profile, and --sort-output flags used by commands that parse data files.
+func addPipelineFlags(fs *flag.FlagSet, opts *cli.Options) {
+	fs.IntVar(&opts.Jobs, "jobs", runtime.GOMAXPROCS(0), "Maximum number of files parsed concurrently (1 forces sequential parsing)")
+	fs.BoolVar(&opts.Profile, "profile", false, "Print a per-stage timing breakdown to stderr")
+	fs.BoolVar(&opts.SortOutput, "sort-output", false, "Sort report entries by level, file, row, and message instead of by stage")
+}
+
 func usage() {
 	fmt.Fprintln(os.Stderr, `Usage: datacur8 <command> [flags]
 
@@ -31,6 +100,11 @@ Commands:
   validate    Validate configuration and data files
   export      Export validated data to configured outputs
   tidy        Normalize file formatting for stable diffs
+  check       Run tidy in check mode and validate (for pre-commit hooks)
+  plan        Show which files, constraints, and outputs each type covers
+  infer       Print a candidate JSON Schema inferred from sample data files
+  config      Print the effective configuration (config dump)
+  schema      Print an embedded JSON Schema
   version     Print the version
 
 Run 'datacur8 <command> --help' for more information on a command.`)
@@ -68,14 +142,74 @@ Flags:`)
 			validateFlags.PrintDefaults()
 		}
 		configOnly := validateFlags.Bool("config-only", false, "Only validate configuration, not data files")
-		format := validateFlags.String("format", "", "Output format: text, json, or yaml (default: text)")
+		stdin := validateFlags.Bool("stdin", false, "Validate data read from stdin as a single file of the type given by --type")
+		typeName := validateFlags.String("type", "", "Type name used to parse and validate --stdin data")
+		opts := addReportFlags(validateFlags)
+		validateFlags.BoolVar(&opts.NoCache, "no-cache", false, "Ignore the validation cache and re-validate every file")
+		validateFlags.StringVar(&opts.Since, "since", "", "Only validate files changed since this git ref (plus untracked files)")
+		validateFlags.BoolVar(&opts.StrictConfig, "strict-config", false, "Report config lint warnings (unused capture groups, types without constraints or output) as errors")
+		validateFlags.BoolVar(&opts.Export, "export", false, "After a clean validation, export the already-parsed items as the export command would")
+		validateFlags.BoolVar(&opts.Fix, "fix", false, "Rewrite values that have a single mechanical fix (trimmed whitespace, enum case) in their files, then re-validate")
+		addPipelineFlags(validateFlags, opts)
+		addFilesFromFlag(validateFlags, opts)
 		validateFlags.Parse(os.Args[2:])
 		if validateFlags.NArg() > 0 {
 			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", validateFlags.Arg(0))
 			validateFlags.Usage()
 			os.Exit(1)
 		}
-		os.Exit(cli.RunValidate(*configOnly, *format, Version))
+		if opts.Jobs < 1 {
+			fmt.Fprintln(os.Stderr, "--jobs must be at least 1")
+			validateFlags.Usage()
+			os.Exit(1)
+		}
+		if *stdin != (*typeName != "") {
+			fmt.Fprintln(os.Stderr, "--stdin and --type must be used together")
+			validateFlags.Usage()
+			os.Exit(1)
+		}
+		if opts.Export {
+			// export needs every item, so the inputs must not be narrowed
+			conflict := ""
+			switch {
+			case *configOnly:
+				conflict = "--config-only"
+			case *stdin:
+				conflict = "--stdin"
+			case opts.Since != "":
+				conflict = "--since"
+			case opts.FilesFrom != "":
+				conflict = "--files-from"
+			}
+			if conflict != "" {
+				fmt.Fprintf(os.Stderr, "--export cannot be combined with %s\n", conflict)
+				validateFlags.Usage()
+				os.Exit(1)
+			}
+		}
+		if opts.Fix && (*configOnly || *stdin) {
+			flagName := "--config-only"
+			if *stdin {
+				flagName = "--stdin"
+			}
+			fmt.Fprintf(os.Stderr, "--fix cannot be combined with %s\n", flagName)
+			validateFlags.Usage()
+			os.Exit(1)
+		}
+		if *stdin {
+			if *configOnly {
+				fmt.Fprintln(os.Stderr, "--stdin cannot be combined with --config-only")
+				validateFlags.Usage()
+				os.Exit(1)
+			}
+			if opts.Since != "" {
+				fmt.Fprintln(os.Stderr, "--stdin cannot be combined with --since")
+				validateFlags.Usage()
+				os.Exit(1)
+			}
+			os.Exit(cli.RunValidateStdin(*typeName, os.Stdin, *opts))
+		}
+		os.Exit(cli.RunValidate(*configOnly, *opts))
 
 	case "export":
 		exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
@@ -88,14 +222,20 @@ if validation fails, export does not proceed.
 Flags:`)
 			exportFlags.PrintDefaults()
 		}
-		format := exportFlags.String("format", "", "Output format: text, json, or yaml (default: text)")
+		opts := addReportFlags(exportFlags)
+		addPipelineFlags(exportFlags, opts)
 		exportFlags.Parse(os.Args[2:])
 		if exportFlags.NArg() > 0 {
 			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", exportFlags.Arg(0))
 			exportFlags.Usage()
 			os.Exit(1)
 		}
-		os.Exit(cli.RunExport(*format, Version))
+		if opts.Jobs < 1 {
+			fmt.Fprintln(os.Stderr, "--jobs must be at least 1")
+			exportFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(cli.RunExport(*opts))
 
 	case "tidy":
 		tidyFlags := flag.NewFlagSet("tidy", flag.ExitOnError)
@@ -103,22 +243,206 @@ Flags:`)
 			fmt.Fprintln(os.Stderr, `Usage: datacur8 tidy [flags]
 
 Normalize file formatting for stable diffs. Default mode is check-only,
-which prints a colored diff and exits non-zero if changes are needed.
+which prints a diff (colored per --color) and exits non-zero if changes are needed.
 
 Flags:`)
 			tidyFlags.PrintDefaults()
 		}
 		write := tidyFlags.Bool("write", false, "Rewrite files in place (default is check-only diff mode)")
-		format := tidyFlags.String("format", "", "Output format: text, json, or yaml (default: text)")
+		verify := tidyFlags.Bool("verify", false, "With --write, re-tidy rewritten files and fail if the output is not stable")
+		diffContext := tidyFlags.Int("diff-context", tidy.DefaultDiffContext, "Unchanged lines shown around each change in check-mode diffs")
+		fullDiff := tidyFlags.Bool("full-diff", false, "Show every line of each changed file in check-mode diffs")
+		opts := addReportFlags(tidyFlags)
+		addFilesFromFlag(tidyFlags, opts)
 		tidyFlags.Parse(os.Args[2:])
 		if tidyFlags.NArg() > 0 {
 			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", tidyFlags.Arg(0))
 			tidyFlags.Usage()
 			os.Exit(1)
 		}
-		os.Exit(cli.RunTidy(*write, *format, Version))
+		if *verify && !*write {
+			fmt.Fprintln(os.Stderr, "--verify requires --write")
+			tidyFlags.Usage()
+			os.Exit(1)
+		}
+		if *diffContext < 0 {
+			fmt.Fprintln(os.Stderr, "--diff-context must not be negative")
+			tidyFlags.Usage()
+			os.Exit(1)
+		}
+		opts.DiffContext = *diffContext
+		if *fullDiff {
+			opts.DiffContext = -1
+		}
+		os.Exit(cli.RunTidy(*write, *verify, *opts))
+
+	case "check":
+		checkFlags := flag.NewFlagSet("check", flag.ExitOnError)
+		checkFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 check [flags]
+
+Run tidy in check mode and then validate, for use in a pre-commit hook.
+Files that need formatting are listed rather than diffed. Exits with the
+highest-priority failure of the two (config, discovery, data, then tidy).
+
+Flags:`)
+			checkFlags.PrintDefaults()
+		}
+		opts := addReportFlags(checkFlags)
+		checkFlags.BoolVar(&opts.Quiet, "quiet", false, "Print only failures, without the per-stage summary")
+		checkFlags.BoolVar(&opts.NoCache, "no-cache", false, "Ignore the validation cache and re-validate every file")
+		addPipelineFlags(checkFlags, opts)
+		addFilesFromFlag(checkFlags, opts)
+		checkFlags.Parse(os.Args[2:])
+		if checkFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", checkFlags.Arg(0))
+			checkFlags.Usage()
+			os.Exit(1)
+		}
+		if opts.Jobs < 1 {
+			fmt.Fprintln(os.Stderr, "--jobs must be at least 1")
+			checkFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(cli.RunCheck(*opts))
+
+	case "infer":
+		inferFlags := flag.NewFlagSet("infer", flag.ExitOnError)
+		inferFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 infer --type <glob or dir> [flags]
+
+Print a candidate JSON Schema inferred from existing JSON, JSON5, and YAML
+files, to paste into a type's schema in .datacur8. A directory is searched
+recursively. Properties present in every file are listed as required.
+
+Flags:`)
+			inferFlags.PrintDefaults()
+		}
+		target := inferFlags.String("type", "", "Glob pattern or directory selecting the sample data files")
+		opts := &cli.Options{Version: Version}
+		inferFlags.StringVar(&opts.Format, "format", "", "Output format: yaml or json (default: yaml)")
+		inferFlags.Parse(os.Args[2:])
+		if inferFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", inferFlags.Arg(0))
+			inferFlags.Usage()
+			os.Exit(1)
+		}
+		if *target == "" {
+			fmt.Fprintln(os.Stderr, "--type is required")
+			inferFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(cli.RunInfer(*target, *opts))
+
+	case "plan":
+		planFlags := flag.NewFlagSet("plan", flag.ExitOnError)
+		planFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 plan [flags]
+
+Print what validate and export would do: the files matched per type, the
+constraints that apply, and the output targets. Validates the configuration
+and runs discovery, but does not parse data files or write anything.
+
+Flags:`)
+			planFlags.PrintDefaults()
+		}
+		opts := &cli.Options{Version: Version}
+		planFlags.StringVar(&opts.Format, "format", "", "Output format: text or json (default: text)")
+		planFlags.StringVar(&opts.Root, "root", "", "Repository root holding .datacur8 (default: current directory)")
+		planFlags.StringVar(&opts.Config, "config", "", "Fetch the config from this http or https URL instead of reading .datacur8")
+		addFilesFromFlag(planFlags, opts)
+		planFlags.BoolVar(&opts.Verbose, "verbose", false, "List each matched file, and the files each type's match.exclude rules out")
+		planFlags.BoolVar(&opts.SkipVersionCheck, "skip-version-check", false, "Do not fail when the config version is incompatible with this CLI version")
+		planFlags.BoolVar(&opts.LenientConfig, "lenient-config", false, "Ignore unknown top-level config keys with a warning instead of failing")
+		planFlags.Parse(os.Args[2:])
+		if planFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", planFlags.Arg(0))
+			planFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(cli.RunPlan(*opts))
+
+	case "config":
+		configFlags := flag.NewFlagSet("config", flag.ExitOnError)
+		configFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 config dump [flags]
+
+Print the effective configuration: .datacur8 with extends and types_include
+merged and defaults applied. The config is validated first.
+
+Flags:`)
+			configFlags.PrintDefaults()
+		}
+		opts := &cli.Options{Version: Version}
+		configFlags.StringVar(&opts.Format, "format", "", "Output format: yaml or json (default: yaml)")
+		configFlags.StringVar(&opts.Root, "root", "", "Repository root holding .datacur8 (default: current directory)")
+		configFlags.StringVar(&opts.Config, "config", "", "Fetch the config from this http or https URL instead of reading .datacur8")
+		configFlags.BoolVar(&opts.SkipVersionCheck, "skip-version-check", false, "Do not fail when the config version is incompatible with this CLI version")
+		configFlags.BoolVar(&opts.LenientConfig, "lenient-config", false, "Ignore unknown top-level config keys with a warning instead of failing")
+		if len(os.Args) < 3 || os.Args[2] != "dump" {
+			configFlags.Usage()
+			os.Exit(1)
+		}
+		configFlags.Parse(os.Args[3:])
+		if configFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", configFlags.Arg(0))
+			configFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(cli.RunConfigDump(*opts))
+
+	case "schema":
+		schemaFlags := flag.NewFlagSet("schema", flag.ExitOnError)
+		schemaFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 schema <name>
+
+Print an embedded JSON Schema to stdout.
+
+Schemas:
+  config      Schema of the .datacur8 configuration file
+  report      Schema of the --format json report output`)
+		}
+		schemaFlags.Parse(os.Args[2:])
+		if schemaFlags.NArg() != 1 {
+			schemaFlags.Usage()
+			os.Exit(1)
+		}
+		switch schemaFlags.Arg(0) {
+		case "config":
+			os.Stdout.Write(config.Schema())
+		case "report":
+			os.Stdout.Write(cli.ReportSchema())
+		default:
+			fmt.Fprintf(os.Stderr, "unknown schema %q\n\n", schemaFlags.Arg(0))
+			schemaFlags.Usage()
+			os.Exit(1)
+		}
+		os.Exit(0)
 
 	case "version":
+		versionFlags := flag.NewFlagSet("version", flag.ExitOnError)
+		versionFlags.Usage = func() {
+			fmt.Fprintln(os.Stderr, `Usage: datacur8 version [flags]
+
+Print the version.
+
+Flags:`)
+			versionFlags.PrintDefaults()
+		}
+		asJSON := versionFlags.Bool("json", false, "Print version, Go version, commit, and build date as JSON")
+		versionFlags.Parse(os.Args[2:])
+		if versionFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "unexpected argument: %s\n", versionFlags.Arg(0))
+			versionFlags.Usage()
+			os.Exit(1)
+		}
+		if *asJSON {
+			bi, _ := debug.ReadBuildInfo()
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(buildVersionInfo(Version, Commit, BuildDate, bi))
+			os.Exit(0)
+		}
 		fmt.Println(buildVersionOutput("datacur8", Version))
 		os.Exit(0)
 
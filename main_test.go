@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"runtime"
+	"runtime/debug"
 	"testing"
 )
 
@@ -47,3 +48,28 @@ func TestBuildVersionOutputAddsVPrefixForPrerelease(t *testing.T) {
 		t.Fatalf("unexpected version output: got %q, want %q", got, want)
 	}
 }
+
+func TestBuildVersionInfoFallsBackToVCSSettings(t *testing.T) {
+	bi := &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "abc123"},
+		{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+	}}
+
+	got := buildVersionInfo("1.2.3", "", "", bi)
+	want := versionInfo{Version: "1.2.3", GoVersion: runtime.Version(), Commit: "abc123", BuildDate: "2024-05-01T12:00:00Z"}
+	if got != want {
+		t.Fatalf("unexpected version info: got %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildVersionInfoPrefersLdflags(t *testing.T) {
+	bi := &debug.BuildInfo{Settings: []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "abc123"},
+		{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+	}}
+
+	got := buildVersionInfo("1.2.3", "def456", "2024-06-01", bi)
+	if got.Commit != "def456" || got.BuildDate != "2024-06-01" {
+		t.Fatalf("expected ldflags values to win, got %+v", got)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -70,6 +72,972 @@ func TestVersionCommand(t *testing.T) {
 	}
 }
 
+func TestVersionCommandJSON(t *testing.T) {
+	cmd := exec.Command(binaryPath, "version", "--json")
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running version --json: %v\nstdout:\n%s\nstderr:\n%s", err, stdout.String(), stderr.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(stdout.String()), &got); err != nil {
+		t.Fatalf("parsing version --json output: %v\n%s", err, stdout.String())
+	}
+	for _, field := range []string{"version", "goVersion", "commit", "buildDate"} {
+		if _, ok := got[field]; !ok {
+			t.Errorf("version --json output missing field %q: %s", field, stdout.String())
+		}
+	}
+	if got["goVersion"] != runtime.Version() {
+		t.Errorf("goVersion = %v, want %q", got["goVersion"], runtime.Version())
+	}
+}
+
+func TestVersionCommandPlainIsSingleLine(t *testing.T) {
+	out, err := exec.Command(binaryPath, "version").Output()
+	if err != nil {
+		t.Fatalf("running version command: %v", err)
+	}
+	if strings.Count(string(out), "\n") != 1 || !strings.HasSuffix(string(out), "\n") {
+		t.Fatalf("expected a single line of output, got %q", out)
+	}
+}
+
+func TestSchemaReportCommand(t *testing.T) {
+	out, err := exec.Command(binaryPath, "schema", "report").Output()
+	if err != nil {
+		t.Fatalf("running schema report command: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(out, &schema); err != nil {
+		t.Fatalf("schema report output is not valid JSON: %v", err)
+	}
+	if _, ok := schema["$defs"]; !ok {
+		t.Fatalf("expected $defs in report schema, got keys %v", schema)
+	}
+}
+
+func TestSchemaConfigCommand(t *testing.T) {
+	out, err := exec.Command(binaryPath, "schema", "config").Output()
+	if err != nil {
+		t.Fatalf("running schema config command: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join(testsDir(), "..", "internal", "config", "config.schema.json"))
+	if err != nil {
+		t.Fatalf("reading config schema: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Fatal("schema config output does not match internal/config/config.schema.json")
+	}
+}
+
+func TestPlanCommand(t *testing.T) {
+	dir := filepath.Join(testsDir(), "export_combined_jsonl")
+
+	cmd := exec.Command(binaryPath, "plan", "--format", "json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running plan: %v", err)
+	}
+	var got struct {
+		Types []struct {
+			Name      string `json:"name"`
+			FileCount int    `json:"fileCount"`
+			Output    *struct {
+				Path string `json:"path"`
+			} `json:"output"`
+		} `json:"types"`
+		CombinedOutput struct {
+			Path string `json:"path"`
+		} `json:"combinedOutput"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("plan output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(got.Types) != 2 || got.Types[0].Name != "team" || got.Types[1].Name != "service" {
+		t.Fatalf("expected team and service in config order, got %s", out)
+	}
+	if got.Types[0].FileCount != 2 || got.Types[0].Output == nil || got.Types[0].Output.Path != "out/teams.json" {
+		t.Errorf("unexpected team plan: %s", out)
+	}
+	if got.Types[1].FileCount != 1 || got.Types[1].Output != nil {
+		t.Errorf("unexpected service plan: %s", out)
+	}
+	if got.CombinedOutput.Path != "out/all.jsonl" {
+		t.Errorf("combinedOutput = %q, want out/all.jsonl", got.CombinedOutput.Path)
+	}
+
+	cmd = exec.Command(binaryPath, "plan")
+	cmd.Dir = dir
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("running plan: %v", err)
+	}
+	want := "team (yaml): 2 files\n  output: out/teams.json (json)\nservice (json): 1 file\ncombined output: out/all.jsonl (jsonl)\n"
+	if string(out) != want {
+		t.Errorf("text plan:\n%s\nwant:\n%s", out, want)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("plan must not write outputs, stat out: %v", err)
+	}
+}
+
+func TestInferCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"data/a.json":     `{"id": "a", "name": "Alpha", "size": 3}`,
+		"data/sub/b.yaml": "id: b\nsize: 4\n",
+		"data/notes.txt":  "not sampled",
+	})
+
+	cmd := exec.Command(binaryPath, "infer", "--type", "data", "--format", "json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running infer: %v", err)
+	}
+	var got struct {
+		Type       string                       `json:"type"`
+		Properties map[string]map[string]string `json:"properties"`
+		Required   []string                     `json:"required"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("infer output is not valid JSON: %v\n%s", err, out)
+	}
+	if got.Type != "object" || !slices.Equal(got.Required, []string{"id", "size"}) {
+		t.Errorf("unexpected schema: %s", out)
+	}
+	if got.Properties["name"]["type"] != "string" || got.Properties["size"]["type"] != "integer" {
+		t.Errorf("unexpected property types: %s", out)
+	}
+
+	cmd = exec.Command(binaryPath, "infer", "--type", "missing/*.json")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		t.Errorf("expected infer to fail when no files match")
+	}
+}
+
+func TestValidateProfileListsStages(t *testing.T) {
+	cmd := exec.Command(binaryPath, "validate", "--profile")
+	cmd.Dir = filepath.Join(testsDir(), "valid_json_basic")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("running validate --profile: %v\nstderr:\n%s", err, stderr.String())
+	}
+	for _, stage := range []string{"config", "discovery", "parse", "schema", "constraints", "total"} {
+		if !strings.Contains(stderr.String(), "  "+stage+" ") {
+			t.Errorf("profile output missing stage %q:\n%s", stage, stderr.String())
+		}
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		caseName  string
+		args      []string
+		wantColor bool
+	}{
+		{"validate always", "invalid_foreign_key", []string{"validate", "--color=always"}, true},
+		{"validate never", "invalid_foreign_key", []string{"validate", "--color=never"}, false},
+		{"validate auto piped", "invalid_foreign_key", []string{"validate"}, false},
+		{"tidy always", "tidy_json", []string{"tidy", "--color=always"}, true},
+		{"tidy never", "tidy_json", []string{"tidy", "--color=never"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(binaryPath, tt.args...)
+			cmd.Dir = filepath.Join(testsDir(), tt.caseName)
+			var stderr strings.Builder
+			cmd.Stderr = &stderr
+			_ = cmd.Run()
+
+			if stderr.Len() == 0 {
+				t.Fatal("expected output on stderr")
+			}
+			if got := strings.Contains(stderr.String(), "\x1b["); got != tt.wantColor {
+				t.Fatalf("ANSI present = %v, want %v\nstderr:\n%q", got, tt.wantColor, stderr.String())
+			}
+		})
+	}
+}
+
+func TestColorFlagInvalid(t *testing.T) {
+	cmd := exec.Command(binaryPath, "validate", "--color=sometimes")
+	cmd.Dir = filepath.Join(testsDir(), "valid_json_basic")
+	out, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != cli.ExitConfigInvalid {
+		t.Fatalf("expected exit %d, got %v\n%s", cli.ExitConfigInvalid, err, out)
+	}
+	if !strings.Contains(string(out), `--color "sometimes" is not valid`) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func runValidateStdin(t *testing.T, caseName, typeName, input string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(binaryPath, "validate", "--stdin", "--type", typeName)
+	cmd.Dir = filepath.Join(testsDir(), caseName)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	code := 0
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatalf("running validate --stdin: %v", err)
+		}
+		code = exitErr.ExitCode()
+	}
+	return code, stderr.String()
+}
+
+func TestValidateStdin(t *testing.T) {
+	code, stderr := runValidateStdin(t, "valid_json_basic", "widget", `{"id": "w9", "label": "Nine"}`)
+	if code != cli.ExitOK {
+		t.Fatalf("valid stdin exit = %d, want %d\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+
+	code, stderr = runValidateStdin(t, "valid_json_basic", "widget", `{"id": 9}`)
+	if code != cli.ExitDataInvalid {
+		t.Fatalf("invalid stdin exit = %d, want %d\nstderr:\n%s", code, cli.ExitDataInvalid, stderr)
+	}
+	if !strings.Contains(stderr, "error: [widget] <stdin> ") {
+		t.Fatalf("expected schema error for <stdin>, got:\n%s", stderr)
+	}
+
+	code, stderr = runValidateStdin(t, "valid_json_basic", "widget", `{"id": `)
+	if code != cli.ExitDataInvalid || !strings.Contains(stderr, "<stdin>") {
+		t.Fatalf("malformed stdin exit = %d, want %d\nstderr:\n%s", code, cli.ExitDataInvalid, stderr)
+	}
+
+	code, stderr = runValidateStdin(t, "valid_json_basic", "gadget", `{}`)
+	if code != cli.ExitConfigInvalid || !strings.Contains(stderr, `--type "gadget" does not match any defined type`) {
+		t.Fatalf("unknown type exit = %d, want %d\nstderr:\n%s", code, cli.ExitConfigInvalid, stderr)
+	}
+}
+
+func TestValidateStdinSkipsCrossFileConstraints(t *testing.T) {
+	code, stderr := runValidateStdin(t, "example_readme_quick_start_success", "app",
+		"id: 7\nname: Seven\nowner:\n  teamId: 99\n")
+	if code != cli.ExitOK {
+		t.Fatalf("exit = %d, want %d\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+	for _, want := range []string{
+		`warning: [app] <stdin> foreign_key constraint #1 skipped: needs items of type "team"`,
+		"warning: [app] <stdin> path_equals_attr constraint #2 skipped: path captures are not available for stdin",
+		"warning: [app] <stdin> path_equals_attr constraint #3 skipped",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in stderr:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestValidateStdinSkipsPathCaptureConstraints(t *testing.T) {
+	code, stderr := runValidateStdin(t, "invalid_unique_path_key", "site", "name: North\n")
+	if code != cli.ExitOK {
+		t.Fatalf("exit = %d, want %d\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+	want := "warning: [site] <stdin> unique constraint one-site-per-region skipped: path captures are not available for stdin"
+	if !strings.Contains(stderr, want) {
+		t.Errorf("expected %q in stderr:\n%s", want, stderr)
+	}
+	if strings.Contains(stderr, "did not resolve") {
+		t.Errorf("expected no dead selector warning for a skipped constraint:\n%s", stderr)
+	}
+}
+
+func TestValidateStdinNoDeadSelectorWarning(t *testing.T) {
+	// one item says nothing about the selectors of the rest of the type
+	code, stderr := runValidateStdin(t, "dead_constraint_warning", "user", "username: carol\nemail: carol@example.com\n")
+	if code != cli.ExitOK {
+		t.Fatalf("exit = %d, want %d\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+	if strings.Contains(stderr, "did not resolve") {
+		t.Errorf("expected no dead selector warning for stdin:\n%s", stderr)
+	}
+}
+
+func TestRootFlag(t *testing.T) {
+	// run executes datacur8 from an unrelated working directory without a
+	// .datacur8 and returns the exit code, stdout, and stderr.
+	run := func(t *testing.T, args ...string) (int, string, string) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, args...)
+		cmd.Dir = t.TempDir()
+		var stdout, stderr strings.Builder
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		code := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("running %v: %v", args, err)
+			}
+			code = exitErr.ExitCode()
+		}
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("validate valid", func(t *testing.T) {
+		code, _, stderr := run(t, "validate", "--root", filepath.Join(testsDir(), "valid_json_basic"))
+		if code != cli.ExitOK {
+			t.Fatalf("exit = %d, want %d\n%s", code, cli.ExitOK, stderr)
+		}
+	})
+
+	t.Run("validate invalid", func(t *testing.T) {
+		caseDir := filepath.Join(testsDir(), "invalid_foreign_key")
+		code, stdout, stderr := run(t, "validate", "--root", caseDir, "--format", "json")
+		if code != readExpectedExit(t, filepath.Join(caseDir, "expected", "validate.exit")) {
+			t.Fatalf("unexpected exit %d\n%s", code, stderr)
+		}
+		expected, err := os.ReadFile(filepath.Join(caseDir, "expected", "validate.stdout"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareJSON(t, "stdout", stdout, string(expected))
+	})
+
+	t.Run("export", func(t *testing.T) {
+		root := t.TempDir()
+		copyDir(t, filepath.Join(testsDir(), "example_examples_multi_format_export_json"), root)
+		if code, _, stderr := run(t, "export", "--root", root); code != cli.ExitOK {
+			t.Fatalf("exit = %d, want %d\n%s", code, cli.ExitOK, stderr)
+		}
+		requireFile(t, filepath.Join(root, "out", "widgets.json"), "export output under --root")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "nope")
+		code, _, stderr := run(t, "validate", "--root", missing)
+		if code != cli.ExitConfigInvalid || !strings.Contains(stderr, "does not exist") {
+			t.Fatalf("exit = %d, want %d\n%s", code, cli.ExitConfigInvalid, stderr)
+		}
+	})
+
+	t.Run("no config", func(t *testing.T) {
+		code, _, stderr := run(t, "validate", "--root", t.TempDir())
+		if code != cli.ExitConfigInvalid || !strings.Contains(stderr, ".datacur8 not found in --root directory") {
+			t.Fatalf("exit = %d, want %d\n%s", code, cli.ExitConfigInvalid, stderr)
+		}
+	})
+}
+
+func TestValidateStrictConfig(t *testing.T) {
+	run := func(args ...string) (int, string) {
+		cmd := exec.Command(binaryPath, append([]string{"validate"}, args...)...)
+		cmd.Dir = filepath.Join(testsDir(), "example_readme_quick_start_success")
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("running validate %v: %v", args, err)
+			}
+			return exitErr.ExitCode(), stderr.String()
+		}
+		return 0, stderr.String()
+	}
+
+	const lint = `match.include[0] named group "app" is not used by any path_selector`
+
+	code, stderr := run()
+	if code != cli.ExitOK || !strings.Contains(stderr, "warning: types[1](app): "+lint) {
+		t.Fatalf("exit = %d, want %d with lint warning\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+
+	code, stderr = run("--strict-config")
+	if code != cli.ExitConfigInvalid || !strings.Contains(stderr, lint) || strings.Contains(stderr, "warning: types[1](app)") {
+		t.Fatalf("exit = %d, want %d with lint error\nstderr:\n%s", code, cli.ExitConfigInvalid, stderr)
+	}
+}
+
+// gitRepo creates a git repository in a temp directory containing files and
+// commits them.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDiscoveryErrorExitCode(t *testing.T) {
+	for _, fixture := range []string{"multi_type_match", "subdirectory_datacur8"} {
+		for _, command := range []string{"validate", "export", "tidy"} {
+			t.Run(fixture+"/"+command, func(t *testing.T) {
+				dir := t.TempDir()
+				copyDir(t, filepath.Join(testsDir(), fixture), dir)
+
+				cmd := exec.Command(binaryPath, command)
+				cmd.Dir = dir
+				out, err := cmd.CombinedOutput()
+				exitErr, ok := err.(*exec.ExitError)
+				if !ok || exitErr.ExitCode() != cli.ExitDiscoveryError {
+					t.Fatalf("%s exit = %v, want %d\noutput:\n%s", command, err, cli.ExitDiscoveryError, out)
+				}
+				if !strings.Contains(string(out), "error: [discovery]") {
+					t.Errorf("expected discovery error in output:\n%s", out)
+				}
+			})
+		}
+	}
+}
+
+func TestPathStyle(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "invalid_unique_constraint"), dir)
+
+	files := func(args ...string) []string {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append([]string{"validate", "--format", "json"}, args...)...)
+		cmd.Dir = dir
+		out, _ := cmd.Output()
+		var entries []struct {
+			File string `json:"file"`
+		}
+		if err := json.Unmarshal(out, &entries); err != nil {
+			t.Fatalf("parsing report: %v\n%s", err, out)
+		}
+		var paths []string
+		for _, e := range entries {
+			paths = append(paths, e.File)
+		}
+		return paths
+	}
+
+	if got, want := files(), []string{"data/a.json", "data/b.json"}; !slices.Equal(got, want) {
+		t.Errorf("default paths = %v, want %v", got, want)
+	}
+	want := []string{filepath.Join(dir, "data", "a.json"), filepath.Join(dir, "data", "b.json")}
+	if got := files("--path-style", "absolute"); !slices.Equal(got, want) {
+		t.Errorf("absolute paths = %v, want %v", got, want)
+	}
+}
+
+func TestReportFile(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "invalid_unique_constraint"), dir)
+	reportPath := filepath.Join(t.TempDir(), "artifacts", "report.json")
+
+	run := func(args ...string) (string, []map[string]any) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append([]string{"validate", "--no-cache", "--report-file", reportPath}, args...)...)
+		cmd.Dir = dir
+		var stdout strings.Builder
+		cmd.Stdout = &stdout
+		err := cmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != cli.ExitDataInvalid {
+			t.Fatalf("exit = %v, want %d", err, cli.ExitDataInvalid)
+		}
+		data, err := os.ReadFile(reportPath)
+		if err != nil {
+			t.Fatalf("reading report file: %v", err)
+		}
+		var entries []map[string]any
+		if err := json.Unmarshal(data, &entries); err != nil {
+			t.Fatalf("report file is not valid JSON: %v\n%s", err, data)
+		}
+		return stdout.String(), entries
+	}
+
+	stdout, entries := run("--format", "json")
+	if len(entries) != 2 || entries[0]["file"] != "data/a.json" || entries[1]["file"] != "data/b.json" {
+		t.Errorf("unexpected report file entries: %v", entries)
+	}
+	var stdoutEntries []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &stdoutEntries); err != nil || !reflect.DeepEqual(stdoutEntries, entries) {
+		t.Errorf("stdout report %q (%v) does not match report file entries %v", stdout, err, entries)
+	}
+
+	// the default text format still writes JSON to the file
+	if stdout, entries = run(); len(entries) != 2 || stdout != "" {
+		t.Errorf("text run: stdout %q, entries %v", stdout, entries)
+	}
+
+	if stdout, entries = run("--format", "json", "--report-stdout=false"); len(entries) != 2 || stdout != "" {
+		t.Errorf("--report-stdout=false: stdout %q, entries %v", stdout, entries)
+	}
+
+	// a clean run leaves an empty report
+	dir = t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "valid_json_basic"), dir)
+	cmd := exec.Command(binaryPath, "validate", "--report-file", reportPath)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("validate failed: %v\n%s", err, out)
+	}
+	if data, err := os.ReadFile(reportPath); err != nil || strings.TrimSpace(string(data)) != "[]" {
+		t.Errorf("clean report file = %q (%v), want []", data, err)
+	}
+}
+
+func TestValidateFilesFrom(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "invalid_unique_constraint"), dir)
+	// a.json and b.json share an id; listing only one of them hides the duplicate
+	list := filepath.Join(t.TempDir(), "files.txt")
+	if err := os.WriteFile(list, []byte("data/a.json\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binaryPath, "validate", "--files-from", list, "--no-cache")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("expected only the listed file to be validated, got %v\n%s", err, out)
+	}
+
+	if err := os.WriteFile(list, []byte("data/a.json\ndata/missing.json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd = exec.Command(binaryPath, "validate", "--files-from", list, "--no-cache")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != cli.ExitDiscoveryError {
+		t.Fatalf("exit = %v, want %d\noutput:\n%s", err, cli.ExitDiscoveryError, out)
+	}
+	if !strings.Contains(string(out), `listed file "data/missing.json" does not exist`) {
+		t.Errorf("expected missing file error in output:\n%s", out)
+	}
+}
+
+func TestValidateExport(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "input_auto"), dir)
+
+	cmd := exec.Command(binaryPath, "validate", "--export", "--no-cache")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("validate --export failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "exported 3 items to out/docs.json (json)") {
+		t.Errorf("expected export summary in output:\n%s", out)
+	}
+	want, err := os.ReadFile(filepath.Join(testsDir(), "input_auto", "expected", "export", "out", "docs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "out", "docs.json")); err != nil || string(got) != string(want) {
+		t.Errorf("out/docs.json = %q (%v), want %q", got, err, want)
+	}
+
+	// a failed validation exports nothing
+	dir = t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "example_readme_quick_start_unique_id_failure"), dir)
+	cmd = exec.Command(binaryPath, "validate", "--export", "--no-cache")
+	cmd.Dir = dir
+	out, err = cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != cli.ExitDataInvalid {
+		t.Fatalf("exit = %v, want %d\noutput:\n%s", err, cli.ExitDataInvalid, out)
+	}
+	if strings.Contains(string(out), "exported") {
+		t.Errorf("expected no export after failed validation:\n%s", out)
+	}
+
+	// without outputs, validate --export succeeds like export does
+	dir = t.TempDir()
+	copyDir(t, filepath.Join(testsDir(), "constraint_severity_warning"), dir)
+	cmd = exec.Command(binaryPath, "validate", "--export", "--no-cache")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil || !strings.Contains(string(out), "no types define output") {
+		t.Errorf("expected no-output notice, got %v\n%s", err, out)
+	}
+}
+
+func TestValidateNDJSON(t *testing.T) {
+	caseDir := filepath.Join(testsDir(), "invalid_file_exists")
+	run := func(format string) string {
+		cmd := exec.Command(binaryPath, "validate", "--no-cache", "--format", format)
+		cmd.Dir = caseDir
+		out, err := cmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != cli.ExitDataInvalid {
+			t.Fatalf("--format %s exit = %v, want %d", format, err, cli.ExitDataInvalid)
+		}
+		return string(out)
+	}
+
+	var want []map[string]any
+	if err := json.Unmarshal([]byte(run("json")), &want); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(run("ndjson"), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d ndjson lines, want %d entries:\n%s", len(lines), len(want), strings.Join(lines, "\n"))
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d does not parse on its own: %v\n%s", i, err, line)
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("line %d = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestValidateFix(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".datacur8": `version: "0.0.0"
+types:
+  - name: user
+    input: yaml
+    match:
+      include: ["^users/.*\\.yaml$"]
+    schema:
+      type: object
+      properties:
+        email: { type: string }
+    constraints:
+      - type: format
+        key: $.email
+        format: email
+`,
+		"users/alice.yaml": "# owner\nemail: \"  alice@example.com \"  # trimmed by --fix\n",
+		"users/bob.yaml":   "email: not an email\n",
+	})
+
+	cmd := exec.Command(binaryPath, "validate", "--fix", "--no-cache")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != cli.ExitDataInvalid {
+		t.Fatalf("exit = %v, want %d\noutput:\n%s", err, cli.ExitDataInvalid, out)
+	}
+	if !strings.Contains(string(out), `fixed: users/alice.yaml: $.email "  alice@example.com " -> "alice@example.com" (trimmed whitespace)`) {
+		t.Errorf("expected the fix to be printed:\n%s", out)
+	}
+	// only the violation without a fix is reported after re-validating
+	if strings.Contains(string(out), "alice@example.com \" for key") || !strings.Contains(string(out), `value "not an email" for key $.email is not a valid email`) {
+		t.Errorf("expected only bob.yaml to be reported:\n%s", out)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dir, "users", "alice.yaml")); string(got) != "# owner\nemail: \"alice@example.com\"  # trimmed by --fix\n" {
+		t.Errorf("users/alice.yaml = %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "users", "bob.yaml")); string(got) != "email: not an email\n" {
+		t.Errorf("users/bob.yaml was changed: %q", got)
+	}
+}
+
+func TestValidateSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := gitRepo(t, map[string]string{
+		".datacur8": `version: "0.0.0"
+types:
+  - name: team
+    input: json
+    match:
+      include: ["^teams/.*\\.json$"]
+    schema:
+      type: object
+      required: [id]
+      properties:
+        id: { type: string }
+    constraints:
+      - type: unique
+        key: "$.id"
+  - name: service
+    input: json
+    match:
+      include: ["^services/.*\\.json$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+        team: { type: string }
+    constraints:
+      - type: foreign_key
+        key: "$.team"
+        references: { type: team, key: "$.id" }
+`,
+		"teams/a.json":      `{"id": "a"}`,
+		"teams/broken.json": `{"id": 1}`,
+		"services/s1.json":  `{"id": "s1", "team": "a"}`,
+	})
+
+	run := func(args ...string) (int, string, string) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, append([]string{"validate"}, args...)...)
+		cmd.Dir = dir
+		var stdout, stderr strings.Builder
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		code := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("running validate: %v", err)
+			}
+			code = exitErr.ExitCode()
+		}
+		return code, stdout.String(), stderr.String()
+	}
+
+	// Without --since the committed invalid team file fails validation.
+	if code, _, _ := run(); code != cli.ExitDataInvalid {
+		t.Fatalf("full validate exit = %d, want %d", code, cli.ExitDataInvalid)
+	}
+
+	// Only the changed team file is parsed; the unchanged invalid one is not.
+	writeFiles(t, dir, map[string]string{"teams/a.json": `{"id": "a" }`})
+	code, _, stderr := run("--since", "HEAD", "--profile", "--format", "json")
+	if code != cli.ExitOK {
+		t.Fatalf("--since exit = %d, want %d\nstderr:\n%s", code, cli.ExitOK, stderr)
+	}
+	var prof struct {
+		Stages []struct {
+			Stage string `json:"stage"`
+			Files int    `json:"files"`
+		} `json:"stages"`
+	}
+	if err := json.Unmarshal([]byte(stderr[strings.Index(stderr, "{"):]), &prof); err != nil {
+		t.Fatalf("parsing profile: %v\n%s", err, stderr)
+	}
+	for _, s := range prof.Stages {
+		if s.Stage == "parse" && s.Files != 1 {
+			t.Errorf("parse stage files = %d, want 1", s.Files)
+		}
+	}
+
+	code, stdout, _ := run("--since", "HEAD", "--format", "json")
+	if code != cli.ExitOK || !strings.Contains(stdout, "unique constraint #0 is only checked among changed files with --since") {
+		t.Fatalf("expected unique warning, exit %d:\n%s", code, stdout)
+	}
+
+	// A changed service resolves foreign keys against unchanged teams, and
+	// errors in those reference-only files are not reported.
+	writeFiles(t, dir, map[string]string{
+		"services/s1.json": `{"id": "s1", "team": "a" }`,
+		"services/s2.json": `{"id": "s2", "team": "zz"}`,
+	})
+	code, stdout, _ = run("--since", "HEAD", "--format", "json")
+	if code != cli.ExitDataInvalid {
+		t.Fatalf("--since exit = %d, want %d\n%s", code, cli.ExitDataInvalid, stdout)
+	}
+	if !strings.Contains(stdout, `foreign key \"zz\" not found`) || strings.Contains(stdout, "s1.json") || strings.Contains(stdout, "broken.json") {
+		t.Fatalf("unexpected report:\n%s", stdout)
+	}
+
+	if code, _, stderr := run("--since", "no-such-ref"); code != cli.ExitConfigInvalid || !strings.Contains(stderr, `--since "no-such-ref"`) {
+		t.Fatalf("bad ref exit = %d, want %d\nstderr:\n%s", code, cli.ExitConfigInvalid, stderr)
+	}
+}
+
+func TestValidateSince_NoDeadSelectorWarning(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := gitRepo(t, map[string]string{
+		".datacur8": `version: "0.0.0"
+types:
+  - name: user
+    input: json
+    match:
+      include: ["^users/.*\\.json$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+        email: { type: string }
+    constraints:
+      - type: unique
+        key: "$.email"
+`,
+		"users/a.json": `{"id": "a", "email": "a@example.com"}`,
+		"users/b.json": `{"id": "b"}`,
+	})
+
+	// Only b.json, without the optional email, is loaded; the constraint is
+	// not dead, it just has nothing to check in the changed file.
+	writeFiles(t, dir, map[string]string{"users/b.json": `{"id": "b" }`})
+	cmd := exec.Command(binaryPath, "validate", "--since", "HEAD", "--format", "json")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("--since validate: %v\n%s", err, out)
+	}
+	if strings.Contains(string(out), "did not resolve") {
+		t.Errorf("unexpected dead selector warning:\n%s", out)
+	}
+}
+
+func TestValidateSince_SpecialCharacterPaths(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := gitRepo(t, map[string]string{
+		".datacur8": `version: "0.0.0"
+types:
+  - name: user
+    input: json
+    match:
+      include: ["^users/.*\\.json$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+`,
+		"users/zoë.json": `{"id": "zoë"}`,
+	})
+
+	// git quotes such names unless they are read NUL-separated.
+	writeFiles(t, dir, map[string]string{
+		"users/zoë.json":      `{"id": 1}`,
+		"users/new user.json": `{"id": 2}`,
+	})
+	cmd := exec.Command(binaryPath, "validate", "--since", "HEAD", "--format", "json")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != cli.ExitDataInvalid {
+		t.Fatalf("expected exit %d, got %v\n%s", cli.ExitDataInvalid, err, out)
+	}
+	for _, want := range []string{`"file": "users/zoë.json"`, `"file": "users/new user.json"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected %s in report:\n%s", want, out)
+		}
+	}
+}
+
+func TestValidateSince_WholeTypeConstraints(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := gitRepo(t, map[string]string{
+		".datacur8": `version: "0.0.0"
+types:
+  - name: seat
+    input: yaml
+    match:
+      include: ["^seats/.*\\.yaml$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+  - name: license
+    input: yaml
+    match:
+      include: ["^licenses/.*\\.yaml$"]
+    schema:
+      type: object
+      properties:
+        id: { type: string }
+    constraints:
+      - type: count_equals
+        references: { type: seat }
+  - name: release
+    input: yaml
+    match:
+      include: ["^releases/.*\\.yaml$"]
+    schema:
+      type: object
+      properties:
+        n: { type: integer }
+        format: { type: string }
+    constraints:
+      - type: sequence
+        key: "$.n"
+        start: 1
+      - type: all_equal
+        key: "$.format"
+`,
+		"seats/s1.yaml":    "id: s1\n",
+		"seats/s2.yaml":    "id: s2\n",
+		"licenses/l1.yaml": "id: l1\n",
+		"licenses/l2.yaml": "id: l2\n",
+		"releases/1.yaml":  "n: 1\nformat: v1\n",
+		"releases/2.yaml":  "n: 2\nformat: v1\n",
+		"releases/3.yaml":  "n: 3\nformat: v1\n",
+		"releases/4.yaml":  "n: 4\nformat: v1\n",
+		"releases/5.yaml":  "n: 5\nformat: v1\n",
+	})
+
+	run := func() (int, string) {
+		t.Helper()
+		cmd := exec.Command(binaryPath, "validate", "--since", "HEAD", "--format", "json")
+		cmd.Dir = dir
+		var stdout strings.Builder
+		cmd.Stdout = &stdout
+		code := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("running validate: %v", err)
+			}
+			code = exitErr.ExitCode()
+		}
+		return code, stdout.String()
+	}
+
+	// Counts, sequences, and shared values are checked against every item of
+	// the type, not just the changed files.
+	writeFiles(t, dir, map[string]string{
+		"seats/s1.yaml":   "id: s1 # renamed\n",
+		"releases/2.yaml": "n: 2 # edited\nformat: v1\n",
+		"releases/5.yaml": "n: 5 # edited\nformat: v1\n",
+	})
+	if code, stdout := run(); code != cli.ExitOK {
+		t.Fatalf("--since exit = %d, want %d\n%s", code, cli.ExitOK, stdout)
+	}
+
+	// A real mismatch in a changed file is still reported.
+	writeFiles(t, dir, map[string]string{
+		"seats/s3.yaml":   "id: s3\n",
+		"releases/5.yaml": "n: 6\nformat: v2\n",
+	})
+	code, stdout := run()
+	if code != cli.ExitDataInvalid {
+		t.Fatalf("--since exit = %d, want %d\n%s", code, cli.ExitDataInvalid, stdout)
+	}
+	for _, want := range []string{"license has 2 items but seat has 3", "[sequence]", "[all_equal]"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in report:\n%s", want, stdout)
+		}
+	}
+}
+
 func TestDataDrivenFixturesComplete(t *testing.T) {
 	root := testsDir()
 	entries, err := os.ReadDir(root)
@@ -126,7 +1094,7 @@ func TestDataDrivenFixturesComplete(t *testing.T) {
 				requireFile(
 					t,
 					snapshotPath,
-					fmt.Sprintf("missing expected export snapshot for configured output.path %q", outPath),
+					fmt.Sprintf("missing expected export snapshot for configured output path %q", outPath),
 				)
 			}
 		})
@@ -199,8 +1167,74 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+// TestCheck runs `datacur8 check` for cases that provide expected/check.exit,
+// comparing its exit code and, when snapshots exist, its stdout and stderr.
+func TestCheck(t *testing.T) {
+	root := testsDir()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("reading tests dir: %v", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		caseDir := filepath.Join(root, name)
+
+		exitFile := filepath.Join(caseDir, "expected", "check.exit")
+		if _, err := os.Stat(exitFile); os.IsNotExist(err) {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			expectedCode := readExpectedExit(t, exitFile)
+
+			args := []string{"check"}
+			if data, err := os.ReadFile(filepath.Join(caseDir, "expected", "check.args")); err == nil {
+				for a := range strings.FieldsSeq(strings.TrimSpace(string(data))) {
+					args = append(args, a)
+				}
+			}
+
+			cmd := exec.Command(binaryPath, args...)
+			cmd.Dir = caseDir
+			var stdout, stderr strings.Builder
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			err := cmd.Run()
+			actualCode := 0
+			if err != nil {
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					actualCode = exitErr.ExitCode()
+				} else {
+					t.Fatalf("running binary: %v", err)
+				}
+			}
+
+			if actualCode != expectedCode {
+				t.Errorf("check exit code = %d, want %d\nstdout:\n%s\nstderr:\n%s",
+					actualCode, expectedCode, stdout.String(), stderr.String())
+			}
+
+			if data, err := os.ReadFile(filepath.Join(caseDir, "expected", "check.stderr")); err == nil {
+				compareLines(t, "check stderr", stderr.String(), string(data))
+			}
+
+			if data, err := os.ReadFile(filepath.Join(caseDir, "expected", "check.stdout")); err == nil {
+				compareJSON(t, "check stdout", stdout.String(), string(data))
+			}
+		})
+	}
+}
+
 type fixtureConfig struct {
-	Types []fixtureType `yaml:"types"`
+	Types  []fixtureType `yaml:"types"`
+	Export *struct {
+		Combined *fixtureOutput `yaml:"combined"`
+	} `yaml:"export"`
 }
 
 type fixtureType struct {
@@ -233,6 +1267,9 @@ func configuredOutputPaths(configPath string) ([]string, error) {
 		}
 		paths = append(paths, path)
 	}
+	if cfg.Export != nil && cfg.Export.Combined != nil && strings.TrimSpace(cfg.Export.Combined.Path) != "" {
+		paths = append(paths, strings.TrimSpace(cfg.Export.Combined.Path))
+	}
 
 	return paths, nil
 }
@@ -422,7 +1459,7 @@ func TestTidy(t *testing.T) {
 				t.Fatalf("walking expected tidy dir to seed check assertions: %v", err)
 			}
 
-			checkCmd := exec.Command(binaryPath, "tidy")
+			checkCmd := exec.Command(binaryPath, "tidy", "--color=always")
 			checkCmd.Dir = checkDir
 			var checkStdout, checkStderr strings.Builder
 			checkCmd.Stdout = &checkStdout